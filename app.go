@@ -2,82 +2,236 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
+	"todo-list/internal/calendar"
+	"todo-list/internal/config"
 	"todo-list/internal/domain"
+	"todo-list/internal/importer"
+	"todo-list/internal/interchange"
+	"todo-list/internal/migrate"
+	"todo-list/internal/replication"
 	"todo-list/internal/repository"
-	"todo-list/internal/service"
 	"todo-list/internal/usecase"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 type App struct {
 	ctx         context.Context
+	settings    *config.Store
+	started     config.Config
 	taskUseCase *usecase.TaskUseCase
+	focus       *usecase.FocusTimer
+	taskRepo    repository.TaskRepository
+	syncRepo    *repository.HybridTaskRepository
+	dataDir     string
+	replicator  *replication.Syncer
+	peerAddr    string
+	peerSecret  string
+	feed        *calendar.FeedServer
+	caldav      *http.Server
 }
 
-func NewApp() *App {
-	var taskRepo repository.TaskRepository
+func NewApp(settings *config.Store) *App {
+	cfg := settings.Get()
+	st := openStorage(cfg)
 
-	pgConnStr := os.Getenv("POSTGRES_CONNECTION_STRING")
-	if pgConnStr != "" {
-		pgRepo, err := repository.NewPostgresTaskRepository(pgConnStr)
-		if err == nil {
-			taskRepo = pgRepo
-		} else {
-			println("Failed to connect to PostgreSQL:", err.Error())
-		}
+	replicator, err := newReplicationSyncer(cfg.Sync, st.dataDir, st.taskRepo)
+	if err != nil {
+		println("Failed to set up replication:", err.Error())
 	}
 
-	if taskRepo == nil {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			homeDir = "."
-		}
+	taskUseCase := st.taskUseCase(newSettingsClock(settings), sortLanguage(settings))
 
-		dataDir := filepath.Join(homeDir, ".todolist")
-		fileRepo, err := repository.NewFileTaskRepository(dataDir)
+	// The calendar feed stays on loopback unless feed.addr says otherwise;
+	// "off" disables it.
+	var feed *calendar.FeedServer
+	if cfg.Feed.Addr != "off" {
+		feed, err = newFeedServer(cfg.Feed.Addr, st.dataDir, taskUseCase)
 		if err != nil {
-			taskRepo = repository.NewMemoryTaskRepository()
-		} else {
-			taskRepo = fileRepo
+			println("Failed to start calendar feed:", err.Error())
 		}
 	}
 
-	taskService := service.NewTaskService(taskRepo)
-	taskUseCase := usecase.NewTaskUseCase(taskService)
-
 	return &App{
+		settings:    settings,
+		started:     cfg,
 		taskUseCase: taskUseCase,
+		focus:       usecase.NewFocusTimer(taskUseCase, focusDurations(settings)),
+		taskRepo:    st.taskRepo,
+		syncRepo:    st.syncRepo,
+		dataDir:     st.dataDir,
+		replicator:  replicator,
+		peerAddr:    cfg.Sync.Listen,
+		peerSecret:  cfg.Sync.Secret,
+		feed:        feed,
+		caldav:      newCalDAVServer(cfg.CalDAV, taskUseCase),
+	}
+}
+
+func newFeedServer(addr, dataDir string, taskUseCase *usecase.TaskUseCase) (*calendar.FeedServer, error) {
+	token, err := calendar.LoadFeedToken(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.NewFeedServer(addr, token, calendar.NewFeedHandler(taskUseCase, token))
+}
+
+// newCalDAVServer exposes tasks to CalDAV clients on the LAN. It listens on
+// all interfaces, so it only starts once caldav.password is set.
+func newCalDAVServer(cfg config.CalDAVConfig, taskUseCase *usecase.TaskUseCase) *http.Server {
+	if cfg.Password == "" {
+		return nil
+	}
+
+	addr := cfg.Addr
+	if addr == "" {
+		addr = calendar.DefaultCalDAVAddr
+	}
+	username := cfg.User
+	if username == "" {
+		username = "todo"
+	}
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: calendar.NewCalDAVHandler(taskUseCase, calendar.CalDAVOptions{Username: username, Password: cfg.Password}),
 	}
 }
 
+// newReplicationSyncer enables serverless multi-device sync when a shared
+// folder, a peer URL or a listen address is configured.
+func newReplicationSyncer(cfg config.SyncConfig, dataDir string, taskRepo repository.TaskRepository) (*replication.Syncer, error) {
+	if cfg.Folder == "" && cfg.Peer == "" && cfg.Listen == "" {
+		return nil, nil
+	}
+
+	replicaDir := filepath.Join(dataDir, "replica")
+	nodeID, err := replication.LoadNodeID(replicaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport replication.Transport
+	switch {
+	case cfg.Folder != "":
+		transport, err = replication.NewFolderTransport(cfg.Folder, nodeID)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.Peer != "":
+		transport = replication.NewHTTPTransport(cfg.Peer, cfg.Secret, nil)
+	default:
+		transport = replication.NopTransport{}
+	}
+
+	replica := replication.NewReplica(nodeID, replication.NewClock(nodeID, nil))
+	return replication.NewSyncer(replica, taskRepo, transport, filepath.Join(replicaDir, "state.json"))
+}
+
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	// Settings edited in the file apply while the app runs; the frontend
+	// picks them up from the event.
+	a.settings.OnChange(func(old, next config.Config) {
+		runtime.EventsEmit(ctx, "settings:changed", next)
+	})
+	go a.settings.Watch(ctx, 2*time.Second)
+
+	// The focus session counts down here rather than in the frontend, so it
+	// keeps time while the window is hidden.
+	a.focus.OnTick(func(state domain.FocusState) {
+		runtime.EventsEmit(ctx, "focus:tick", state)
+	})
+	a.focus.OnPhase(func(state domain.FocusState, ended domain.FocusPhase) {
+		runtime.EventsEmit(ctx, "focus:phase", state, ended.Phase, ended.IsPomodoro())
+	})
+	go a.focus.Run(ctx, time.Second)
+
+	if a.syncRepo != nil {
+		go a.syncRepo.Run(ctx, 30*time.Second)
+	}
+
+	if a.replicator != nil {
+		go a.replicator.Run(ctx, time.Minute)
+
+		if a.peerAddr != "" && a.peerSecret == "" {
+			println("Replication listener not started: sync.secret is not set")
+		} else if a.peerAddr != "" {
+			server := &http.Server{
+				Addr:    a.peerAddr,
+				Handler: replication.NewHTTPHandler(a.replicator.Replica(), a.peerSecret, a.replicator.Trigger),
+			}
+			go func() {
+				<-ctx.Done()
+				server.Close()
+			}()
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					println("Replication listener failed:", err.Error())
+				}
+			}()
+		}
+	}
+
+	go a.rebalanceRanks(ctx, time.Hour)
+
+	if a.feed != nil {
+		go func() {
+			if err := a.feed.Serve(ctx); err != nil {
+				println("Calendar feed failed:", err.Error())
+			}
+		}()
+	}
+
+	if a.caldav != nil {
+		go func() {
+			<-ctx.Done()
+			a.caldav.Close()
+		}()
+		go func() {
+			if err := a.caldav.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				println("CalDAV server failed:", err.Error())
+			}
+		}()
+	}
 }
 
 func (a *App) CreateTask(title, description string) (*domain.Task, error) {
 	req := usecase.CreateTaskRequest{
 		Title:       title,
 		Description: description,
+		Priority:    a.settings.Get().Tasks.Priority,
 	}
 	return a.taskUseCase.CreateTask(a.ctx, req)
 }
 
-func (a *App) CreateTaskWithDetails(title, description, priority string, dueDate *time.Time) (*domain.Task, error) {
+// CreateTaskWithDetails creates a task with a due date that is either a
+// date (allDay) or a date and time, and an optional start date.
+func (a *App) CreateTaskWithDetails(title, description, priority string, dueDate *time.Time, allDay bool, startDate *time.Time) (*domain.Task, error) {
+	if priority == "" {
+		priority = a.settings.Get().Tasks.Priority
+	}
 	req := usecase.CreateTaskRequest{
 		Title:       title,
 		Description: description,
 		Priority:    priority,
 		DueDate:     dueDate,
+		DueAllDay:   allDay,
+		StartDate:   startDate,
 	}
 	return a.taskUseCase.CreateTask(a.ctx, req)
 }
 
 func (a *App) GetAllTasks() ([]*domain.Task, error) {
 	filter := usecase.TaskFilter{Status: "all"}
-	sort := usecase.TaskSort{Field: "created", Order: "desc"}
+	sort := a.defaultSort()
 	return a.taskUseCase.GetFilteredAndSortedTasks(a.ctx, filter, sort)
 }
 
@@ -91,6 +245,9 @@ func (a *App) GetFilteredTasks(status, priority, dateType, sortField, sortOrder
 		Field: sortField,
 		Order: sortOrder,
 	}
+	if sort.Field == "" {
+		sort = a.defaultSort()
+	}
 	return a.taskUseCase.GetFilteredAndSortedTasks(a.ctx, filter, sort)
 }
 
@@ -102,18 +259,590 @@ func (a *App) UpdateTask(id, title, description string) (*domain.Task, error) {
 	return a.taskUseCase.UpdateTask(a.ctx, id, title, description)
 }
 
-func (a *App) ToggleTaskStatus(id string) (*domain.Task, error) {
-	return a.taskUseCase.ToggleTaskStatus(a.ctx, id)
+// TransitionTask moves a task to another workflow state such as
+// "in_progress" or "done". Starting or completing a task that waits for
+// open tasks fails unless override is set.
+func (a *App) TransitionTask(id, status string, override bool) (*domain.Task, error) {
+	return a.taskUseCase.TransitionTask(a.ctx, id, status, override)
+}
+
+// AddDependency makes a task wait until blockerID is done.
+func (a *App) AddDependency(id, blockerID string) (*domain.Task, error) {
+	return a.taskUseCase.AddDependency(a.ctx, id, blockerID)
+}
+
+func (a *App) RemoveDependency(id, blockerID string) (*domain.Task, error) {
+	return a.taskUseCase.RemoveDependency(a.ctx, id, blockerID)
+}
+
+// GetDependencies returns the tasks a task waits for and the ones waiting
+// for it.
+func (a *App) GetDependencies(id string) (*usecase.TaskDependencies, error) {
+	return a.taskUseCase.GetDependencies(a.ctx, id)
+}
+
+// GetDependencyPlan orders the open tasks of project, or of every project
+// when empty, so each comes after the tasks it waits for, and finds the
+// longest chain among them.
+func (a *App) GetDependencyPlan(project string) (*usecase.DependencyPlan, error) {
+	filter := usecase.TaskFilter{Status: "all", Project: project}
+	return a.taskUseCase.GetDependencyPlan(a.ctx, filter, a.defaultSort())
+}
+
+// StartTimer starts timing work on a task, stopping the timer of any other
+// task. The timer keeps running across restarts until StopTimer.
+func (a *App) StartTimer(id string) (*domain.TimeEntry, error) {
+	return a.taskUseCase.StartTimer(a.ctx, id)
+}
+
+func (a *App) StopTimer() (*domain.TimeEntry, error) {
+	return a.taskUseCase.StopTimer(a.ctx)
+}
+
+// GetRunningTimer returns the entry of the running timer, or null.
+func (a *App) GetRunningTimer() (*domain.TimeEntry, error) {
+	return a.taskUseCase.GetRunningTimer(a.ctx)
+}
+
+func (a *App) AddTimeEntry(taskID string, start, end time.Time, note string) (*domain.TimeEntry, error) {
+	return a.taskUseCase.AddTimeEntry(a.ctx, taskID, start, end, note)
+}
+
+// UpdateTimeEntry corrects a logged entry. end may only be null for the
+// running timer.
+func (a *App) UpdateTimeEntry(id string, start time.Time, end *time.Time, note string) (*domain.TimeEntry, error) {
+	return a.taskUseCase.UpdateTimeEntry(a.ctx, id, start, end, note)
+}
+
+func (a *App) DeleteTimeEntry(id string) error {
+	return a.taskUseCase.DeleteTimeEntry(a.ctx, id)
+}
+
+func (a *App) GetTimeEntries(filter usecase.TimeFilter) ([]*domain.TimeEntry, error) {
+	return a.taskUseCase.GetTimeEntries(a.ctx, filter)
+}
+
+// GetTimeReport totals the time matching filter by "task", "project" or
+// "day".
+func (a *App) GetTimeReport(filter usecase.TimeFilter, groupBy string) (*usecase.TimeReport, error) {
+	return a.taskUseCase.GetTimeReport(a.ctx, filter, groupBy)
+}
+
+// StartFocus starts a Pomodoro focus session on a task, ending any other.
+// The app emits "focus:tick" with the state every second and "focus:phase"
+// when a phase ends.
+func (a *App) StartFocus(id string) (domain.FocusState, error) {
+	return a.focus.Start(a.ctx, id)
+}
+
+func (a *App) PauseFocus() (domain.FocusState, error) {
+	return a.focus.Pause()
+}
+
+func (a *App) ResumeFocus() (domain.FocusState, error) {
+	return a.focus.Resume()
+}
+
+func (a *App) SkipFocusPhase() (domain.FocusState, error) {
+	return a.focus.Skip(a.ctx)
+}
+
+func (a *App) StopFocus() domain.FocusState {
+	return a.focus.Stop()
+}
+
+func (a *App) GetFocusState() domain.FocusState {
+	return a.focus.State()
+}
+
+// GetFocusStats returns the pomodoros and focus time per day of the
+// entries matching filter.
+func (a *App) GetFocusStats(filter usecase.TimeFilter) ([]usecase.FocusDay, error) {
+	return a.taskUseCase.GetFocusStats(a.ctx, filter)
+}
+
+// GetWorkflow returns the states and allowed transitions of project's
+// workflow, for offering the next states of a task.
+func (a *App) GetWorkflow(project string) domain.Workflow {
+	return a.taskUseCase.Workflow(project)
+}
+
+// GetBoard returns the Kanban board of project, or of every project when
+// empty, with columns and WIP limits from the board settings.
+func (a *App) GetBoard(project, tag string) (*usecase.Board, error) {
+	filter := usecase.TaskFilter{Status: "all", Project: project, Tag: tag}
+	return a.taskUseCase.GetBoard(a.ctx, filter, a.boardOptions())
+}
+
+// MoveCard drops a card into column at position, counted from the top.
+func (a *App) MoveCard(id, column string, position int) (*usecase.MoveCardResult, error) {
+	return a.taskUseCase.MoveCard(a.ctx, id, column, position, a.boardOptions())
+}
+
+// ReorderTask places a task between beforeID and afterID in the manual
+// order; either may be empty for the top or the bottom.
+func (a *App) ReorderTask(id, beforeID, afterID string) (*domain.Task, error) {
+	return a.taskUseCase.ReorderTask(a.ctx, id, beforeID, afterID)
 }
 
 func (a *App) SetTaskPriority(id, priority string) (*domain.Task, error) {
 	return a.taskUseCase.SetTaskPriority(a.ctx, id, priority)
 }
 
-func (a *App) SetTaskDueDate(id string, dueDate *time.Time) (*domain.Task, error) {
-	return a.taskUseCase.SetTaskDueDate(a.ctx, id, dueDate)
+func (a *App) SetTaskDueDate(id string, dueDate *time.Time, allDay bool) (*domain.Task, error) {
+	return a.taskUseCase.SetTaskDueDate(a.ctx, id, dueDate, allDay)
+}
+
+func (a *App) SetTaskStartDate(id string, startDate *time.Time) (*domain.Task, error) {
+	return a.taskUseCase.SetTaskStartDate(a.ctx, id, startDate)
+}
+
+// SetTaskEstimate sets a task's estimate from text such as "1h30m" or
+// "3pt"; an empty estimate removes it.
+func (a *App) SetTaskEstimate(id, estimate string) (*domain.Task, error) {
+	return a.taskUseCase.SetTaskEstimate(a.ctx, id, estimate)
+}
+
+// GetEffortSummary compares the estimates of the tasks matching filter with
+// the time tracked on them.
+func (a *App) GetEffortSummary(filter usecase.TaskFilter) (*usecase.EffortSummary, error) {
+	return a.taskUseCase.GetEffortSummary(a.ctx, filter)
 }
 
 func (a *App) DeleteTask(id string) error {
 	return a.taskUseCase.DeleteTask(a.ctx, id)
 }
+
+func (a *App) BulkComplete(selector usecase.BulkSelector) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkComplete(a.ctx, selector)
+}
+
+func (a *App) BulkDelete(selector usecase.BulkSelector) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkDelete(a.ctx, selector)
+}
+
+func (a *App) BulkSetPriority(selector usecase.BulkSelector, priority string) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkSetPriority(a.ctx, selector, priority)
+}
+
+func (a *App) BulkSetDueDate(selector usecase.BulkSelector, dueDate *time.Time, allDay bool) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkSetDueDate(a.ctx, selector, dueDate, allDay)
+}
+
+func (a *App) BulkSetStartDate(selector usecase.BulkSelector, startDate *time.Time) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkSetStartDate(a.ctx, selector, startDate)
+}
+
+func (a *App) BulkMoveToProject(selector usecase.BulkSelector, project string) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkMoveToProject(a.ctx, selector, project)
+}
+
+func (a *App) BulkAddTags(selector usecase.BulkSelector, tags []string) (*usecase.BulkResult, error) {
+	return a.taskUseCase.BulkAddTags(a.ctx, selector, tags)
+}
+
+// ExportTasks asks where to save and writes the tasks matching filter as CSV.
+// It returns the chosen path, or "" when the dialog was cancelled.
+func (a *App) ExportTasks(filter usecase.TaskFilter, options interchange.CSVOptions) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export tasks",
+		DefaultFilename: "tasks.csv",
+		Filters:         []runtime.FileFilter{{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sort := usecase.TaskSort{Field: "created", Order: "desc"}
+	if err := a.taskUseCase.ExportTasksCSV(a.ctx, file, filter, sort, options); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+// ImportTasks asks for a CSV file and imports it. Run it with dryRun first
+// and pass the reported source to ImportTasksFromFile to confirm.
+func (a *App) ImportTasks(options interchange.CSVImportOptions, dryRun bool) (*usecase.ImportReport, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import tasks",
+		Filters: []runtime.FileFilter{{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	return a.ImportTasksFromFile(path, options, dryRun)
+}
+
+func (a *App) ImportTasksFromFile(path string, options interchange.CSVImportOptions, dryRun bool) (*usecase.ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report, err := a.taskUseCase.ImportTasksCSV(a.ctx, file, options, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Source = path
+	return report, nil
+}
+
+func (a *App) GetImportFormats() []importer.Format {
+	return importer.Formats()
+}
+
+// ImportFromApp asks for an export of another to-do app and imports it with
+// the given format, or the detected one when format is empty. Run it with
+// dryRun first to preview, then pass the reported source to
+// ImportFromAppFile to confirm.
+func (a *App) ImportFromApp(format string, dryRun bool) (*usecase.ImportReport, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import from another app",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Exports (*.json, *.csv, *.ics, *.txt, *.md)", Pattern: "*.json;*.csv;*.ics;*.txt;*.md"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	return a.ImportFromAppFile(path, format, dryRun)
+}
+
+func (a *App) ImportFromAppFile(path, format string, dryRun bool) (*usecase.ImportReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.taskUseCase.ImportTasks(a.ctx, data, path, format, dryRun)
+}
+
+// ExportBackup asks where to save and writes all tasks as a versioned JSON
+// backup that can be restored into any storage backend.
+func (a *App) ExportBackup() (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Back up tasks",
+		DefaultFilename: "tasks-backup-" + time.Now().Format("2006-01-02") + ".json",
+		Filters:         []runtime.FileFilter{{DisplayName: "JSON files (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := a.taskUseCase.ExportTasksJSON(a.ctx, file); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+// RestoreBackup asks for a JSON backup and adds the tasks it does not have
+// yet. Run it with dryRun first and pass the reported source to
+// RestoreBackupFromFile to confirm.
+func (a *App) RestoreBackup(dryRun bool) (*usecase.ImportReport, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Restore backup",
+		Filters: []runtime.FileFilter{{DisplayName: "JSON files (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	return a.RestoreBackupFromFile(path, dryRun)
+}
+
+func (a *App) RestoreBackupFromFile(path string, dryRun bool) (*usecase.ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report, err := a.taskUseCase.ImportTasksJSON(a.ctx, file, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Source = path
+	return report, nil
+}
+
+// ExportMarkdown asks where to save and writes the tasks matching filter as
+// a Markdown checklist, optionally grouped under headings.
+func (a *App) ExportMarkdown(filter usecase.TaskFilter, sort usecase.TaskSort, options interchange.MarkdownOptions) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export as Markdown",
+		DefaultFilename: "tasks.md",
+		Filters:         []runtime.FileFilter{{DisplayName: "Markdown files (*.md)", Pattern: "*.md"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := a.taskUseCase.ExportTasksMarkdown(a.ctx, file, filter, sort, options); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+// ExportICalendar asks where to save and writes the tasks matching filter as
+// an .ics file of VTODOs.
+func (a *App) ExportICalendar(filter usecase.TaskFilter) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export to calendar",
+		DefaultFilename: "tasks.ics",
+		Filters:         []runtime.FileFilter{{DisplayName: "iCalendar files (*.ics)", Pattern: "*.ics"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := a.taskUseCase.ExportTasksICal(a.ctx, file, filter); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+// ExportTimeLog asks where to save and writes the time log matching filter
+// as CSV.
+func (a *App) ExportTimeLog(filter usecase.TimeFilter, options interchange.TimeLogOptions) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export time log",
+		DefaultFilename: "time-log.csv",
+		Filters:         []runtime.FileFilter{{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := a.taskUseCase.ExportTimeLogCSV(a.ctx, file, filter, options); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+func (a *App) ImportICalendar(dryRun bool) (*usecase.ImportReport, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "Import from calendar",
+		Filters: []runtime.FileFilter{{DisplayName: "iCalendar files (*.ics)", Pattern: "*.ics"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	return a.ImportICalendarFromFile(path, dryRun)
+}
+
+func (a *App) ImportICalendarFromFile(path string, dryRun bool) (*usecase.ImportReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	report, err := a.taskUseCase.ImportTasksICal(a.ctx, file, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Source = path
+	return report, nil
+}
+
+// GetFeedURL returns the webcal:// address calendar apps can subscribe to,
+// or "" when the feed is not running.
+func (a *App) GetFeedURL() string {
+	if a.feed == nil {
+		return ""
+	}
+	return a.feed.URL()
+}
+
+func (a *App) GetSyncStatus() repository.SyncStatus {
+	if a.syncRepo == nil {
+		return repository.SyncStatus{State: repository.SyncDisabled}
+	}
+	return a.syncRepo.Status()
+}
+
+func (a *App) SyncNow() (repository.SyncStatus, error) {
+	if a.syncRepo == nil {
+		return repository.SyncStatus{State: repository.SyncDisabled}, nil
+	}
+	err := a.syncRepo.Sync(a.ctx)
+	return a.syncRepo.Status(), err
+}
+
+func (a *App) ReplicateNow() error {
+	if a.replicator == nil {
+		return errors.New("replication is not configured")
+	}
+	return a.replicator.SyncOnce(a.ctx)
+}
+
+// MigrationPrompt offers to copy the tasks of the local file store into
+// PostgreSQL, which otherwise starts out empty.
+type MigrationPrompt struct {
+	Source string `json:"source"`
+	Tasks  int    `json:"tasks"`
+}
+
+func (a *App) migrationMarker() string {
+	return filepath.Join(a.dataDir, "migrated-to-postgres")
+}
+
+// GetMigrationPrompt returns nil unless PostgreSQL is enabled, the prompt
+// was neither accepted nor dismissed before and local tasks are missing in
+// the database.
+func (a *App) GetMigrationPrompt() (*MigrationPrompt, error) {
+	if a.syncRepo == nil {
+		return nil, nil
+	}
+	if _, err := os.Stat(a.migrationMarker()); err == nil {
+		return nil, nil
+	}
+	source := filepath.Join(a.dataDir, "tasks.json")
+	if _, err := os.Stat(source); err != nil {
+		return nil, nil
+	}
+
+	local, err := repository.NewFileTaskRepository(a.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := migrate.Pending(a.ctx, local, a.taskRepo)
+	if err != nil || pending == 0 {
+		return nil, err
+	}
+
+	return &MigrationPrompt{Source: source, Tasks: pending}, nil
+}
+
+// MigrateLocalTasks copies the local file store into PostgreSQL and stops
+// the prompt from showing again.
+func (a *App) MigrateLocalTasks() (*migrate.Result, error) {
+	if a.syncRepo == nil {
+		return nil, errors.New("PostgreSQL is not configured")
+	}
+
+	local, err := repository.NewFileTaskRepository(a.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	result, err := migrate.Copy(a.ctx, local, a.taskRepo, false)
+	if err != nil {
+		return nil, err
+	}
+
+	go a.syncRepo.Sync(a.ctx)
+	return result, a.DismissMigrationPrompt()
+}
+
+func (a *App) DismissMigrationPrompt() error {
+	return os.WriteFile(a.migrationMarker(), []byte(time.Now().Format(time.RFC3339)+"\n"), 0644)
+}
+
+func (a *App) defaultSort() usecase.TaskSort {
+	tasks := a.settings.Get().Tasks
+	return usecase.TaskSort{Field: tasks.SortField, Order: tasks.SortOrder}
+}
+
+// rebalanceRanks keeps ranks short by spreading them out at startup and
+// then every interval, should reordering have grown them.
+func (a *App) rebalanceRanks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.taskUseCase.RebalanceRanks(ctx); err != nil {
+			println("Failed to rebalance task order:", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *App) boardOptions() usecase.BoardOptions {
+	settings := a.settings.Get()
+	return usecase.BoardOptions{Columns: settings.Board.Columns, WIPLimits: settings.WIPLimits()}
+}
+
+// SettingsView is the current settings with where they come from.
+// Overridden settings are set by the environment or flags and cannot be
+// changed from the app.
+type SettingsView struct {
+	Settings   config.Config `json:"settings"`
+	Path       string        `json:"path"`
+	Overridden []string      `json:"overridden"`
+}
+
+type SettingsUpdate struct {
+	Settings config.Config `json:"settings"`
+	// RestartRequired is set while settings only read at startup differ
+	// from the ones the app started with.
+	RestartRequired bool `json:"restart_required"`
+}
+
+func (a *App) GetSettings() SettingsView {
+	return SettingsView{
+		Settings:   a.settings.Get(),
+		Path:       a.settings.Path(),
+		Overridden: a.settings.Overridden(),
+	}
+}
+
+// UpdateSettings validates and saves settings. Everything but storage,
+// window size and the network services applies immediately.
+func (a *App) UpdateSettings(settings config.Config) (*SettingsUpdate, error) {
+	current, err := a.settings.Update(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingsUpdate{
+		Settings:        current,
+		RestartRequired: a.started.RestartRequired(current),
+	}, nil
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"todo-list/internal/config"
+	"todo-list/internal/domain"
+	"todo-list/internal/importer"
+	"todo-list/internal/interchange"
+	"todo-list/internal/migrate"
+	"todo-list/internal/usecase"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+var commands = []command{
+	{"export-csv", "write tasks as CSV", runExportCSV},
+	{"import-csv", "import tasks from a CSV file", runImportCSV},
+	{"export-md", "write tasks as a Markdown checklist", runExportMarkdown},
+	{"export-time", "write the time log as CSV", runExportTime},
+	{"import", "import an export of another to-do app", runImport},
+	{"backup", "write all tasks as a JSON backup", runBackup},
+	{"restore", "restore tasks from a JSON backup", runRestore},
+	{"migrate-data", "copy all tasks from one storage backend to another", runMigrateData},
+}
+
+// runCLI handles command-line subcommands. It reports false when args do not
+// start with a subcommand, in which case the desktop app starts instead.
+func runCLI(cfg config.Config, args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return true, 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		if err := cmd.run(context.Background(), cfg, args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+			return true, 1
+		}
+		return true, 0
+	}
+
+	return false, 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: todo-list [global flags] [command] [flags]")
+	fmt.Fprintln(w, "\nWithout a command the desktop app starts.\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fmt.Fprintln(w, "  -config FILE    settings file (default "+config.DefaultPath()+")")
+	fmt.Fprintln(w, "  -data-dir DIR   same as -set storage.data_dir=DIR")
+	fmt.Fprintln(w, "  -backend NAME   same as -set storage.backend=NAME")
+	fmt.Fprintln(w, "  -set KEY=VALUE  override a setting, e.g. -set tasks.priority=high")
+}
+
+// withStorage opens the same storage as the desktop app and pushes pending
+// changes to PostgreSQL before returning.
+func withStorage(ctx context.Context, cfg config.Config, fn func(uc *usecase.TaskUseCase) error) error {
+	st := openStorage(cfg)
+	clock := domain.NewSystemClock(cfg.Location(), cfg.WeekStart())
+	if err := fn(st.taskUseCase(clock, cfg.SortLanguage)); err != nil {
+		return err
+	}
+
+	if st.syncRepo != nil {
+		if err := st.syncRepo.Sync(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: changes are queued until PostgreSQL is reachable:", err)
+		}
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func runExportCSV(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export-csv", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default stdout)")
+	status := flags.String("status", "all", "all, active, ready, deferred, completed or a workflow state such as in_progress")
+	priority := flags.String("priority", "all", "all, low, medium or high")
+	dateType := flags.String("date", "", "today, tomorrow, this_week, next_week, next_7_days, this_month, overdue or no_date")
+	project := flags.String("project", "", "only tasks in this project")
+	tag := flags.String("tag", "", "only tasks with this tag")
+	estimate := flags.String("estimate", "", "none, estimated, over or a comparison such as \"<=2h\" or \">3pt\"")
+	columns := flags.String("columns", "", "comma-separated columns (default "+strings.Join(interchange.DefaultCSVColumns, ",")+")")
+	dateFormat := flags.String("date-format", interchange.DefaultCSVDateFormat, "Go time layout for dates")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	filter := usecase.TaskFilter{Status: *status, Priority: *priority, DateType: *dateType, Project: *project, Tag: *tag, Estimate: *estimate}
+	opts := interchange.CSVOptions{Columns: splitList(*columns), DateFormat: *dateFormat}
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		return uc.ExportTasksCSV(ctx, w, filter, usecase.TaskSort{Field: "created", Order: "desc"}, opts)
+	})
+}
+
+func runExportMarkdown(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export-md", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default stdout)")
+	status := flags.String("status", "all", "all, active, ready, deferred, completed or a workflow state such as in_progress")
+	priority := flags.String("priority", "all", "all, low, medium or high")
+	project := flags.String("project", "", "only tasks in this project")
+	tag := flags.String("tag", "", "only tasks with this tag")
+	group := flags.String("group", "", "group under headings by due_date, priority or project")
+	title := flags.String("title", "Tasks", "document heading")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	filter := usecase.TaskFilter{Status: *status, Priority: *priority, Project: *project, Tag: *tag}
+	opts := interchange.MarkdownOptions{Title: *title, GroupBy: *group}
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		return uc.ExportTasksMarkdown(ctx, w, filter, usecase.TaskSort{Field: "due_date", Order: "asc"}, opts)
+	})
+}
+
+func runExportTime(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export-time", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default stdout)")
+	group := flags.String("group", "task", "group by task, project or day")
+	summary := flags.Bool("summary", false, "one total per group instead of every entry")
+	period := flags.String("period", "", "today, this_week, this_month or another date filter that is a range")
+	from := flags.String("from", "", "first day, as 2006-01-02")
+	to := flags.String("to", "", "last day, as 2006-01-02")
+	project := flags.String("project", "", "only time spent on this project")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter := usecase.TimeFilter{Project: *project, Period: *period}
+	if *from != "" {
+		day, err := time.ParseInLocation("2006-01-02", *from, cfg.Location())
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		filter.From = &day
+	}
+	if *to != "" {
+		day, err := time.ParseInLocation("2006-01-02", *to, cfg.Location())
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		end := domain.AddDays(day, 1)
+		filter.To = &end
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	opts := interchange.TimeLogOptions{GroupBy: *group, Summary: *summary}
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		return uc.ExportTimeLogCSV(ctx, w, filter, opts)
+	})
+}
+
+func runImportCSV(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-csv", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate and report without importing")
+	dateFormat := flags.String("date-format", "", "Go time layout used in the file")
+	mapping := flags.String("map", "", "header mapping, e.g. \"Task Name=title,Due=due_date\"")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one CSV file")
+	}
+
+	opts := interchange.CSVImportOptions{DateFormat: *dateFormat, HeaderMap: make(map[string]string)}
+	for _, pair := range splitList(*mapping) {
+		source, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid mapping %q", pair)
+		}
+		opts.HeaderMap[strings.TrimSpace(source)] = strings.TrimSpace(column)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		report, err := uc.ImportTasksCSV(ctx, file, opts, *dryRun)
+		if err != nil {
+			return err
+		}
+		report.Source = flags.Arg(0)
+		return printReport(report)
+	})
+}
+
+func runImport(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "preview and report without importing")
+	format := flags.String("format", "", "source format (default: detect); see -list")
+	list := flags.Bool("list", false, "list supported formats")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		for _, f := range importer.Formats() {
+			fmt.Printf("%-14s %s\n", f.Name, f.Description)
+		}
+		return nil
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one file")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		report, err := uc.ImportTasks(ctx, data, flags.Arg(0), *format, *dryRun)
+		if err != nil {
+			return err
+		}
+		return printReport(report)
+	})
+}
+
+func runBackup(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := flags.String("o", "", "output file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		return uc.ExportTasksJSON(ctx, w)
+	})
+}
+
+func runRestore(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report without restoring")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one backup file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return withStorage(ctx, cfg, func(uc *usecase.TaskUseCase) error {
+		report, err := uc.ImportTasksJSON(ctx, file, *dryRun)
+		if err != nil {
+			return err
+		}
+		report.Source = flags.Arg(0)
+		return printReport(report)
+	})
+}
+
+func runMigrateData(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate-data", flag.ContinueOnError)
+	from := flags.String("from", "file:"+cfg.Storage.DataDir, "source: file:DIR, todotxt:DIR, markdown:FILE or a postgres:// URL")
+	to := flags.String("to", "", "target, same forms as -from (default storage.dsn)")
+	dryRun := flags.Bool("dry-run", false, "count what would be copied without writing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" {
+		*to = cfg.Storage.DSN
+	}
+	if *to == "" {
+		return fmt.Errorf("no target given, use -to")
+	}
+	if *from == *to {
+		return fmt.Errorf("source and target are the same")
+	}
+
+	source, sourceCloser, err := migrate.Open(*from)
+	if err != nil {
+		return fmt.Errorf("opening source: %w", err)
+	}
+	defer sourceCloser.Close()
+
+	target, targetCloser, err := migrate.Open(*to)
+	if err != nil {
+		return fmt.Errorf("opening target: %w", err)
+	}
+	defer targetCloser.Close()
+
+	result, err := migrate.Copy(ctx, source, target, *dryRun)
+	if result != nil {
+		printReport(result)
+	}
+	return err
+}
+
+func printReport(report interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
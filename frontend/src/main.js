@@ -1,15 +1,45 @@
 import './style.css';
 import {
+    AddDependency,
+    AddTimeEntry,
     CreateTask,
     CreateTaskWithDetails,
     DeleteTask,
+    DeleteTimeEntry,
+    DismissMigrationPrompt,
+    ExportTimeLog,
     GetAllTasks,
+    GetBoard,
+    GetEffortSummary,
     GetFilteredTasks,
-    ToggleTaskStatus,
+    GetFocusState,
+    GetFocusStats,
+    GetMigrationPrompt,
+    GetRunningTimer,
+    GetSettings,
+    GetTimeEntries,
+    GetTimeReport,
+    GetWorkflow,
+    MigrateLocalTasks,
+    MoveCard,
+    PauseFocus,
+    RemoveDependency,
+    ReorderTask,
+    ResumeFocus,
+    SetTaskEstimate,
     SetTaskPriority,
     SetTaskDueDate,
-    UpdateTask
+    SkipFocusPhase,
+    StartFocus,
+    StartTimer,
+    StopFocus,
+    StopTimer,
+    TransitionTask,
+    UpdateSettings,
+    UpdateTask,
+    UpdateTimeEntry
 } from '../wailsjs/go/main/App';
+import {EventsOn} from '../wailsjs/runtime/runtime';
 
 class TodoApp {
     constructor() {
@@ -22,14 +52,68 @@ class TodoApp {
             sortOrder: 'desc'
         };
         this.taskToDelete = null;
+        this.settings = null;
+        this.workflows = {};
+        this.view = 'list';
+        this.board = null;
+        this.runningTimer = null;
+        this.timeSpent = {};
+        this.timeLoadedAt = Date.now();
+        this.timeEntries = [];
+        this.allTasks = [];
+        this.effort = null;
+        this.overEstimate = new Set();
+        this.focus = {active: false};
+        this.focusDays = [];
         this.init();
     }
 
     async init() {
         this.setupEventListeners();
-        this.setupTheme();
+        await this.loadSettings();
         await this.loadTasks();
         this.render();
+        setInterval(() => this.tickTimer(), 30 * 1000);
+        await this.loadFocus();
+        await this.checkMigration();
+    }
+
+    async checkMigration() {
+        try {
+            const prompt = await GetMigrationPrompt();
+            if (!prompt) {
+                return;
+            }
+            document.getElementById('migrate-message').textContent =
+                `${prompt.tasks} task(s) in ${prompt.source} are not in the database yet. Copy them to PostgreSQL?`;
+            document.getElementById('migrate-modal').style.display = 'flex';
+        } catch (error) {
+            console.error('Failed to check for local tasks:', error);
+        }
+    }
+
+    async confirmMigration() {
+        this.hideMigrateModal();
+        try {
+            await MigrateLocalTasks();
+            await this.loadTasks();
+            this.render();
+        } catch (error) {
+            this.showError('Failed to copy tasks: ' + error);
+        }
+    }
+
+    async dismissMigration() {
+        this.hideMigrateModal();
+        try {
+            await DismissMigrationPrompt();
+        } catch (error) {
+            console.error('Failed to dismiss migration prompt:', error);
+        }
+    }
+
+    hideMigrateModal() {
+        document.getElementById('migrate-modal').style.display = 'none';
     }
 
     setupEventListeners() {
@@ -44,9 +128,26 @@ class TodoApp {
 
         document.getElementById('theme-toggle').addEventListener('click', this.toggleTheme.bind(this));
 
+        document.getElementById('list-view').addEventListener('click', () => this.showView('list'));
+        document.getElementById('board-view').addEventListener('click', () => this.showView('board'));
+
+        document.getElementById('time-log').addEventListener('click', this.showTimeLog.bind(this));
+        document.getElementById('close-time').addEventListener('click', this.hideTimeLog.bind(this));
+        document.getElementById('export-time').addEventListener('click', this.exportTimeLog.bind(this));
+        document.getElementById('time-period').addEventListener('change', this.loadTimeLog.bind(this));
+        document.getElementById('time-group').addEventListener('change', this.loadTimeLog.bind(this));
+        document.getElementById('time-entry-form').addEventListener('submit', this.addTimeEntry.bind(this));
+
+        document.getElementById('pause-focus').addEventListener('click', this.togglePauseFocus.bind(this));
+        document.getElementById('skip-focus').addEventListener('click', this.skipFocusPhase.bind(this));
+        document.getElementById('stop-focus').addEventListener('click', this.stopFocus.bind(this));
+
         document.getElementById('cancel-delete').addEventListener('click', this.hideDeleteModal.bind(this));
         document.getElementById('confirm-delete').addEventListener('click', this.confirmDelete.bind(this));
 
+        document.getElementById('dismiss-migrate').addEventListener('click', this.dismissMigration.bind(this));
+        document.getElementById('confirm-migrate').addEventListener('click', this.confirmMigration.bind(this));
+
         document.getElementById('delete-modal').addEventListener('click', (e) => {
             if (e.target.id === 'delete-modal') {
                 this.hideDeleteModal();
@@ -54,19 +155,64 @@ class TodoApp {
         });
     }
 
-    setupTheme() {
-        const savedTheme = localStorage.getItem('theme') || 'light';
-        document.documentElement.setAttribute('data-theme', savedTheme);
-        this.updateThemeIcon(savedTheme);
+    // A sort_field spelling out several keys gets its own option.
+    addSortOption(spec) {
+        const select = document.getElementById('sort-field');
+        if (!spec || Array.from(select.options).some(option => option.value === spec)) {
+            return;
+        }
+        const option = document.createElement('option');
+        option.value = spec;
+        option.textContent = spec;
+        select.appendChild(option);
     }
 
-    toggleTheme() {
+    async loadSettings() {
+        try {
+            const view = await GetSettings();
+            this.applySettings(view.settings);
+            this.currentFilters.sortField = view.settings.tasks.sort_field;
+            this.currentFilters.sortOrder = view.settings.tasks.sort_order;
+            this.addSortOption(this.currentFilters.sortField);
+            document.getElementById('sort-field').value = this.currentFilters.sortField;
+            document.getElementById('sort-order').value = this.currentFilters.sortOrder;
+        } catch (error) {
+            console.error('Failed to load settings:', error);
+            this.applyTheme('light');
+        }
+
+        // Edits to the settings file apply without a restart.
+        EventsOn('settings:changed', (settings) => this.applySettings(settings));
+    }
+
+    applySettings(settings) {
+        this.settings = settings;
+        this.applyTheme(settings.ui.theme);
+    }
+
+    applyTheme(theme) {
+        if (theme === 'system') {
+            theme = window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+        }
+        document.documentElement.setAttribute('data-theme', theme);
+        this.updateThemeIcon(theme);
+    }
+
+    async toggleTheme() {
         const currentTheme = document.documentElement.getAttribute('data-theme');
         const newTheme = currentTheme === 'dark' ? 'light' : 'dark';
+        this.applyTheme(newTheme);
 
-        document.documentElement.setAttribute('data-theme', newTheme);
-        localStorage.setItem('theme', newTheme);
-        this.updateThemeIcon(newTheme);
+        if (!this.settings) {
+            return;
+        }
+        try {
+            const settings = {...this.settings, ui: {...this.settings.ui, theme: newTheme}};
+            const update = await UpdateSettings(settings);
+            this.settings = update.settings;
+        } catch (error) {
+            this.showError('Failed to save theme: ' + error);
+        }
     }
 
     updateThemeIcon(theme) {
@@ -92,12 +238,21 @@ class TodoApp {
         const description = document.getElementById('task-description').value.trim();
         const priority = document.getElementById('task-priority').value;
         const dueDateValue = document.getElementById('task-due-date').value;
+        const dueTimeValue = document.getElementById('task-due-time').value;
+        const startDateValue = document.getElementById('task-start-date').value;
 
         try {
             let newTask;
-            if (description || priority !== 'medium' || dueDateValue) {
-                const dueDate = dueDateValue ? new Date(dueDateValue) : null;
-                newTask = await CreateTaskWithDetails(title, description, priority, dueDate);
+            if (description || priority !== 'medium' || dueDateValue || startDateValue) {
+                // Dates without a time are sent as midnight UTC, the form
+                // the backend keeps all-day dates in.
+                const allDay = !dueTimeValue;
+                let dueDate = null;
+                if (dueDateValue) {
+                    dueDate = allDay ? new Date(`${dueDateValue}T00:00:00Z`) : new Date(`${dueDateValue}T${dueTimeValue}`);
+                }
+                const startDate = startDateValue ? new Date(`${startDateValue}T00:00:00Z`) : null;
+                newTask = await CreateTaskWithDetails(title, description, priority, dueDate, allDay, startDate);
             } else {
                 newTask = await CreateTask(title, description);
             }
@@ -131,20 +286,590 @@ class TodoApp {
         try {
             const { status, priority, dateType, sortField, sortOrder } = this.currentFilters;
             this.tasks = await GetFilteredTasks(status, priority, dateType, sortField, sortOrder);
+            await this.loadWorkflows();
+            await this.loadTimers();
+            this.effort = await GetEffortSummary({ status, priority, date: dateType });
+            this.overEstimate = new Set(this.effort.over_estimate.map(task => task.id));
+            if (this.view === 'board') {
+                this.board = await GetBoard('', '');
+            }
         } catch (error) {
             console.error('Error loading tasks:', error);
             this.tasks = [];
         }
     }
 
+    async showView(view) {
+        this.view = view;
+        document.getElementById('list-view').classList.toggle('active', view === 'list');
+        document.getElementById('board-view').classList.toggle('active', view === 'board');
+        await this.loadTasks();
+        this.render();
+    }
+
+    renderBoard() {
+        const board = document.getElementById('board');
+        if (!this.board) {
+            board.innerHTML = '';
+            return;
+        }
+
+        board.innerHTML = this.board.columns.map(column => {
+            const limit = column.wip_limit ? ` / ${column.wip_limit}` : '';
+            const byProject = this.settings && this.settings.board.columns === 'project';
+            const name = byProject ? (column.name || 'No project') : this.formatStatus(column.name);
+            return `
+                <div class="board-column ${column.over_limit ? 'over-limit' : ''}" data-column="${column.name}"
+                    ondragover="event.preventDefault()" ondrop="todoApp.dropCard(event, this)">
+                    <h3 class="board-column-title">
+                        ${name}
+                        <span class="task-counter">${column.tasks.length}${limit}</span>
+                    </h3>
+                    ${column.tasks.map(task => this.renderCard(task)).join('')}
+                </div>
+            `;
+        }).join('');
+    }
+
+    renderCard(task) {
+        const isOverdue = this.isOverdue(task);
+        return `
+            <div class="board-card ${isOverdue ? 'overdue' : ''}" draggable="true" data-id="${task.id}"
+                ondragstart="event.dataTransfer.setData('text/plain', '${task.id}')">
+                <div class="task-title">${task.title}</div>
+                <div class="task-meta">
+                    <span class="priority-badge priority-${task.priority}">${task.priority}</span>
+                    ${task.due_date ? `<span class="due-date ${isOverdue ? 'overdue' : ''}">${this.formatDue(task)}</span>` : ''}
+                </div>
+            </div>
+        `;
+    }
+
+    // dropCard moves the dragged card in front of the first card whose
+    // middle is below the pointer.
+    async dropCard(event, columnElement) {
+        event.preventDefault();
+        const taskId = event.dataTransfer.getData('text/plain');
+        const cards = [...columnElement.querySelectorAll('.board-card')].filter(card => card.dataset.id !== taskId);
+        let position = cards.findIndex(card => {
+            const box = card.getBoundingClientRect();
+            return event.clientY < box.top + box.height / 2;
+        });
+        if (position < 0) {
+            position = cards.length;
+        }
+
+        try {
+            const result = await MoveCard(taskId, columnElement.dataset.column, position);
+            if (result.warning) {
+                this.showError(result.warning);
+            }
+        } catch (error) {
+            this.showError('Failed to move card: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    // loadWorkflows fetches the workflow of every project on screen, which
+    // decides the states offered for each task.
+    async loadWorkflows() {
+        const projects = new Set(this.tasks.map(task => task.project || ''));
+        for (const project of projects) {
+            if (!this.workflows[project]) {
+                this.workflows[project] = await GetWorkflow(project);
+            }
+        }
+    }
+
+    isClosed(task) {
+        return task.status === 'done' || task.status === 'cancelled';
+    }
+
     async toggleTask(taskId) {
+        const task = this.tasks.find(task => task.id === taskId);
+        await this.transitionTask(taskId, task && this.isClosed(task) ? 'todo' : 'done');
+    }
+
+    async transitionTask(taskId, status) {
         try {
-            await ToggleTaskStatus(taskId);
-            await this.loadTasks();
-            this.render();
+            await TransitionTask(taskId, status, false);
+        } catch (error) {
+            // Tasks waiting for open tasks can still be moved on purpose.
+            if (String(error).includes('task is blocked') && confirm(`${error}\n\nChange the status anyway?`)) {
+                try {
+                    await TransitionTask(taskId, status, true);
+                } catch (error) {
+                    this.showError('Failed to change status: ' + error);
+                }
+            } else {
+                this.showError('Failed to change status: ' + error);
+            }
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    async addDependency(taskId, blockerId) {
+        if (!blockerId) {
+            return;
+        }
+        try {
+            await AddDependency(taskId, blockerId);
+        } catch (error) {
+            this.showError('Failed to add dependency: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    async removeDependency(taskId, blockerId) {
+        try {
+            await RemoveDependency(taskId, blockerId);
+        } catch (error) {
+            this.showError('Failed to remove dependency: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    // Blockers filtered out of the list are not shown.
+    renderDependencies(task) {
+        return (task.blocked_by || [])
+            .map(id => this.tasks.find(other => other.id === id))
+            .filter(blocker => blocker && !this.isClosed(blocker))
+            .map(blocker => `
+                <span class="dependency-badge">
+                    Waiting on ${blocker.title}
+                    <button type="button" class="dependency-remove" title="Remove dependency"
+                        onclick="todoApp.removeDependency('${task.id}', '${blocker.id}')">×</button>
+                </span>
+            `).join('');
+    }
+
+    renderDependencySelect(task) {
+        if (this.isClosed(task)) {
+            return '';
+        }
+        const candidates = this.tasks.filter(other =>
+            other.id !== task.id && !this.isClosed(other) && !(task.blocked_by || []).includes(other.id)
+        );
+        if (candidates.length === 0) {
+            return '';
+        }
+        const options = candidates.map(other => `<option value="${other.id}">${other.title}</option>`).join('');
+
+        return `
+            <select class="dependency-select" onchange="todoApp.addDependency('${task.id}', this.value)">
+                <option value="">Depends on…</option>
+                ${options}
+            </select>
+        `;
+    }
+
+    async loadTimers() {
+        try {
+            this.runningTimer = await GetRunningTimer();
+            const report = await GetTimeReport({}, 'task');
+            this.timeSpent = Object.fromEntries(report.totals.map(total => [total.group, total.seconds]));
+            this.timeLoadedAt = Date.now();
+        } catch (error) {
+            console.error('Failed to load time log:', error);
+        }
+    }
+
+    isTiming(task) {
+        return this.runningTimer && this.runningTimer.task_id === task.id;
+    }
+
+    async startTimer(taskId) {
+        try {
+            await StartTimer(taskId);
+        } catch (error) {
+            this.showError('Failed to start timer: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    async stopTimer() {
+        try {
+            await StopTimer();
+        } catch (error) {
+            this.showError('Failed to stop timer: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    // tickTimer counts the running timer up without reloading the tasks.
+    tickTimer() {
+        if (!this.runningTimer) {
+            return;
+        }
+        const seconds = (this.timeSpent[this.runningTimer.task_id] || 0) + (Date.now() - this.timeLoadedAt) / 1000;
+        document.querySelectorAll('.time-spent.running').forEach(element => {
+            element.textContent = `⏱ ${this.formatDuration(seconds)}`;
+        });
+    }
+
+    formatDuration(seconds) {
+        const minutes = Math.floor(seconds / 60);
+        const hours = Math.floor(minutes / 60);
+        return hours > 0 ? `${hours}h ${String(minutes % 60).padStart(2, '0')}m` : `${minutes}m`;
+    }
+
+    renderTimeSpent(task) {
+        const seconds = this.timeSpent[task.id] || 0;
+        if (!this.isTiming(task) && seconds === 0) {
+            return '';
+        }
+        return `<span class="time-spent ${this.isTiming(task) ? 'running' : ''}">⏱ ${this.formatDuration(seconds)}</span>`;
+    }
+
+    renderTimerButton(task) {
+        if (this.isTiming(task)) {
+            return `<button type="button" class="btn btn-secondary timer-button running" onclick="todoApp.stopTimer()">Stop</button>`;
+        }
+        if (this.isClosed(task)) {
+            return '';
+        }
+        return `<button type="button" class="btn btn-secondary timer-button" onclick="todoApp.startTimer('${task.id}')">Start timer</button>`;
+    }
+
+    // The focus session runs in the backend, which sends its state every
+    // second and when a phase ends.
+    async loadFocus() {
+        try {
+            this.focus = await GetFocusState();
         } catch (error) {
-            console.error('Error toggling task:', error);
+            console.error('Failed to load focus session:', error);
         }
+        await this.loadFocusStats();
+        this.renderFocus();
+
+        EventsOn('focus:tick', (state) => {
+            this.focus = state;
+            this.renderFocus();
+        });
+        EventsOn('focus:phase', async (state, phase, pomodoro) => {
+            this.focus = state;
+            if (pomodoro) {
+                await this.loadTasks();
+                await this.loadFocusStats();
+                this.render();
+            }
+            this.renderFocus();
+        });
+    }
+
+    async loadFocusStats() {
+        try {
+            this.focusDays = await GetFocusStats({period: 'today'});
+        } catch (error) {
+            console.error('Failed to load focus stats:', error);
+        }
+        this.renderFocusStats();
+    }
+
+    isFocusing(task) {
+        return this.focus.active && this.focus.task_id === task.id;
+    }
+
+    async startFocus(taskId) {
+        try {
+            this.focus = await StartFocus(taskId);
+        } catch (error) {
+            this.showError('Failed to start focus session: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+        this.renderFocus();
+    }
+
+    async togglePauseFocus() {
+        try {
+            this.focus = this.focus.paused ? await ResumeFocus() : await PauseFocus();
+        } catch (error) {
+            this.showError('Failed to pause focus session: ' + error);
+        }
+        this.renderFocus();
+    }
+
+    async skipFocusPhase() {
+        try {
+            this.focus = await SkipFocusPhase();
+        } catch (error) {
+            this.showError('Failed to skip phase: ' + error);
+        }
+        this.renderFocus();
+    }
+
+    async stopFocus() {
+        try {
+            this.focus = await StopFocus();
+        } catch (error) {
+            this.showError('Failed to stop focus session: ' + error);
+        }
+        this.render();
+        this.renderFocus();
+    }
+
+    formatFocusPhase(phase) {
+        return {work: '🍅 Focus', short_break: '☕ Short break', long_break: '🌿 Long break'}[phase] || phase;
+    }
+
+    renderFocus() {
+        const focus = this.focus;
+        const bar = document.getElementById('focus-bar');
+        if (!focus.active) {
+            bar.style.display = 'none';
+            return;
+        }
+        const task = this.tasks.find(task => task.id === focus.task_id);
+        const minutes = Math.floor(focus.remaining / 60);
+        const seconds = String(focus.remaining % 60).padStart(2, '0');
+
+        bar.style.display = 'flex';
+        bar.classList.toggle('break', focus.phase !== 'work');
+        bar.classList.toggle('paused', focus.paused);
+        document.getElementById('focus-phase').textContent = this.formatFocusPhase(focus.phase);
+        document.getElementById('focus-remaining').textContent = `${minutes}:${seconds}`;
+        document.getElementById('focus-task').textContent = task ? task.title : '';
+        document.getElementById('focus-round').textContent = `${focus.round}/${focus.cycles} • ${focus.completed} done`;
+        document.getElementById('pause-focus').textContent = focus.paused ? 'Resume' : 'Pause';
+    }
+
+    renderFocusStats() {
+        const today = this.focusDays[this.focusDays.length - 1];
+        document.getElementById('focus-stats').textContent = today && today.pomodoros > 0
+            ? ` • 🍅 ${today.pomodoros} today (${this.formatDuration(today.seconds)})`
+            : '';
+    }
+
+    renderFocusButton(task) {
+        if (this.isFocusing(task) || this.isClosed(task)) {
+            return '';
+        }
+        return `<button type="button" class="btn btn-secondary" onclick="todoApp.startFocus('${task.id}')">Focus</button>`;
+    }
+
+    async showTimeLog() {
+        try {
+            this.allTasks = await GetAllTasks();
+        } catch (error) {
+            this.showError('Failed to load tasks: ' + error);
+            return;
+        }
+        document.getElementById('time-entry-task').innerHTML = this.allTasks
+            .filter(task => !this.isClosed(task))
+            .map(task => `<option value="${task.id}">${task.title}</option>`)
+            .join('');
+        document.getElementById('time-entry-date').value = this.formatLocalDay(new Date());
+        document.getElementById('time-modal').style.display = 'flex';
+        await this.loadTimeLog();
+    }
+
+    hideTimeLog() {
+        document.getElementById('time-modal').style.display = 'none';
+    }
+
+    timeFilter() {
+        return { period: document.getElementById('time-period').value };
+    }
+
+    taskTitle(taskId) {
+        const task = this.allTasks.find(task => task.id === taskId);
+        return task ? task.title : 'Deleted task';
+    }
+
+    async loadTimeLog() {
+        const filter = this.timeFilter();
+        try {
+            const report = await GetTimeReport(filter, document.getElementById('time-group').value);
+            this.timeEntries = await GetTimeEntries(filter);
+
+            document.getElementById('time-totals').innerHTML = report.totals.map(total => `
+                <div class="time-row">
+                    <span class="time-label">${total.label}</span>
+                    <span>${this.formatDuration(total.seconds)}</span>
+                </div>
+            `).join('') + `
+                <div class="time-row total">
+                    <span class="time-label">Total</span>
+                    <span>${this.formatDuration(report.seconds)}</span>
+                </div>
+            `;
+
+            document.getElementById('time-entries').innerHTML = this.timeEntries.map(entry => `
+                <div class="time-row">
+                    <span>${this.formatLocalDay(new Date(entry.start))}</span>
+                    <span>${this.formatLocalTime(new Date(entry.start))}–${entry.end ? this.formatLocalTime(new Date(entry.end)) : 'now'}</span>
+                    <span class="time-label">${entry.focus ? '🍅 ' : ''}${this.taskTitle(entry.task_id)}${entry.note ? ` · ${entry.note}` : ''}</span>
+                    <button type="button" class="btn btn-secondary" onclick="todoApp.editTimeEntry('${entry.id}')">Edit</button>
+                    <button type="button" class="dependency-remove" title="Delete entry" onclick="todoApp.deleteTimeEntry('${entry.id}')">×</button>
+                </div>
+            `).join('');
+        } catch (error) {
+            this.showError('Failed to load time log: ' + error);
+        }
+    }
+
+    async addTimeEntry(e) {
+        e.preventDefault();
+        const day = document.getElementById('time-entry-date').value;
+        const start = new Date(`${day}T${document.getElementById('time-entry-start').value}`);
+        const end = new Date(`${day}T${document.getElementById('time-entry-end').value}`);
+        try {
+            await AddTimeEntry(document.getElementById('time-entry-task').value, start, end,
+                document.getElementById('time-entry-note').value);
+            document.getElementById('time-entry-note').value = '';
+        } catch (error) {
+            this.showError('Failed to add time entry: ' + error);
+        }
+        await this.refreshTimeLog();
+    }
+
+    // Times are edited as "2006-01-02 15:04" in local time; an empty end
+    // keeps the running timer going.
+    async editTimeEntry(entryId) {
+        const entry = this.timeEntries.find(entry => entry.id === entryId);
+        if (!entry) {
+            return;
+        }
+        const format = date => `${this.formatLocalDay(date)} ${this.formatLocalTime(date)}`;
+        const start = prompt('Start', format(new Date(entry.start)));
+        if (start === null) {
+            return;
+        }
+        const end = prompt('End', entry.end ? format(new Date(entry.end)) : '');
+        if (end === null) {
+            return;
+        }
+        const note = prompt('Note', entry.note || '');
+        if (note === null) {
+            return;
+        }
+
+        try {
+            await UpdateTimeEntry(entryId, new Date(start.replace(' ', 'T')),
+                end.trim() ? new Date(end.replace(' ', 'T')) : null, note);
+        } catch (error) {
+            this.showError('Failed to update time entry: ' + error);
+        }
+        await this.refreshTimeLog();
+    }
+
+    async deleteTimeEntry(entryId) {
+        if (!confirm('Delete this time entry?')) {
+            return;
+        }
+        try {
+            await DeleteTimeEntry(entryId);
+        } catch (error) {
+            this.showError('Failed to delete time entry: ' + error);
+        }
+        await this.refreshTimeLog();
+    }
+
+    async refreshTimeLog() {
+        await this.loadTimeLog();
+        await this.loadTasks();
+        this.render();
+    }
+
+    async exportTimeLog() {
+        const options = {
+            group_by: document.getElementById('time-group').value,
+            summary: document.getElementById('time-summary').checked
+        };
+        try {
+            await ExportTimeLog(this.timeFilter(), options);
+        } catch (error) {
+            this.showError('Failed to export time log: ' + error);
+        }
+    }
+
+    formatLocalDay(date) {
+        const pad = n => String(n).padStart(2, '0');
+        return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}`;
+    }
+
+    formatLocalTime(date) {
+        return `${String(date.getHours()).padStart(2, '0')}:${String(date.getMinutes()).padStart(2, '0')}`;
+    }
+
+    async editEstimate(taskId) {
+        const task = this.tasks.find(task => task.id === taskId);
+        const estimate = prompt('Estimate, e.g. 1h30m or 3pt (empty to remove)',
+            task && task.estimate ? this.formatEstimate(task.estimate) : '');
+        if (estimate === null) {
+            return;
+        }
+        try {
+            await SetTaskEstimate(taskId, estimate);
+        } catch (error) {
+            this.showError('Failed to set estimate: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
+    // formatEstimate writes an estimate the way the app reads it.
+    formatEstimate(estimate) {
+        if (estimate.points) {
+            return `${estimate.points}pt`;
+        }
+        const hours = Math.floor(estimate.minutes / 60);
+        const minutes = estimate.minutes % 60;
+        if (hours === 0) return `${minutes}m`;
+        return minutes === 0 ? `${hours}h` : `${hours}h${minutes}m`;
+    }
+
+    renderEstimate(task) {
+        if (!task.estimate) {
+            return '';
+        }
+        const over = this.overEstimate.has(task.id);
+        return `<span class="estimate-badge ${over ? 'over' : ''}" title="${over ? 'More time tracked than estimated' : 'Estimate'}">
+            Est. ${this.formatEstimate(task.estimate)}${over ? ' — over' : ''}
+        </span>`;
+    }
+
+    // The effort summary covers the tasks the filters show.
+    renderEffortSummary() {
+        const effort = this.effort;
+        const element = document.getElementById('effort-summary');
+        if (!effort || (effort.estimated.minutes === 0 && effort.estimated.points === 0 && effort.tracked_seconds === 0)) {
+            element.textContent = '';
+            return;
+        }
+        const sum = part => [
+            part.minutes ? this.formatDuration(part.minutes * 60) : '',
+            part.points ? `${part.points}pt` : ''
+        ].filter(Boolean).join(' + ') || '0m';
+
+        element.textContent = ` • Estimated ${sum(effort.estimated)} (${sum(effort.remaining)} left, ${sum(effort.completed)} done)` +
+            ` • ${this.formatDuration(effort.tracked_seconds)} tracked`;
+    }
+
+    formatStatus(status) {
+        const label = status.replace('_', ' ');
+        return label.charAt(0).toUpperCase() + label.slice(1);
+    }
+
+    renderStatusSelect(task) {
+        const workflow = this.workflows[task.project || ''] || {};
+        const states = [task.status, ...(workflow[task.status] || [])];
+        const options = states.map(status =>
+            `<option value="${status}" ${status === task.status ? 'selected' : ''}>${this.formatStatus(status)}</option>`
+        ).join('');
+
+        return `
+            <select class="status-select status-${task.status}" onchange="todoApp.transitionTask('${task.id}', this.value)">
+                ${options}
+            </select>
+        `;
     }
 
     showDeleteModal(taskId) {
@@ -196,17 +921,83 @@ class TodoApp {
         return formatted;
     }
 
+    // localDate turns a date stored as midnight UTC into local midnight.
+    localDate(dateString) {
+        const date = new Date(dateString);
+        return new Date(date.getUTCFullYear(), date.getUTCMonth(), date.getUTCDate());
+    }
+
+    formatDay(dateString) {
+        const date = this.localDate(dateString);
+        const today = new Date();
+        today.setHours(0, 0, 0, 0);
+        const diffDays = Math.round((date - today) / (1000 * 60 * 60 * 24));
+
+        let formatted = new Intl.DateTimeFormat('en-US', { month: 'short', day: 'numeric' }).format(date);
+
+        if (diffDays < 0) {
+            formatted += ' (overdue)';
+        } else if (diffDays === 0) {
+            formatted += ' (today)';
+        } else if (diffDays === 1) {
+            formatted += ' (tomorrow)';
+        }
+
+        return formatted;
+    }
+
+    formatDue(task) {
+        return task.due_all_day ? this.formatDay(task.due_date) : this.formatDate(task.due_date);
+    }
+
     isOverdue(task) {
-        if (!task.due_date || task.status === 'completed') return false;
+        if (!task.due_date || this.isClosed(task)) return false;
+        if (task.due_all_day) {
+            const end = this.localDate(task.due_date);
+            end.setDate(end.getDate() + 1);
+            return end <= new Date();
+        }
         return new Date(task.due_date) < new Date();
     }
 
+    isDeferred(task) {
+        if (!task.start_date || this.isClosed(task)) return false;
+        return this.localDate(task.start_date) > new Date();
+    }
+
+    // dropTask places the dragged task in front of the first task whose
+    // middle is below the pointer, in the manual order.
+    async dropTask(event, listElement) {
+        event.preventDefault();
+        const taskId = event.dataTransfer.getData('text/plain');
+        const items = [...listElement.querySelectorAll('.task-item')].filter(item => item.dataset.id !== taskId);
+        let index = items.findIndex(item => {
+            const box = item.getBoundingClientRect();
+            return event.clientY < box.top + box.height / 2;
+        });
+        if (index < 0) {
+            index = items.length;
+        }
+        const beforeId = index > 0 ? items[index - 1].dataset.id : '';
+        const afterId = index < items.length ? items[index].dataset.id : '';
+
+        try {
+            await ReorderTask(taskId, beforeId, afterId);
+        } catch (error) {
+            this.showError('Failed to reorder task: ' + error);
+        }
+        await this.loadTasks();
+        this.render();
+    }
+
     renderTask(task) {
         const isOverdue = this.isOverdue(task);
-        const isCompleted = task.status === 'completed';
+        const isCompleted = this.isClosed(task);
+        const draggable = this.currentFilters.sortField === 'manual';
 
         return `
-            <div class="task-item ${isCompleted ? 'completed' : ''} ${isOverdue ? 'overdue' : ''}">
+            <div class="task-item ${isCompleted ? 'completed' : ''} ${isOverdue ? 'overdue' : ''}" data-id="${task.id}"
+                ${draggable ? `draggable="true" ondragstart="event.dataTransfer.setData('text/plain', '${task.id}')"` : ''}>
                 <div class="task-header">
                     <input
                         type="checkbox"
@@ -219,11 +1010,20 @@ class TodoApp {
                         ${task.description ? `<div class="task-description">${task.description}</div>` : ''}
                         <div class="task-meta">
                             <span class="priority-badge priority-${task.priority}">${task.priority}</span>
-                            ${task.due_date ? `<span class="due-date ${isOverdue ? 'overdue' : ''}">${this.formatDate(task.due_date)}</span>` : ''}
+                            ${task.due_date ? `<span class="due-date ${isOverdue ? 'overdue' : ''}">${this.formatDue(task)}</span>` : ''}
+                            ${this.renderDependencies(task)}
+                            ${this.renderEstimate(task)}
+                            ${this.renderTimeSpent(task)}
+                            ${this.isDeferred(task) ? `<span class="start-date">Starts ${new Intl.DateTimeFormat('en-US', { month: 'short', day: 'numeric' }).format(this.localDate(task.start_date))}</span>` : ''}
                             <span class="created-date">Created ${this.formatDate(task.created_at)}</span>
                         </div>
                     </div>
                     <div class="task-actions">
+                        ${this.renderStatusSelect(task)}
+                        ${this.renderDependencySelect(task)}
+                        ${this.renderTimerButton(task)}
+                        ${this.renderFocusButton(task)}
+                        <button type="button" class="btn btn-secondary" onclick="todoApp.editEstimate('${task.id}')">Estimate</button>
                         <button class="btn btn-danger" onclick="todoApp.showDeleteModal('${task.id}')">
                             Delete
                         </button>
@@ -234,12 +1034,23 @@ class TodoApp {
     }
 
     render() {
-        const activeTasks = this.tasks.filter(task => task.status === 'active');
-        const completedTasks = this.tasks.filter(task => task.status === 'completed');
+        const isBoard = this.view === 'board';
+        document.getElementById('board').style.display = isBoard ? 'flex' : 'none';
+        if (isBoard) {
+            this.renderBoard();
+            document.getElementById('active-tasks-section').style.display = 'none';
+            document.getElementById('completed-tasks-section').style.display = 'none';
+            document.getElementById('empty-state').style.display = 'none';
+            return;
+        }
+
+        const activeTasks = this.tasks.filter(task => !this.isClosed(task));
+        const completedTasks = this.tasks.filter(task => this.isClosed(task));
 
         document.getElementById('active-count').textContent = `${activeTasks.length} active`;
         document.getElementById('completed-count').textContent = `${completedTasks.length} completed`;
         document.getElementById('total-count').textContent = `${this.tasks.length} total`;
+        this.renderEffortSummary();
         document.getElementById('active-counter').textContent = activeTasks.length;
         document.getElementById('completed-counter').textContent = completedTasks.length;
 
@@ -265,9 +1076,9 @@ class TodoApp {
         }
 
         document.getElementById('active-tasks-section').style.display =
-            this.currentFilters.status === 'completed' ? 'none' : 'block';
+            activeTasks.length === 0 && this.currentFilters.status !== 'all' ? 'none' : 'block';
         document.getElementById('completed-tasks-section').style.display =
-            this.currentFilters.status === 'active' ? 'none' : 'block';
+            completedTasks.length === 0 && this.currentFilters.status !== 'all' ? 'none' : 'block';
     }
 
     showError(message) {
@@ -1,24 +1,89 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {config} from '../models';
 import {domain} from '../models';
+import {interchange} from '../models';
+import {main} from '../models';
+import {migrate} from '../models';
 import {time} from '../models';
+import {usecase} from '../models';
+
+export function AddDependency(arg1:string,arg2:string):Promise<domain.Task>;
+
+export function AddTimeEntry(arg1:string,arg2:time.Time,arg3:time.Time,arg4:string):Promise<domain.TimeEntry>;
 
 export function CreateTask(arg1:string,arg2:string):Promise<domain.Task>;
 
-export function CreateTaskWithDetails(arg1:string,arg2:string,arg3:string,arg4:time.Time):Promise<domain.Task>;
+export function CreateTaskWithDetails(arg1:string,arg2:string,arg3:string,arg4:time.Time,arg5:boolean,arg6:time.Time):Promise<domain.Task>;
 
 export function DeleteTask(arg1:string):Promise<void>;
 
+export function DeleteTimeEntry(arg1:string):Promise<void>;
+
+export function DismissMigrationPrompt():Promise<void>;
+
+export function ExportTimeLog(arg1:usecase.TimeFilter,arg2:interchange.TimeLogOptions):Promise<string>;
+
 export function GetAllTasks():Promise<Array<domain.Task>>;
 
+export function GetBoard(arg1:string,arg2:string):Promise<usecase.Board>;
+
+export function GetEffortSummary(arg1:usecase.TaskFilter):Promise<usecase.EffortSummary>;
+
 export function GetFilteredTasks(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<Array<domain.Task>>;
 
+export function GetFocusState():Promise<domain.FocusState>;
+
+export function GetFocusStats(arg1:usecase.TimeFilter):Promise<Array<usecase.FocusDay>>;
+
+export function GetMigrationPrompt():Promise<main.MigrationPrompt>;
+
+export function GetRunningTimer():Promise<domain.TimeEntry>;
+
+export function GetSettings():Promise<main.SettingsView>;
+
 export function GetTask(arg1:string):Promise<domain.Task>;
 
-export function SetTaskDueDate(arg1:string,arg2:time.Time):Promise<domain.Task>;
+export function GetTimeEntries(arg1:usecase.TimeFilter):Promise<Array<domain.TimeEntry>>;
+
+export function GetTimeReport(arg1:usecase.TimeFilter,arg2:string):Promise<usecase.TimeReport>;
+
+export function GetWorkflow(arg1:string):Promise<{[key: string]: Array<string>}>;
+
+export function MigrateLocalTasks():Promise<migrate.Result>;
+
+export function MoveCard(arg1:string,arg2:string,arg3:number):Promise<usecase.MoveCardResult>;
+
+export function PauseFocus():Promise<domain.FocusState>;
+
+export function RemoveDependency(arg1:string,arg2:string):Promise<domain.Task>;
+
+export function ReorderTask(arg1:string,arg2:string,arg3:string):Promise<domain.Task>;
+
+export function ResumeFocus():Promise<domain.FocusState>;
+
+export function SetTaskDueDate(arg1:string,arg2:time.Time,arg3:boolean):Promise<domain.Task>;
+
+export function SetTaskEstimate(arg1:string,arg2:string):Promise<domain.Task>;
 
 export function SetTaskPriority(arg1:string,arg2:string):Promise<domain.Task>;
 
-export function ToggleTaskStatus(arg1:string):Promise<domain.Task>;
+export function SetTaskStartDate(arg1:string,arg2:time.Time):Promise<domain.Task>;
+
+export function SkipFocusPhase():Promise<domain.FocusState>;
+
+export function StartFocus(arg1:string):Promise<domain.FocusState>;
+
+export function StartTimer(arg1:string):Promise<domain.TimeEntry>;
+
+export function StopFocus():Promise<domain.FocusState>;
+
+export function StopTimer():Promise<domain.TimeEntry>;
+
+export function TransitionTask(arg1:string,arg2:string,arg3:boolean):Promise<domain.Task>;
+
+export function UpdateSettings(arg1:config.Config):Promise<main.SettingsUpdate>;
 
 export function UpdateTask(arg1:string,arg2:string,arg3:string):Promise<domain.Task>;
+
+export function UpdateTimeEntry(arg1:string,arg2:time.Time,arg3:time.Time,arg4:string):Promise<domain.TimeEntry>;
@@ -2,42 +2,162 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddDependency(arg1, arg2) {
+  return window['go']['main']['App']['AddDependency'](arg1, arg2);
+}
+
+export function AddTimeEntry(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AddTimeEntry'](arg1, arg2, arg3, arg4);
+}
+
 export function CreateTask(arg1, arg2) {
   return window['go']['main']['App']['CreateTask'](arg1, arg2);
 }
 
-export function CreateTaskWithDetails(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['App']['CreateTaskWithDetails'](arg1, arg2, arg3, arg4);
+export function CreateTaskWithDetails(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['CreateTaskWithDetails'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
 export function DeleteTask(arg1) {
   return window['go']['main']['App']['DeleteTask'](arg1);
 }
 
+export function DeleteTimeEntry(arg1) {
+  return window['go']['main']['App']['DeleteTimeEntry'](arg1);
+}
+
+export function DismissMigrationPrompt() {
+  return window['go']['main']['App']['DismissMigrationPrompt']();
+}
+
+export function ExportTimeLog(arg1, arg2) {
+  return window['go']['main']['App']['ExportTimeLog'](arg1, arg2);
+}
+
 export function GetAllTasks() {
   return window['go']['main']['App']['GetAllTasks']();
 }
 
+export function GetBoard(arg1, arg2) {
+  return window['go']['main']['App']['GetBoard'](arg1, arg2);
+}
+
+export function GetEffortSummary(arg1) {
+  return window['go']['main']['App']['GetEffortSummary'](arg1);
+}
+
 export function GetFilteredTasks(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['GetFilteredTasks'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function GetFocusState() {
+  return window['go']['main']['App']['GetFocusState']();
+}
+
+export function GetFocusStats(arg1) {
+  return window['go']['main']['App']['GetFocusStats'](arg1);
+}
+
+export function GetMigrationPrompt() {
+  return window['go']['main']['App']['GetMigrationPrompt']();
+}
+
+export function GetRunningTimer() {
+  return window['go']['main']['App']['GetRunningTimer']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
 export function GetTask(arg1) {
   return window['go']['main']['App']['GetTask'](arg1);
 }
 
-export function SetTaskDueDate(arg1, arg2) {
-  return window['go']['main']['App']['SetTaskDueDate'](arg1, arg2);
+export function GetTimeEntries(arg1) {
+  return window['go']['main']['App']['GetTimeEntries'](arg1);
+}
+
+export function GetTimeReport(arg1, arg2) {
+  return window['go']['main']['App']['GetTimeReport'](arg1, arg2);
+}
+
+export function GetWorkflow(arg1) {
+  return window['go']['main']['App']['GetWorkflow'](arg1);
+}
+
+export function MigrateLocalTasks() {
+  return window['go']['main']['App']['MigrateLocalTasks']();
+}
+
+export function MoveCard(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveCard'](arg1, arg2, arg3);
+}
+
+export function PauseFocus() {
+  return window['go']['main']['App']['PauseFocus']();
+}
+
+export function RemoveDependency(arg1, arg2) {
+  return window['go']['main']['App']['RemoveDependency'](arg1, arg2);
+}
+
+export function ReorderTask(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ReorderTask'](arg1, arg2, arg3);
+}
+
+export function ResumeFocus() {
+  return window['go']['main']['App']['ResumeFocus']();
+}
+
+export function SetTaskDueDate(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetTaskDueDate'](arg1, arg2, arg3);
+}
+
+export function SetTaskEstimate(arg1, arg2) {
+  return window['go']['main']['App']['SetTaskEstimate'](arg1, arg2);
 }
 
 export function SetTaskPriority(arg1, arg2) {
   return window['go']['main']['App']['SetTaskPriority'](arg1, arg2);
 }
 
-export function ToggleTaskStatus(arg1) {
-  return window['go']['main']['App']['ToggleTaskStatus'](arg1);
+export function SetTaskStartDate(arg1, arg2) {
+  return window['go']['main']['App']['SetTaskStartDate'](arg1, arg2);
+}
+
+export function SkipFocusPhase() {
+  return window['go']['main']['App']['SkipFocusPhase']();
+}
+
+export function StartFocus(arg1) {
+  return window['go']['main']['App']['StartFocus'](arg1);
+}
+
+export function StartTimer(arg1) {
+  return window['go']['main']['App']['StartTimer'](arg1);
+}
+
+export function StopFocus() {
+  return window['go']['main']['App']['StopFocus']();
+}
+
+export function StopTimer() {
+  return window['go']['main']['App']['StopTimer']();
+}
+
+export function TransitionTask(arg1, arg2, arg3) {
+  return window['go']['main']['App']['TransitionTask'](arg1, arg2, arg3);
+}
+
+export function UpdateSettings(arg1) {
+  return window['go']['main']['App']['UpdateSettings'](arg1);
 }
 
 export function UpdateTask(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateTask'](arg1, arg2, arg3);
 }
+
+export function UpdateTimeEntry(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['UpdateTimeEntry'](arg1, arg2, arg3, arg4);
+}
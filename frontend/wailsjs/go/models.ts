@@ -1,5 +1,284 @@
+export namespace config {
+	
+	export class BoardConfig {
+	    columns: string;
+	    wip_limits: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BoardConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.columns = source["columns"];
+	        this.wip_limits = source["wip_limits"];
+	    }
+	}
+	export class CalendarConfig {
+	    week_start: string;
+	    time_zone: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.week_start = source["week_start"];
+	        this.time_zone = source["time_zone"];
+	    }
+	}
+	
+	export class UIConfig {
+	    theme: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UIConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.theme = source["theme"];
+	    }
+	}
+	
+	export class ReminderConfig {
+	    enabled: boolean;
+	    lead_time: string;
+	    default_time: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReminderConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.lead_time = source["lead_time"];
+	        this.default_time = source["default_time"];
+	    }
+	}
+	
+	export class TaskConfig {
+	    priority: string;
+	    sort_field: string;
+	    sort_order: string;
+	    sort_locale: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TaskConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.priority = source["priority"];
+	        this.sort_field = source["sort_field"];
+	        this.sort_order = source["sort_order"];
+	        this.sort_locale = source["sort_locale"];
+	    }
+	}
+	
+	export class WindowConfig {
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WindowConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	
+	export class StorageConfig {
+	    backend: string;
+	    dsn: string;
+	    data_dir: string;
+	    todotxt_dir: string;
+	    markdown_file: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StorageConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend = source["backend"];
+	        this.dsn = source["dsn"];
+	        this.data_dir = source["data_dir"];
+	        this.todotxt_dir = source["todotxt_dir"];
+	        this.markdown_file = source["markdown_file"];
+	    }
+	}
+	
+	export class FocusConfig {
+	    work: string;
+	    short_break: string;
+	    long_break: string;
+	    cycles: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FocusConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.work = source["work"];
+	        this.short_break = source["short_break"];
+	        this.long_break = source["long_break"];
+	        this.cycles = source["cycles"];
+	    }
+	}
+	
+	export class SyncConfig {
+	    folder: string;
+	    listen: string;
+	    peer: string;
+	    secret: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.listen = source["listen"];
+	        this.peer = source["peer"];
+	        this.secret = source["secret"];
+	    }
+	}
+	
+	export class FeedConfig {
+	    addr: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FeedConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.addr = source["addr"];
+	    }
+	}
+	
+	export class CalDAVConfig {
+	    addr: string;
+	    user: string;
+	    password: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalDAVConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.addr = source["addr"];
+	        this.user = source["user"];
+	        this.password = source["password"];
+	    }
+	}
+	
+	export class Config {
+	    storage: StorageConfig;
+	    window: WindowConfig;
+	    tasks: TaskConfig;
+	    calendar: CalendarConfig;
+	    reminders: ReminderConfig;
+	    board: BoardConfig;
+	    focus: FocusConfig;
+	    sync: SyncConfig;
+	    feed: FeedConfig;
+	    caldav: CalDAVConfig;
+	    ui: UIConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.storage = this.convertValues(source["storage"], StorageConfig);
+	        this.window = this.convertValues(source["window"], WindowConfig);
+	        this.tasks = this.convertValues(source["tasks"], TaskConfig);
+	        this.calendar = this.convertValues(source["calendar"], CalendarConfig);
+	        this.reminders = this.convertValues(source["reminders"], ReminderConfig);
+	        this.board = this.convertValues(source["board"], BoardConfig);
+	        this.focus = this.convertValues(source["focus"], FocusConfig);
+	        this.sync = this.convertValues(source["sync"], SyncConfig);
+	        this.feed = this.convertValues(source["feed"], FeedConfig);
+	        this.caldav = this.convertValues(source["caldav"], CalDAVConfig);
+	        this.ui = this.convertValues(source["ui"], UIConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace domain {
 	
+	export class Estimate {
+	    minutes?: number;
+	    points?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Estimate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.minutes = source["minutes"];
+	        this.points = source["points"];
+	    }
+	}
+	export class FocusState {
+	    active: boolean;
+	    task_id?: string;
+	    phase?: string;
+	    round?: number;
+	    cycles?: number;
+	    completed: number;
+	    paused: boolean;
+	    remaining: number;
+	    length: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FocusState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.task_id = source["task_id"];
+	        this.phase = source["phase"];
+	        this.round = source["round"];
+	        this.cycles = source["cycles"];
+	        this.completed = source["completed"];
+	        this.paused = source["paused"];
+	        this.remaining = source["remaining"];
+	        this.length = source["length"];
+	    }
+	}
 	export class Task {
 	    id: string;
 	    title: string;
@@ -7,6 +286,13 @@ export namespace domain {
 	    status: string;
 	    priority: string;
 	    due_date?: time.Time;
+	    due_all_day?: boolean;
+	    start_date?: time.Time;
+	    blocked_by?: string[];
+	    estimate?: Estimate;
+	    rank?: string;
+	    started_at?: time.Time;
+	    completed_at?: time.Time;
 	    created_at: time.Time;
 	    updated_at: time.Time;
 	
@@ -22,6 +308,13 @@ export namespace domain {
 	        this.status = source["status"];
 	        this.priority = source["priority"];
 	        this.due_date = this.convertValues(source["due_date"], time.Time);
+	        this.due_all_day = source["due_all_day"];
+	        this.start_date = this.convertValues(source["start_date"], time.Time);
+	        this.blocked_by = source["blocked_by"];
+	        this.estimate = this.convertValues(source["estimate"], Estimate);
+	        this.rank = source["rank"];
+	        this.started_at = this.convertValues(source["started_at"], time.Time);
+	        this.completed_at = this.convertValues(source["completed_at"], time.Time);
 	        this.created_at = this.convertValues(source["created_at"], time.Time);
 	        this.updated_at = this.convertValues(source["updated_at"], time.Time);
 	    }
@@ -44,6 +337,195 @@ export namespace domain {
 		    return a;
 		}
 	}
+	export class TimeEntry {
+	    id: string;
+	    task_id: string;
+	    start: time.Time;
+	    end?: time.Time;
+	    note?: string;
+	    focus?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.task_id = source["task_id"];
+	        this.start = this.convertValues(source["start"], time.Time);
+	        this.end = this.convertValues(source["end"], time.Time);
+	        this.note = source["note"];
+	        this.focus = source["focus"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimeTotal {
+	    group: string;
+	    label: string;
+	    seconds: number;
+	    entries: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeTotal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.group = source["group"];
+	        this.label = source["label"];
+	        this.seconds = source["seconds"];
+	        this.entries = source["entries"];
+	    }
+	}
+
+}
+
+export namespace interchange {
+	
+	export class TimeLogOptions {
+	    group_by: string;
+	    summary?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeLogOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.group_by = source["group_by"];
+	        this.summary = source["summary"];
+	    }
+	}
+
+}
+
+export namespace main {
+	
+	export class MigrationPrompt {
+	    source: string;
+	    tasks: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MigrationPrompt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.tasks = source["tasks"];
+	    }
+	}
+	
+	export class SettingsUpdate {
+	    settings: config.Config;
+	    restart_required: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingsUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.settings = this.convertValues(source["settings"], config.Config);
+	        this.restart_required = source["restart_required"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SettingsView {
+	    settings: config.Config;
+	    path: string;
+	    overridden: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingsView(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.settings = this.convertValues(source["settings"], config.Config);
+	        this.path = source["path"];
+	        this.overridden = source["overridden"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace migrate {
+	
+	export class Result {
+	    dry_run: boolean;
+	    source: number;
+	    copied: number;
+	    existing: number;
+	    target: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dry_run = source["dry_run"];
+	        this.source = source["source"];
+	        this.copied = source["copied"];
+	        this.existing = source["existing"];
+	        this.target = source["target"];
+	    }
+	}
 
 }
 
@@ -64,3 +546,272 @@ export namespace time {
 
 }
 
+export namespace usecase {
+	
+	export class Column {
+	    name: string;
+	    tasks: domain.Task[];
+	    wip_limit?: number;
+	    over_limit?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Column(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.tasks = this.convertValues(source["tasks"], domain.Task);
+	        this.wip_limit = source["wip_limit"];
+	        this.over_limit = source["over_limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Board {
+	    columns: Column[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Board(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.columns = this.convertValues(source["columns"], Column);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Effort {
+	    minutes: number;
+	    points: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Effort(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.minutes = source["minutes"];
+	        this.points = source["points"];
+	    }
+	}
+	export class EffortSummary {
+	    tasks: number;
+	    unestimated: number;
+	    estimated: Effort;
+	    remaining: Effort;
+	    completed: Effort;
+	    tracked_seconds: number;
+	    over_estimate: domain.Task[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EffortSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tasks = source["tasks"];
+	        this.unestimated = source["unestimated"];
+	        this.estimated = this.convertValues(source["estimated"], Effort);
+	        this.remaining = this.convertValues(source["remaining"], Effort);
+	        this.completed = this.convertValues(source["completed"], Effort);
+	        this.tracked_seconds = source["tracked_seconds"];
+	        this.over_estimate = this.convertValues(source["over_estimate"], domain.Task);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FocusDay {
+	    date: string;
+	    pomodoros: number;
+	    seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FocusDay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.date = source["date"];
+	        this.pomodoros = source["pomodoros"];
+	        this.seconds = source["seconds"];
+	    }
+	}
+	export class MoveCardResult {
+	    task: domain.Task;
+	    warning?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MoveCardResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.task = this.convertValues(source["task"], domain.Task);
+	        this.warning = source["warning"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TaskFilter {
+	    status: string;
+	    priority: string;
+	    date: string;
+	    project?: string;
+	    tag?: string;
+	    estimate?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TaskFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.priority = source["priority"];
+	        this.date = source["date"];
+	        this.project = source["project"];
+	        this.tag = source["tag"];
+	        this.estimate = source["estimate"];
+	    }
+	}
+	export class TimeFilter {
+	    task_id?: string;
+	    project?: string;
+	    period?: string;
+	    from?: time.Time;
+	    to?: time.Time;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.task_id = source["task_id"];
+	        this.project = source["project"];
+	        this.period = source["period"];
+	        this.from = this.convertValues(source["from"], time.Time);
+	        this.to = this.convertValues(source["to"], time.Time);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimeReport {
+	    group_by: string;
+	    totals: domain.TimeTotal[];
+	    seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.group_by = source["group_by"];
+	        this.totals = this.convertValues(source["totals"], domain.TimeTotal);
+	        this.seconds = source["seconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
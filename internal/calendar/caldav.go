@@ -0,0 +1,652 @@
+package calendar
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+	"todo-list/internal/repository"
+	"todo-list/internal/usecase"
+)
+
+const (
+	DefaultCalDAVAddr = ":8781"
+
+	// DAVPrefix is both the principal and the calendar home; the single task
+	// calendar lives below it.
+	DAVPrefix    = "/dav/"
+	CalendarPath = DAVPrefix + "tasks/"
+
+	nsDAV       = "DAV:"
+	nsCalDAV    = "urn:ietf:params:xml:ns:caldav"
+	nsCalServer = "http://calendarserver.org/ns/"
+
+	maxICalBody = 1 << 20
+)
+
+type CalDAVOptions struct {
+	Username string
+	Password string
+}
+
+// CalDAVHandler is a minimal CalDAV server exposing all tasks as one VTODO
+// calendar. It implements what common task clients need: discovery via
+// PROPFIND, calendar-query and calendar-multiget reports, and GET/PUT/DELETE
+// of single tasks guarded by ETags.
+type CalDAVHandler struct {
+	uc   *usecase.TaskUseCase
+	opts CalDAVOptions
+}
+
+func NewCalDAVHandler(uc *usecase.TaskUseCase, opts CalDAVOptions) *CalDAVHandler {
+	return &CalDAVHandler{uc: uc, opts: opts}
+}
+
+// ETag changes whenever the task is written. Storage keeps UpdatedAt, so no
+// separate version counter is needed.
+func ETag(task *domain.Task) string {
+	return `"` + strconv.FormatInt(task.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+func (h *CalDAVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="todo-list"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.URL.Path == "/.well-known/caldav" || r.URL.Path == "/" {
+		http.Redirect(w, r, DAVPrefix, http.StatusMovedPermanently)
+		return
+	}
+
+	w.Header().Set("DAV", "1, 3, calendar-access")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		w.WriteHeader(http.StatusOK)
+	case "PROPFIND":
+		h.propfind(w, r)
+	case "REPORT":
+		h.report(w, r)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r)
+	case http.MethodPut:
+		h.put(w, r)
+	case http.MethodDelete:
+		h.delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CalDAVHandler) authorized(r *http.Request) bool {
+	if h.opts.Password == "" {
+		return true
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(h.opts.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(h.opts.Password)) == 1
+	return userOK && passOK
+}
+
+// resource is what a request path points at.
+type resource int
+
+const (
+	resourceNone resource = iota
+	resourceHome
+	resourceCalendar
+	resourceTask
+)
+
+func parsePath(path string) (resource, string) {
+	switch {
+	case path == DAVPrefix || path+"/" == DAVPrefix:
+		return resourceHome, ""
+	case path == CalendarPath || path+"/" == CalendarPath:
+		return resourceCalendar, ""
+	case strings.HasPrefix(path, CalendarPath) && strings.HasSuffix(path, ".ics"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, CalendarPath), ".ics")
+		if id != "" && !strings.Contains(id, "/") {
+			return resourceTask, id
+		}
+	}
+	return resourceNone, ""
+}
+
+func taskHref(task *domain.Task) string {
+	return CalendarPath + task.ID + ".ics"
+}
+
+func (h *CalDAVHandler) get(w http.ResponseWriter, r *http.Request) {
+	kind, id := parsePath(r.URL.Path)
+	if kind == resourceCalendar {
+		// Some clients fetch the collection itself; serve it like the feed.
+		var body bytes.Buffer
+		if err := h.uc.ExportTasksICal(r.Context(), &body, usecase.TaskFilter{}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeICal(w, r, body.Bytes(), "")
+		return
+	}
+	if kind != resourceTask {
+		http.NotFound(w, r)
+		return
+	}
+
+	task, err := h.uc.GetTask(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	body, err := calendarData(task)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeICal(w, r, body, ETag(task))
+}
+
+func writeICal(w http.ResponseWriter, r *http.Request, body []byte, etag string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+func calendarData(task *domain.Task) ([]byte, error) {
+	var body bytes.Buffer
+	if err := interchange.WriteICalendar(&body, []*domain.Task{task}, ""); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// ifMatch implements the If-Match and If-None-Match preconditions against
+// the stored task, which is nil when the resource does not exist.
+func ifMatch(r *http.Request) func(current *domain.Task) bool {
+	match := r.Header.Get("If-Match")
+	noneMatch := r.Header.Get("If-None-Match")
+
+	return func(current *domain.Task) bool {
+		if noneMatch == "*" && current != nil {
+			return false
+		}
+		if match == "" {
+			return true
+		}
+		if current == nil {
+			return false
+		}
+		return match == "*" || etagListContains(match, ETag(current))
+	}
+}
+
+func etagListContains(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *CalDAVHandler) put(w http.ResponseWriter, r *http.Request) {
+	kind, id := parsePath(r.URL.Path)
+	if kind != resourceTask {
+		http.Error(w, "tasks can only be written inside "+CalendarPath, http.StatusForbidden)
+		return
+	}
+
+	records, err := interchange.ReadICalendar(io.LimitReader(r.Body, maxICalBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(records) != 1 {
+		// RFC 4791 5.3.2.1: a resource holds exactly one component.
+		writePrecondition(w, http.StatusForbidden, nsCalDAV, "valid-calendar-object-resource",
+			fmt.Sprintf("expected exactly one VTODO, got %d", len(records)))
+		return
+	}
+	record := records[0]
+	if !record.Valid() {
+		writePrecondition(w, http.StatusForbidden, nsCalDAV, "valid-calendar-data", strings.Join(record.Errors, "; "))
+		return
+	}
+
+	// The resource name identifies the task; clients pick it and expect it
+	// to stay stable, even when it differs from the UID they sent.
+	stored, created, err := h.uc.UpsertTask(r.Context(), id, ifMatch(r), func(current *domain.Task) *domain.Task {
+		return interchange.MergeICalendar(current, record)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", ETag(stored))
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *CalDAVHandler) delete(w http.ResponseWriter, r *http.Request) {
+	kind, id := parsePath(r.URL.Path)
+	if kind != resourceTask {
+		http.Error(w, "only single tasks can be deleted", http.StatusForbidden)
+		return
+	}
+
+	if err := h.uc.DeleteTaskIf(r.Context(), id, ifMatch(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrTaskNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, usecase.ErrTaskModified):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writePrecondition(w http.ResponseWriter, status int, space, name, description string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`+"\n"+
+		`<D:error xmlns:D="DAV:"><%s xmlns="%s"/><D:responsedescription>%s</D:responsedescription></D:error>`,
+		name, space, xmlEscape(description))
+}
+
+// anyName collects the names of arbitrary child elements.
+type anyName struct {
+	XMLName xml.Name
+}
+
+type propRequest struct {
+	Names []anyName `xml:",any"`
+}
+
+type propfindRequest struct {
+	AllProp  *struct{}    `xml:"DAV: allprop"`
+	PropName *struct{}    `xml:"DAV: propname"`
+	Prop     *propRequest `xml:"DAV: prop"`
+}
+
+type compFilter struct {
+	Name      string       `xml:"name,attr"`
+	Filters   []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	TimeRange *struct {
+		Start string `xml:"start,attr"`
+		End   string `xml:"end,attr"`
+	} `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+}
+
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    *propRequest `xml:"DAV: prop"`
+	Hrefs   []string     `xml:"DAV: href"`
+	Filter  *compFilter  `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+var defaultProps = []xml.Name{
+	{Space: nsDAV, Local: "resourcetype"},
+	{Space: nsDAV, Local: "displayname"},
+	{Space: nsDAV, Local: "getetag"},
+	{Space: nsDAV, Local: "getcontenttype"},
+	{Space: nsCalServer, Local: "getctag"},
+}
+
+func requestedProps(prop *propRequest) []xml.Name {
+	if prop == nil {
+		return defaultProps
+	}
+	names := make([]xml.Name, len(prop.Names))
+	for i, name := range prop.Names {
+		names[i] = name.XMLName
+	}
+	return names
+}
+
+func (h *CalDAVHandler) propfind(w http.ResponseWriter, r *http.Request) {
+	var req propfindRequest
+	if err := decodeXML(r.Body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	props := requestedProps(req.Prop)
+
+	depth := r.Header.Get("Depth")
+	if depth == "infinity" {
+		writePrecondition(w, http.StatusForbidden, nsDAV, "propfind-finite-depth", "Depth: infinity is not supported")
+		return
+	}
+
+	ctx := r.Context()
+	var responses []davResponse
+
+	kind, id := parsePath(r.URL.Path)
+	switch kind {
+	case resourceHome:
+		responses = append(responses, h.propResponse(DAVPrefix, resourceHome, nil, nil, props))
+		if depth == "1" {
+			tasks, err := h.uc.GetFilteredAndSortedTasks(ctx, usecase.TaskFilter{}, usecase.TaskSort{})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			responses = append(responses, h.propResponse(CalendarPath, resourceCalendar, nil, tasks, props))
+		}
+	case resourceCalendar:
+		tasks, err := h.uc.GetFilteredAndSortedTasks(ctx, usecase.TaskFilter{}, usecase.TaskSort{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		responses = append(responses, h.propResponse(CalendarPath, resourceCalendar, nil, tasks, props))
+		if depth == "1" {
+			for _, task := range tasks {
+				responses = append(responses, h.propResponse(taskHref(task), resourceTask, task, nil, props))
+			}
+		}
+	case resourceTask:
+		task, err := h.uc.GetTask(ctx, id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		responses = append(responses, h.propResponse(taskHref(task), resourceTask, task, nil, props))
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	writeMultistatus(w, responses)
+}
+
+func (h *CalDAVHandler) report(w http.ResponseWriter, r *http.Request) {
+	var req reportRequest
+	if err := decodeXML(r.Body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if kind, _ := parsePath(r.URL.Path); kind != resourceCalendar {
+		http.Error(w, "reports are only supported on "+CalendarPath, http.StatusForbidden)
+		return
+	}
+	props := requestedProps(req.Prop)
+	ctx := r.Context()
+
+	var responses []davResponse
+	switch req.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		tasks, err := h.uc.GetFilteredAndSortedTasks(ctx, usecase.TaskFilter{}, usecase.TaskSort{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, task := range tasks {
+			if matchesFilter(task, req.Filter) {
+				responses = append(responses, h.propResponse(taskHref(task), resourceTask, task, nil, props))
+			}
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, href := range req.Hrefs {
+			kind, id := parsePath(hrefPath(href))
+			if kind != resourceTask {
+				responses = append(responses, davResponse{Href: href, Status: "HTTP/1.1 404 Not Found"})
+				continue
+			}
+			task, err := h.uc.GetTask(ctx, id)
+			if err != nil {
+				responses = append(responses, davResponse{Href: href, Status: "HTTP/1.1 404 Not Found"})
+				continue
+			}
+			responses = append(responses, h.propResponse(taskHref(task), resourceTask, task, nil, props))
+		}
+	default:
+		writePrecondition(w, http.StatusForbidden, nsDAV, "supported-report", "unsupported report "+req.XMLName.Local)
+		return
+	}
+
+	writeMultistatus(w, responses)
+}
+
+// hrefPath accepts both absolute URLs and paths in multiget hrefs.
+func hrefPath(href string) string {
+	if i := strings.Index(href, "://"); i >= 0 {
+		rest := href[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			return rest[j:]
+		}
+		return "/"
+	}
+	return href
+}
+
+// matchesFilter evaluates the parts of a calendar-query filter tasks can
+// satisfy: the component name and a time range on the due date. Tasks
+// without a due date match every range, as RFC 4791 9.9 prescribes for
+// VTODOs without DTSTART or DUE.
+func matchesFilter(task *domain.Task, filter *compFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if !strings.EqualFold(filter.Name, "VCALENDAR") {
+		return false
+	}
+
+	for _, child := range filter.Filters {
+		if !strings.EqualFold(child.Name, "VTODO") {
+			return false
+		}
+		if child.TimeRange != nil && task.DueDate != nil {
+			// An all-day task covers its whole day, [dueFrom, dueTo), in
+			// the server's zone.
+			dueFrom, dueTo := task.DueSpan(time.Local)
+			if start, err := time.Parse("20060102T150405Z", child.TimeRange.Start); err == nil {
+				if dueTo.Before(start) || task.DueAllDay && dueTo.Equal(start) {
+					return false
+				}
+			}
+			if end, err := time.Parse("20060102T150405Z", child.TimeRange.End); err == nil && !dueFrom.Before(end) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func decodeXML(body io.Reader, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(body, maxICalBody))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		// An empty PROPFIND body means allprop.
+		return nil
+	}
+	return xml.Unmarshal(data, v)
+}
+
+type davResponse struct {
+	Href      string
+	Status    string
+	Propstats []davPropstat
+}
+
+type davPropstat struct {
+	Props  []string
+	Status string
+}
+
+// propResponse renders the requested properties of one resource. tasks is
+// only used for the calendar collection's CTag.
+func (h *CalDAVHandler) propResponse(href string, kind resource, task *domain.Task, tasks []*domain.Task, names []xml.Name) davResponse {
+	var found, missing []string
+	for _, name := range names {
+		if value, ok := h.propValue(kind, task, tasks, name); ok {
+			found = append(found, value)
+		} else {
+			missing = append(missing, emptyElement(name))
+		}
+	}
+
+	response := davResponse{Href: href}
+	if len(found) > 0 {
+		response.Propstats = append(response.Propstats, davPropstat{Props: found, Status: "HTTP/1.1 200 OK"})
+	}
+	if len(missing) > 0 {
+		response.Propstats = append(response.Propstats, davPropstat{Props: missing, Status: "HTTP/1.1 404 Not Found"})
+	}
+	return response
+}
+
+func (h *CalDAVHandler) propValue(kind resource, task *domain.Task, tasks []*domain.Task, name xml.Name) (string, bool) {
+	switch name {
+	case xml.Name{Space: nsDAV, Local: "resourcetype"}:
+		switch kind {
+		case resourceHome:
+			return "<D:resourcetype><D:collection/><D:principal/></D:resourcetype>", true
+		case resourceCalendar:
+			return "<D:resourcetype><D:collection/><C:calendar/></D:resourcetype>", true
+		}
+		return "<D:resourcetype/>", true
+	case xml.Name{Space: nsDAV, Local: "displayname"}:
+		switch kind {
+		case resourceHome:
+			return "<D:displayname>todo-list</D:displayname>", true
+		case resourceCalendar:
+			return "<D:displayname>Tasks</D:displayname>", true
+		}
+		return element("D:displayname", task.Title), true
+	case xml.Name{Space: nsDAV, Local: "current-user-principal"},
+		xml.Name{Space: nsDAV, Local: "principal-URL"},
+		xml.Name{Space: nsDAV, Local: "owner"}:
+		return "<D:" + name.Local + "><D:href>" + DAVPrefix + "</D:href></D:" + name.Local + ">", true
+	case xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}:
+		return "<C:calendar-home-set><D:href>" + DAVPrefix + "</D:href></C:calendar-home-set>", true
+	case xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}:
+		return "<D:current-user-privilege-set>" +
+			"<D:privilege><D:read/></D:privilege>" +
+			"<D:privilege><D:write/></D:privilege>" +
+			"<D:privilege><D:write-content/></D:privilege>" +
+			"<D:privilege><D:bind/></D:privilege>" +
+			"<D:privilege><D:unbind/></D:privilege>" +
+			"</D:current-user-privilege-set>", true
+	}
+
+	switch kind {
+	case resourceCalendar:
+		switch name {
+		case xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}:
+			return `<C:supported-calendar-component-set><C:comp name="VTODO"/></C:supported-calendar-component-set>`, true
+		case xml.Name{Space: nsDAV, Local: "supported-report-set"}:
+			return "<D:supported-report-set>" +
+				"<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>" +
+				"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>" +
+				"</D:supported-report-set>", true
+		case xml.Name{Space: nsCalServer, Local: "getctag"}:
+			return element("CS:getctag", collectionTag(tasks)), true
+		}
+	case resourceTask:
+		switch name {
+		case xml.Name{Space: nsDAV, Local: "getetag"}:
+			return element("D:getetag", ETag(task)), true
+		case xml.Name{Space: nsDAV, Local: "getcontenttype"}:
+			return "<D:getcontenttype>text/calendar; charset=utf-8; component=VTODO</D:getcontenttype>", true
+		case xml.Name{Space: nsDAV, Local: "getlastmodified"}:
+			return element("D:getlastmodified", task.UpdatedAt.UTC().Format(http.TimeFormat)), true
+		case xml.Name{Space: nsCalDAV, Local: "calendar-data"}:
+			data, err := calendarData(task)
+			if err != nil {
+				return "", false
+			}
+			return element("C:calendar-data", string(data)), true
+		}
+	}
+
+	return "", false
+}
+
+// collectionTag changes whenever a task in the calendar is added, changed
+// or removed, so clients can skip a full listing.
+func collectionTag(tasks []*domain.Task) string {
+	var latest time.Time
+	for _, task := range tasks {
+		if task.UpdatedAt.After(latest) {
+			latest = task.UpdatedAt
+		}
+	}
+	return fmt.Sprintf("%d-%s", len(tasks), strconv.FormatInt(latest.UnixNano(), 36))
+}
+
+func element(name, value string) string {
+	return "<" + name + ">" + xmlEscape(value) + "</" + name + ">"
+}
+
+func emptyElement(name xml.Name) string {
+	return fmt.Sprintf(`<%s xmlns="%s"/>`, name.Local, xmlEscape(name.Space))
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []davResponse) {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="` + nsCalDAV + `" xmlns:CS="` + nsCalServer + `">`)
+	for _, response := range responses {
+		b.WriteString("<D:response>")
+		b.WriteString(element("D:href", response.Href))
+		if response.Status != "" {
+			b.WriteString(element("D:status", response.Status))
+		}
+		for _, propstat := range response.Propstats {
+			b.WriteString("<D:propstat><D:prop>")
+			b.WriteString(strings.Join(propstat.Props, ""))
+			b.WriteString("</D:prop>")
+			b.WriteString(element("D:status", propstat.Status))
+			b.WriteString("</D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+	b.WriteString("</D:multistatus>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
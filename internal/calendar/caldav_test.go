@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/internal/usecase"
+)
+
+func newTestCalDAV(t *testing.T) (*CalDAVHandler, *usecase.TaskUseCase) {
+	t.Helper()
+	uc := usecase.NewTaskUseCase(service.NewTaskService(repository.NewMemoryTaskRepository()))
+	return NewCalDAVHandler(uc, CalDAVOptions{}), uc
+}
+
+func serve(h http.Handler, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func createTask(t *testing.T, uc *usecase.TaskUseCase, title string) *domain.Task {
+	t.Helper()
+	task, err := uc.CreateTask(context.Background(), usecase.CreateTaskRequest{Title: title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func vtodo(uid string, lines ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\nBEGIN:VTODO\r\nUID:" + uid + "\r\n" +
+		strings.Join(lines, "\r\n") + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+}
+
+func TestCalDAVPropfindListsTasks(t *testing.T) {
+	h, uc := newTestCalDAV(t)
+	task := createTask(t, uc, "Water plants")
+
+	body := `<?xml version="1.0"?><D:propfind xmlns:D="DAV:"><D:prop><D:getetag/><D:resourcetype/></D:prop></D:propfind>`
+	w := serve(h, "PROPFIND", CalendarPath, body, map[string]string{"Depth": "1"})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusMultiStatus, w.Body)
+	}
+	got := w.Body.String()
+	for _, want := range []string{
+		"<D:href>" + CalendarPath + "</D:href>",
+		"<C:calendar/>",
+		"<D:href>" + taskHref(task) + "</D:href>",
+		"<D:getetag>" + xmlEscape(ETag(task)) + "</D:getetag>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("response lacks %s:\n%s", want, got)
+		}
+	}
+
+	w = serve(h, "PROPFIND", CalendarPath, body, map[string]string{"Depth": "infinity"})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Depth: infinity got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCalDAVReports(t *testing.T) {
+	h, uc := newTestCalDAV(t)
+	ctx := context.Background()
+	due := createTask(t, uc, "Pay rent")
+	dueDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	if _, err := uc.SetTaskDueDate(ctx, due.ID, &dueDate, true); err != nil {
+		t.Fatal(err)
+	}
+	later := createTask(t, uc, "File taxes")
+	laterDate := time.Date(2024, 4, 15, 0, 0, 0, 0, time.Local)
+	if _, err := uc.SetTaskDueDate(ctx, later.ID, &laterDate, true); err != nil {
+		t.Fatal(err)
+	}
+
+	query := `<?xml version="1.0"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO">
+    <C:time-range start="20240225T000000Z" end="20240310T000000Z"/>
+  </C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`
+	w := serve(h, "REPORT", CalendarPath, query, nil)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("calendar-query status %d: %s", w.Code, w.Body)
+	}
+	if got := w.Body.String(); !strings.Contains(got, taskHref(due)) || strings.Contains(got, taskHref(later)) {
+		t.Errorf("calendar-query should match only %s:\n%s", due.ID, got)
+	}
+
+	multiget := `<?xml version="1.0"?>
+<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <D:href>` + taskHref(later) + `</D:href>
+  <D:href>` + CalendarPath + `missing.ics</D:href>
+</C:calendar-multiget>`
+	w = serve(h, "REPORT", CalendarPath, multiget, nil)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("calendar-multiget status %d: %s", w.Code, w.Body)
+	}
+	got := w.Body.String()
+	if !strings.Contains(got, "SUMMARY:File taxes") {
+		t.Errorf("calendar-multiget lacks the calendar data of %s:\n%s", later.ID, got)
+	}
+	if !strings.Contains(got, "missing.ics</D:href><D:status>HTTP/1.1 404 Not Found") {
+		t.Errorf("calendar-multiget should report the missing task as not found:\n%s", got)
+	}
+}
+
+func TestCalDAVPutHonoursPreconditions(t *testing.T) {
+	h, uc := newTestCalDAV(t)
+	path := CalendarPath + "new-task.ics"
+
+	w := serve(h, http.MethodPut, path, vtodo("client-uid", "SUMMARY:Call plumber"), map[string]string{"If-None-Match": "*"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status %d: %s", w.Code, w.Body)
+	}
+	etag := w.Header().Get("ETag")
+	task, err := uc.GetTask(context.Background(), "new-task")
+	if err != nil {
+		t.Fatalf("task not stored under its resource name: %v", err)
+	}
+	if etag != ETag(task) {
+		t.Errorf("ETag %s, want %s", etag, ETag(task))
+	}
+
+	w = serve(h, http.MethodPut, path, vtodo("client-uid", "SUMMARY:Call plumber again"), map[string]string{"If-None-Match": "*"})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("If-None-Match: * on an existing task got status %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+
+	w = serve(h, http.MethodPut, path, vtodo("client-uid", "SUMMARY:Call plumber today"), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("update status %d: %s", w.Code, w.Body)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("ETag did not change on update")
+	}
+
+	// etag is stale now.
+	w = serve(h, http.MethodPut, path, vtodo("client-uid", "SUMMARY:Lost update"), map[string]string{"If-Match": etag})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match got status %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	task, err = uc.GetTask(context.Background(), "new-task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Title != "Call plumber today" {
+		t.Errorf("title %q after a rejected update, want %q", task.Title, "Call plumber today")
+	}
+
+	w = serve(h, http.MethodPut, CalendarPath+"other.ics", vtodo("a", "SUMMARY:A")+vtodo("b", "SUMMARY:B"), nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("two VTODOs got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestCalDAVPutKeepsPropertiesTheClientDropped(t *testing.T) {
+	h, uc := newTestCalDAV(t)
+	ctx := context.Background()
+	blocker := createTask(t, uc, "Buy paint")
+	task := createTask(t, uc, "Paint fence")
+	if _, err := uc.SetTaskEstimate(ctx, task.ID, "2h"); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	if _, err := uc.SetTaskDueDate(ctx, task.ID, &due, true); err != nil {
+		t.Fatal(err)
+	}
+	task, err := uc.TransitionTask(ctx, task.ID, string(domain.InProgressTask), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.TransitionTask(ctx, task.ID, string(domain.InReviewTask), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.AddDependency(ctx, task.ID, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+	before, err := uc.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A client that knows none of our properties renames the task and
+	// clears its due date.
+	w := serve(h, http.MethodPut, taskHref(task), vtodo(task.ID+"@todo-list", "SUMMARY:Paint the fence", "STATUS:IN-PROCESS"), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("update status %d: %s", w.Code, w.Body)
+	}
+
+	after, err := uc.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Title != "Paint the fence" {
+		t.Errorf("title %q, want %q", after.Title, "Paint the fence")
+	}
+	if after.DueDate != nil {
+		t.Errorf("due date %v, want it cleared", after.DueDate)
+	}
+	if after.Status != domain.InReviewTask {
+		t.Errorf("status %s, want %s kept", after.Status, domain.InReviewTask)
+	}
+	if after.Rank != before.Rank {
+		t.Errorf("rank %q, want %q kept", after.Rank, before.Rank)
+	}
+	if after.Estimate == nil || after.Estimate.String() != before.Estimate.String() {
+		t.Errorf("estimate %v, want %v kept", after.Estimate, before.Estimate)
+	}
+	if !after.DependsOn(blocker.ID) {
+		t.Errorf("dependencies %v, want %s kept", after.BlockedBy, blocker.ID)
+	}
+
+	// Once the client sends a different STATUS, it wins over the stored
+	// state.
+	w = serve(h, http.MethodPut, taskHref(task), vtodo(task.ID+"@todo-list", "SUMMARY:Paint the fence", "STATUS:COMPLETED"), nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("update status %d: %s", w.Code, w.Body)
+	}
+	after, err = uc.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Status != domain.DoneTask || after.CompletedAt == nil {
+		t.Errorf("status %s, completed at %v; want done with a completion time", after.Status, after.CompletedAt)
+	}
+}
+
+func TestCalDAVGetServesTask(t *testing.T) {
+	h, uc := newTestCalDAV(t)
+	task := createTask(t, uc, "Read book")
+
+	w := serve(h, http.MethodGet, taskHref(task), "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("ETag"); got != ETag(task) {
+		t.Errorf("ETag %s, want %s", got, ETag(task))
+	}
+	data, _ := io.ReadAll(w.Body)
+	if !strings.Contains(string(data), "SUMMARY:Read book") {
+		t.Errorf("body lacks the task:\n%s", data)
+	}
+
+	if w := serve(h, http.MethodGet, CalendarPath+"missing.ics", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("missing task got status %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,158 @@
+// Package calendar exposes tasks to calendar clients over HTTP.
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"todo-list/internal/usecase"
+)
+
+const DefaultFeedAddr = "127.0.0.1:8780"
+
+// FeedPath is where the read-only subscription is served, below the
+// install's token.
+const FeedPath = "/tasks.ics"
+
+// LoadFeedToken returns the random token of this install, creating it in
+// dataDir on first use. Only a URL carrying it gets the feed, so other
+// users of the machine cannot guess their way in.
+func LoadFeedToken(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, "feed_token")
+
+	data, err := os.ReadFile(path)
+	if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(bytes)
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func feedPath(token string) string {
+	return "/" + token + FeedPath
+}
+
+// NewFeedHandler serves every task as a VCALENDAR at the token's feed path.
+// Query parameters status, priority, project and tag narrow the feed the
+// same way the task list does.
+func NewFeedHandler(uc *usecase.TaskUseCase, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(feedPath(token), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		filter := usecase.TaskFilter{
+			Status:   query.Get("status"),
+			Priority: query.Get("priority"),
+			Project:  query.Get("project"),
+			Tag:      query.Get("tag"),
+		}
+
+		// Render into a buffer so a failure still produces a clean 500.
+		var body bytes.Buffer
+		if err := uc.ExportTasksICal(r.Context(), &body, filter); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Method == http.MethodGet {
+			w.Write(body.Bytes())
+		}
+	})
+	return localOnly(mux)
+}
+
+// localOnly refuses requests addressed to any host but this machine, so a
+// web page cannot read the feed through a name that resolves to 127.0.0.1.
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		host = strings.Trim(host, "[]")
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FeedServer serves the feed until its context is cancelled.
+type FeedServer struct {
+	listener net.Listener
+	server   *http.Server
+	token    string
+}
+
+// NewFeedServer listens on addr for handler, which serves the feed of
+// token.
+func NewFeedServer(addr, token string, handler http.Handler) (*FeedServer, error) {
+	if addr == "" {
+		addr = DefaultFeedAddr
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeedServer{
+		listener: listener,
+		server:   &http.Server{Handler: handler},
+		token:    token,
+	}, nil
+}
+
+// URL is the subscription address, using webcal:// so calendar apps offer to
+// subscribe instead of downloading a one-off file.
+func (s *FeedServer) URL() string {
+	return "webcal://" + s.listener.Addr().String() + feedPath(s.token)
+}
+
+func (s *FeedServer) HTTPURL() string {
+	return "http://" + strings.TrimPrefix(s.URL(), "webcal://")
+}
+
+func (s *FeedServer) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.server.Close()
+	}()
+
+	if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+// Package config holds the user settings: a TOML file in the user config
+// directory, overridden by environment variables and command-line flags.
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	BackendFile     = "file"
+	BackendTodoTxt  = "todotxt"
+	BackendMarkdown = "markdown"
+	BackendPostgres = "postgres"
+)
+
+type Config struct {
+	Storage   StorageConfig  `json:"storage"`
+	Window    WindowConfig   `json:"window"`
+	Tasks     TaskConfig     `json:"tasks"`
+	Calendar  CalendarConfig `json:"calendar"`
+	Reminders ReminderConfig `json:"reminders"`
+	Board     BoardConfig    `json:"board"`
+	Focus     FocusConfig    `json:"focus"`
+	Sync      SyncConfig     `json:"sync"`
+	Feed      FeedConfig     `json:"feed"`
+	CalDAV    CalDAVConfig   `json:"caldav"`
+	UI        UIConfig       `json:"ui"`
+}
+
+type StorageConfig struct {
+	Backend string `json:"backend"`
+	// DSN is the PostgreSQL connection string.
+	DSN     string `json:"dsn"`
+	DataDir string `json:"data_dir"`
+	// TodoTxtDir and MarkdownFile default to the data directory.
+	TodoTxtDir   string `json:"todotxt_dir"`
+	MarkdownFile string `json:"markdown_file"`
+}
+
+type WindowConfig struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// TaskConfig holds the defaults for new tasks and the task list.
+type TaskConfig struct {
+	Priority string `json:"priority"`
+	// SortField is one field sorted in SortOrder, or a list of keys such as
+	// "priority desc,due_date,title".
+	SortField string `json:"sort_field"`
+	SortOrder string `json:"sort_order"`
+	// SortLocale is the BCP 47 language titles are sorted for; empty sorts
+	// them for no language in particular.
+	SortLocale string `json:"sort_locale"`
+}
+
+type CalendarConfig struct {
+	WeekStart string `json:"week_start"`
+	// TimeZone is an IANA name; empty means the system time zone.
+	TimeZone string `json:"time_zone"`
+}
+
+type ReminderConfig struct {
+	Enabled bool `json:"enabled"`
+	// LeadTime is how long before the due time a reminder fires, as a Go
+	// duration like "15m".
+	LeadTime string `json:"lead_time"`
+	// DefaultTime is the HH:MM used for tasks due on a date without a time.
+	DefaultTime string `json:"default_time"`
+}
+
+type BoardConfig struct {
+	// Columns is the task field the board has a column per value of:
+	// status, priority or project.
+	Columns string `json:"columns"`
+	// WIPLimits caps the cards of columns, e.g. "in_progress=3,in_review=2".
+	WIPLimits string `json:"wip_limits"`
+}
+
+// FocusConfig sets up Pomodoro focus sessions. The lengths are Go durations
+// like "25m"; Cycles is the number of pomodoros before a long break.
+type FocusConfig struct {
+	Work       string `json:"work"`
+	ShortBreak string `json:"short_break"`
+	LongBreak  string `json:"long_break"`
+	Cycles     int    `json:"cycles"`
+}
+
+// SyncConfig sets up multi-device sync through a shared folder or between
+// peers; it is off while all of them are empty.
+type SyncConfig struct {
+	Folder string `json:"folder"`
+	// Listen is the address this device accepts peers on, Peer the URL of
+	// the device it connects to.
+	Listen string `json:"listen"`
+	Peer   string `json:"peer"`
+	// Secret is shared by all devices; without it no peer is answered.
+	Secret string `json:"secret"`
+}
+
+type FeedConfig struct {
+	// Addr is where the calendar feed listens, empty for the loopback
+	// default and "off" to disable it.
+	Addr string `json:"addr"`
+}
+
+// CalDAVConfig sets up the CalDAV server, which only starts with a
+// password. An empty Addr listens on the default port.
+type CalDAVConfig struct {
+	Addr     string `json:"addr"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type UIConfig struct {
+	Theme string `json:"theme"`
+}
+
+// Default returns the settings used when nothing is configured.
+func Default() Config {
+	dataDir := ".todolist"
+	if home, err := os.UserHomeDir(); err == nil {
+		dataDir = filepath.Join(home, ".todolist")
+	}
+
+	return Config{
+		Storage:   StorageConfig{Backend: BackendFile, DataDir: dataDir},
+		Window:    WindowConfig{Width: 1024, Height: 768},
+		Tasks:     TaskConfig{Priority: string(domain.MediumPriority), SortField: "created", SortOrder: "desc"},
+		Calendar:  CalendarConfig{WeekStart: "monday"},
+		Reminders: ReminderConfig{Enabled: true, LeadTime: "15m", DefaultTime: "09:00"},
+		Board:     BoardConfig{Columns: "status"},
+		Focus:     FocusConfig{Work: "25m", ShortBreak: "5m", LongBreak: "15m", Cycles: 4},
+		CalDAV:    CalDAVConfig{User: "todo"},
+		UI:        UIConfig{Theme: "light"},
+	}
+}
+
+// DefaultPath is config.toml in the user config directory, e.g.
+// ~/.config/todo-list on Linux.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "todo-list", "config.toml")
+}
+
+// TodoTxtPath and MarkdownPath resolve the defaults of the file backends.
+func (c Config) TodoTxtPath() string {
+	if c.Storage.TodoTxtDir != "" {
+		return c.Storage.TodoTxtDir
+	}
+	return c.Storage.DataDir
+}
+
+func (c Config) MarkdownPath() string {
+	if c.Storage.MarkdownFile != "" {
+		return c.Storage.MarkdownFile
+	}
+	return filepath.Join(c.Storage.DataDir, "tasks.md")
+}
+
+// TimeLogPath is the JSON file time spent on tasks is logged in, whatever
+// the backend.
+func (c Config) TimeLogPath() string {
+	return filepath.Join(c.Storage.DataDir, "time_entries.json")
+}
+
+// WIPLimits returns the limit of every column board.wip_limits caps.
+func (c Config) WIPLimits() map[string]int {
+	limits, _ := parseWIPLimits(c.Board.WIPLimits)
+	return limits
+}
+
+func parseWIPLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		column, raw, ok := strings.Cut(item, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || strings.TrimSpace(column) == "" || err != nil || limit < 1 {
+			return nil, fmt.Errorf("board.wip_limits must look like in_progress=3,in_review=2, not %q", value)
+		}
+		limits[strings.TrimSpace(column)] = limit
+	}
+	return limits, nil
+}
+
+// FocusDurations returns the lengths of the focus session phases, or an
+// error naming the setting that is out of range.
+func (c Config) FocusDurations() (domain.FocusDurations, error) {
+	durations := domain.FocusDurations{Cycles: c.Focus.Cycles}
+	for _, phase := range []struct {
+		key   string
+		value string
+		ptr   *time.Duration
+	}{
+		{"focus.work", c.Focus.Work, &durations.Work},
+		{"focus.short_break", c.Focus.ShortBreak, &durations.ShortBreak},
+		{"focus.long_break", c.Focus.LongBreak, &durations.LongBreak},
+	} {
+		d, err := time.ParseDuration(phase.value)
+		if err != nil || d < time.Minute {
+			return durations, fmt.Errorf("%s must be a duration of at least 1m, not %q", phase.key, phase.value)
+		}
+		*phase.ptr = d
+	}
+	if durations.Cycles < 1 {
+		return durations, fmt.Errorf("focus.cycles must be at least 1, not %d", durations.Cycles)
+	}
+	return durations, nil
+}
+
+// Location returns the configured time zone.
+func (c Config) Location() *time.Location {
+	if c.Calendar.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Calendar.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// SortLanguage returns the language task titles are sorted for.
+func (c Config) SortLanguage() language.Tag {
+	tag, _ := language.Parse(c.Tasks.SortLocale)
+	return tag
+}
+
+// WeekStart returns the configured first day of the week.
+func (c Config) WeekStart() time.Weekday {
+	day, _ := parseWeekday(c.Calendar.WeekStart)
+	return day
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, true
+		}
+	}
+	return time.Monday, false
+}
+
+func (c Config) Validate() error {
+	switch c.Storage.Backend {
+	case BackendFile, BackendTodoTxt, BackendMarkdown:
+	case BackendPostgres:
+		if c.Storage.DSN == "" {
+			return fmt.Errorf("storage.dsn is required for the postgres backend")
+		}
+	default:
+		return fmt.Errorf("storage.backend must be file, todotxt, markdown or postgres, not %q", c.Storage.Backend)
+	}
+	if c.Storage.DataDir == "" {
+		return fmt.Errorf("storage.data_dir cannot be empty")
+	}
+
+	if c.Window.Width < 400 || c.Window.Height < 300 {
+		return fmt.Errorf("window must be at least 400x300, not %dx%d", c.Window.Width, c.Window.Height)
+	}
+
+	if !domain.Priority(c.Tasks.Priority).IsValid() {
+		return fmt.Errorf("tasks.priority must be low, medium or high, not %q", c.Tasks.Priority)
+	}
+	if _, err := domain.ParseSortSpec(c.Tasks.SortField); err != nil {
+		return fmt.Errorf("tasks.sort_field: %w", err)
+	}
+	if c.Tasks.SortOrder != "asc" && c.Tasks.SortOrder != "desc" {
+		return fmt.Errorf("tasks.sort_order must be asc or desc, not %q", c.Tasks.SortOrder)
+	}
+
+	if c.Tasks.SortLocale != "" {
+		if _, err := language.Parse(c.Tasks.SortLocale); err != nil {
+			return fmt.Errorf("tasks.sort_locale: unknown language %q", c.Tasks.SortLocale)
+		}
+	}
+
+	if _, ok := parseWeekday(c.Calendar.WeekStart); !ok {
+		return fmt.Errorf("calendar.week_start must be a weekday, not %q", c.Calendar.WeekStart)
+	}
+	if c.Calendar.TimeZone != "" {
+		if _, err := time.LoadLocation(c.Calendar.TimeZone); err != nil {
+			return fmt.Errorf("calendar.time_zone: unknown time zone %q", c.Calendar.TimeZone)
+		}
+	}
+
+	if lead, err := time.ParseDuration(c.Reminders.LeadTime); err != nil || lead < 0 {
+		return fmt.Errorf("reminders.lead_time must be a duration like 15m, not %q", c.Reminders.LeadTime)
+	}
+	if _, err := time.Parse("15:04", c.Reminders.DefaultTime); err != nil {
+		return fmt.Errorf("reminders.default_time must be HH:MM, not %q", c.Reminders.DefaultTime)
+	}
+
+	switch c.Board.Columns {
+	case "status", "priority", "project":
+	default:
+		return fmt.Errorf("board.columns must be status, priority or project, not %q", c.Board.Columns)
+	}
+	if _, err := parseWIPLimits(c.Board.WIPLimits); err != nil {
+		return err
+	}
+
+	if _, err := c.FocusDurations(); err != nil {
+		return err
+	}
+
+	if c.Sync.Peer != "" {
+		peer, err := url.Parse(c.Sync.Peer)
+		if err != nil || (peer.Scheme != "http" && peer.Scheme != "https") || peer.Host == "" {
+			return fmt.Errorf("sync.peer must be an http:// or https:// URL, not %q", c.Sync.Peer)
+		}
+	}
+	for _, addr := range []struct{ key, value string }{
+		{"sync.listen", c.Sync.Listen},
+		{"feed.addr", c.Feed.Addr},
+		{"caldav.addr", c.CalDAV.Addr},
+	} {
+		if addr.value == "" || addr.key == "feed.addr" && addr.value == "off" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr.value); err != nil {
+			return fmt.Errorf("%s must be an address like :8765, not %q", addr.key, addr.value)
+		}
+	}
+
+	switch c.UI.Theme {
+	case "light", "dark", "system":
+	default:
+		return fmt.Errorf("ui.theme must be light, dark or system, not %q", c.UI.Theme)
+	}
+
+	return nil
+}
+
+// RestartRequired reports whether going from c to next changes settings
+// that are only read at startup.
+func (c Config) RestartRequired(next Config) bool {
+	return c.Storage != next.Storage || c.Window != next.Window ||
+		c.Sync != next.Sync || c.Feed != next.Feed || c.CalDAV != next.CalDAV
+}
+
+// field is one setting, addressed as "section.key" in the file, in -set
+// flags and, upper-cased with underscores and a TODOLIST_ prefix, in the
+// environment.
+type field struct {
+	key    string
+	quoted bool
+	get    func(c *Config) string
+	set    func(c *Config, value string) error
+}
+
+func stringField(key string, ptr func(c *Config) *string) field {
+	return field{
+		key:    key,
+		quoted: true,
+		get:    func(c *Config) string { return *ptr(c) },
+		set: func(c *Config, value string) error {
+			*ptr(c) = value
+			return nil
+		},
+	}
+}
+
+func intField(key string, ptr func(c *Config) *int) field {
+	return field{
+		key: key,
+		get: func(c *Config) string { return strconv.Itoa(*ptr(c)) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a number, not %q", key, value)
+			}
+			*ptr(c) = n
+			return nil
+		},
+	}
+}
+
+func boolField(key string, ptr func(c *Config) *bool) field {
+	return field{
+		key: key,
+		get: func(c *Config) string { return strconv.FormatBool(*ptr(c)) },
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s must be true or false, not %q", key, value)
+			}
+			*ptr(c) = b
+			return nil
+		},
+	}
+}
+
+var fields = []field{
+	stringField("storage.backend", func(c *Config) *string { return &c.Storage.Backend }),
+	stringField("storage.dsn", func(c *Config) *string { return &c.Storage.DSN }),
+	stringField("storage.data_dir", func(c *Config) *string { return &c.Storage.DataDir }),
+	stringField("storage.todotxt_dir", func(c *Config) *string { return &c.Storage.TodoTxtDir }),
+	stringField("storage.markdown_file", func(c *Config) *string { return &c.Storage.MarkdownFile }),
+	intField("window.width", func(c *Config) *int { return &c.Window.Width }),
+	intField("window.height", func(c *Config) *int { return &c.Window.Height }),
+	stringField("tasks.priority", func(c *Config) *string { return &c.Tasks.Priority }),
+	stringField("tasks.sort_field", func(c *Config) *string { return &c.Tasks.SortField }),
+	stringField("tasks.sort_order", func(c *Config) *string { return &c.Tasks.SortOrder }),
+	stringField("tasks.sort_locale", func(c *Config) *string { return &c.Tasks.SortLocale }),
+	stringField("calendar.week_start", func(c *Config) *string { return &c.Calendar.WeekStart }),
+	stringField("calendar.time_zone", func(c *Config) *string { return &c.Calendar.TimeZone }),
+	boolField("reminders.enabled", func(c *Config) *bool { return &c.Reminders.Enabled }),
+	stringField("reminders.lead_time", func(c *Config) *string { return &c.Reminders.LeadTime }),
+	stringField("reminders.default_time", func(c *Config) *string { return &c.Reminders.DefaultTime }),
+	stringField("board.columns", func(c *Config) *string { return &c.Board.Columns }),
+	stringField("board.wip_limits", func(c *Config) *string { return &c.Board.WIPLimits }),
+	stringField("focus.work", func(c *Config) *string { return &c.Focus.Work }),
+	stringField("focus.short_break", func(c *Config) *string { return &c.Focus.ShortBreak }),
+	stringField("focus.long_break", func(c *Config) *string { return &c.Focus.LongBreak }),
+	intField("focus.cycles", func(c *Config) *int { return &c.Focus.Cycles }),
+	stringField("sync.folder", func(c *Config) *string { return &c.Sync.Folder }),
+	stringField("sync.listen", func(c *Config) *string { return &c.Sync.Listen }),
+	stringField("sync.peer", func(c *Config) *string { return &c.Sync.Peer }),
+	stringField("sync.secret", func(c *Config) *string { return &c.Sync.Secret }),
+	stringField("feed.addr", func(c *Config) *string { return &c.Feed.Addr }),
+	stringField("caldav.addr", func(c *Config) *string { return &c.CalDAV.Addr }),
+	stringField("caldav.user", func(c *Config) *string { return &c.CalDAV.User }),
+	stringField("caldav.password", func(c *Config) *string { return &c.CalDAV.Password }),
+	stringField("ui.theme", func(c *Config) *string { return &c.UI.Theme }),
+}
+
+// envAliases are the variables the app read before it had a config file.
+var envAliases = map[string]string{
+	"TODOLIST_BACKEND":           "storage.backend",
+	"POSTGRES_CONNECTION_STRING": "storage.dsn",
+	"TODOLIST_TODOTXT_DIR":       "storage.todotxt_dir",
+	"TODOLIST_MARKDOWN_FILE":     "storage.markdown_file",
+}
+
+func lookupField(key string) (field, bool) {
+	for _, f := range fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// Set changes one setting by key, e.g. Set("tasks.priority", "high").
+func (c *Config) Set(key, value string) error {
+	f, ok := lookupField(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return f.set(c, value)
+}
+
+// Keys lists every setting key.
+func Keys() []string {
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		keys = append(keys, f.key)
+	}
+	return keys
+}
+
+func envName(key string) string {
+	return "TODOLIST_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// EnvOverrides collects settings from the environment. A connection string
+// in POSTGRES_CONNECTION_STRING selects the postgres backend unless a
+// backend is set explicitly, as it always did.
+func EnvOverrides() map[string]string {
+	overrides := make(map[string]string)
+
+	aliases := make([]string, 0, len(envAliases))
+	for name := range envAliases {
+		aliases = append(aliases, name)
+	}
+	sort.Strings(aliases)
+	for _, name := range aliases {
+		if value := os.Getenv(name); value != "" {
+			overrides[envAliases[name]] = value
+		}
+	}
+
+	for _, f := range fields {
+		if value := os.Getenv(envName(f.key)); value != "" {
+			overrides[f.key] = value
+		}
+	}
+
+	if _, ok := overrides["storage.backend"]; !ok && overrides["storage.dsn"] != "" {
+		overrides["storage.backend"] = BackendPostgres
+	}
+
+	return overrides
+}
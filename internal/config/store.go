@@ -0,0 +1,232 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store holds the current settings. They are the defaults, then the file,
+// then the overrides from the environment and flags, which stay in effect
+// across reloads and are never written back to the file.
+type Store struct {
+	path      string
+	overrides map[string]string
+
+	mutex     sync.RWMutex
+	file      Config
+	current   Config
+	modTime   time.Time
+	listeners []func(old, next Config)
+}
+
+// Open loads the settings from path, which need not exist.
+func Open(path string, overrides map[string]string) (*Store, error) {
+	s := &Store{path: path, overrides: overrides}
+
+	for key := range overrides {
+		if _, ok := lookupField(key); !ok {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+
+	file, modTime, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.apply(file)
+	if err != nil {
+		return nil, err
+	}
+
+	s.file, s.current, s.modTime = file, current, modTime
+	return s, nil
+}
+
+func (s *Store) read() (Config, time.Time, error) {
+	c := Default()
+
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return c, time.Time{}, nil
+	}
+	if err != nil {
+		return c, time.Time{}, err
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return c, time.Time{}, err
+	}
+	if err := decode(data, &c); err != nil {
+		return c, time.Time{}, fmt.Errorf("%s: %w", s.path, err)
+	}
+
+	return c, info.ModTime(), nil
+}
+
+// apply layers the overrides on top of file and validates the result.
+func (s *Store) apply(file Config) (Config, error) {
+	c := file
+
+	keys := make([]string, 0, len(s.overrides))
+	for key := range s.overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := c.Set(key, s.overrides[key]); err != nil {
+			return c, err
+		}
+	}
+
+	return c, c.Validate()
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Get() Config {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.current
+}
+
+// Overridden lists the keys set by the environment or flags; changing them
+// in the file has no effect.
+func (s *Store) Overridden() []string {
+	keys := make([]string, 0, len(s.overrides))
+	for key := range s.overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// OnChange registers fn to be called after every change, whether made by
+// Update or by editing the file.
+func (s *Store) OnChange(fn func(old, next Config)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Update validates c and saves it. Overridden settings keep their value
+// from the file.
+func (s *Store) Update(c Config) (Config, error) {
+	s.mutex.Lock()
+	old := s.current
+	err := s.write(c)
+	current, listeners := s.current, s.listeners
+	s.mutex.Unlock()
+
+	if err != nil {
+		return current, err
+	}
+	notify(listeners, old, current)
+	return current, nil
+}
+
+func (s *Store) write(c Config) error {
+	file := c
+	for key := range s.overrides {
+		f, _ := lookupField(key)
+		if err := f.set(&file, f.get(&s.file)); err != nil {
+			return err
+		}
+	}
+
+	current, err := s.apply(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	// Only the user may read it: it can hold the sync secret and the
+	// CalDAV password.
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, encode(&file), 0600); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	if err := os.Rename(tempFile, s.path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.file, s.current, s.modTime = file, current, info.ModTime()
+	return nil
+}
+
+// Reload rereads the file if it changed since it was last read. A file that
+// does not parse or validate is reported once and the current settings
+// stay.
+func (s *Store) Reload() error {
+	var modTime time.Time
+	if info, err := os.Stat(s.path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	s.mutex.Lock()
+	if modTime.Equal(s.modTime) {
+		s.mutex.Unlock()
+		return nil
+	}
+
+	old := s.current
+	s.modTime = modTime
+	file, _, err := s.read()
+	if err == nil {
+		var current Config
+		if current, err = s.apply(file); err == nil {
+			s.file, s.current = file, current
+		}
+	}
+	current, listeners := s.current, s.listeners
+	s.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+	notify(listeners, old, current)
+	return nil
+}
+
+func notify(listeners []func(old, next Config), old, next Config) {
+	if old == next {
+		return
+	}
+	for _, fn := range listeners {
+		fn(old, next)
+	}
+}
+
+// Watch polls the file every interval until ctx is done, so edits made
+// while the app runs take effect without a restart.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				println("Failed to reload settings:", err.Error())
+			}
+		}
+	}
+}
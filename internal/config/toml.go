@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The config file is the subset of TOML the settings need: [section]
+// headers, key = value pairs with basic or literal strings, integers and
+// booleans, and # comments. No setting is an array or a table, so those are
+// rejected like any other value of the wrong type.
+
+// decode applies the settings in data on top of c.
+func decode(data []byte, c *Config) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	section := ""
+	line := 0
+	seen := make(map[string]bool)
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return fmt.Errorf("line %d: invalid section header", line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+
+		name, raw, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", line)
+		}
+		key := strings.TrimSpace(name)
+		if section != "" {
+			key = section + "." + key
+		}
+
+		f, ok := lookupField(key)
+		if !ok {
+			return fmt.Errorf("line %d: unknown setting %q", line, key)
+		}
+		if seen[key] {
+			return fmt.Errorf("line %d: %s is set twice", line, key)
+		}
+		seen[key] = true
+
+		value, quoted, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+		if quoted != f.quoted {
+			if f.quoted {
+				return fmt.Errorf("line %d: %s must be a quoted string", line, key)
+			}
+			return fmt.Errorf("line %d: %s must not be quoted", line, key)
+		}
+		if err := f.set(c, value); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// stripComment drops a # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseValue(raw string) (string, bool, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		if len(raw) < 2 || !strings.HasSuffix(raw, `"`) {
+			return "", false, fmt.Errorf("unterminated string")
+		}
+		value, err := unquote(raw[1 : len(raw)-1])
+		if err != nil {
+			return "", false, fmt.Errorf("invalid string %s: %w", raw, err)
+		}
+		return value, true, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", false, fmt.Errorf("unterminated string")
+		}
+		value := raw[1 : len(raw)-1]
+		if strings.Contains(value, "'") {
+			return "", false, fmt.Errorf("invalid string %s", raw)
+		}
+		return value, true, nil
+	case strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{"):
+		return "", false, fmt.Errorf("arrays and tables are not supported")
+	case raw == "":
+		return "", false, fmt.Errorf("missing value")
+	}
+	return raw, false, nil
+}
+
+// unquote resolves the escapes of a TOML basic string, which are fewer than
+// Go's.
+func unquote(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return "", fmt.Errorf("unescaped quote")
+		case c < 0x20 && c != '\t' || c == 0x7f:
+			return "", fmt.Errorf("control character %U must be escaped", rune(c))
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"':
+			b.WriteByte('"')
+		case '\\':
+			b.WriteByte('\\')
+		case 'u', 'U':
+			digits := 4
+			if s[i] == 'U' {
+				digits = 8
+			}
+			if i+digits >= len(s) {
+				return "", fmt.Errorf("short escape \\%c", s[i])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid escape \\%s", s[i:i+1+digits])
+			}
+			b.WriteRune(rune(code))
+			i += digits
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// quote writes s as a TOML basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// encode writes every setting, grouped by section in the order of fields.
+func encode(c *Config) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# todo-list settings. Environment variables (TODOLIST_SECTION_KEY) and\n")
+	buf.WriteString("# command-line flags take precedence over this file.\n")
+
+	section := ""
+	for _, f := range fields {
+		prefix, key, _ := strings.Cut(f.key, ".")
+		if prefix != section {
+			section = prefix
+			fmt.Fprintf(&buf, "\n[%s]\n", section)
+		}
+
+		value := f.get(c)
+		if f.quoted {
+			value = quote(value)
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", key, value)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		want  string
+	}{
+		{"basic string", "[tasks]\npriority = \"high\"\n", "tasks.priority", "high"},
+		{"trailing comment", "[tasks]\npriority = \"high\" # the default\n", "tasks.priority", "high"},
+		{"hash inside string", "[sync]\nsecret = \"a#b\" # comment\n", "sync.secret", "a#b"},
+		{"literal string keeps backslashes", "[storage]\ndata_dir = 'C:\\todo #1'\n", "storage.data_dir", `C:\todo #1`},
+		{"escapes", "[sync]\nsecret = \"q\\\"b\\\\s\\tt\\nn\\u00e9\\U0001F600\"\n", "sync.secret", "q\"b\\s\tt\nn\u00e9\U0001F600"},
+		{"escaped quote before comment", "[sync]\nsecret = \"a\\\"#b\" # c\n", "sync.secret", `a"#b`},
+		{"dotted key", "tasks.priority = \"low\"\n", "tasks.priority", "low"},
+		{"comments and blank lines", "# settings\n\n[ui] # look\n  # theme below\ntheme = \"dark\"\n", "ui.theme", "dark"},
+		{"CRLF line endings", "[ui]\r\ntheme = \"light\"\r\n", "ui.theme", "light"},
+		{"integer", "[window]\nwidth = 800\n", "window.width", "800"},
+		{"boolean", "[reminders]\nenabled = false\n", "reminders.enabled", "false"},
+		{"empty string", "[ui]\ntheme = \"\"\n", "ui.theme", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			if err := decode([]byte(tt.input), &c); err != nil {
+				t.Fatal(err)
+			}
+			f, _ := lookupField(tt.key)
+			if got := f.get(&c); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"array", "[board]\ncolumns = [\"status\", \"priority\"]\n", "line 2: board.columns: arrays and tables are not supported"},
+		{"inline table", "[board]\ncolumns = { a = 1 }\n", "arrays and tables are not supported"},
+		{"array of tables", "[[board]]\ncolumns = \"status\"\n", "unknown setting"},
+		{"unknown setting", "[tasks]\ncolour = \"red\"\n", `line 2: unknown setting "tasks.colour"`},
+		{"unquoted string", "[tasks]\npriority = high\n", "tasks.priority must be a quoted string"},
+		{"quoted integer", "[window]\nwidth = \"800\"\n", "window.width must not be quoted"},
+		{"not a number", "[window]\nwidth = wide\n", "window.width must be a number"},
+		{"Go escape", "[sync]\nsecret = \"\\x41\"\n", `invalid escape \x`},
+		{"short unicode escape", "[sync]\nsecret = \"\\u00\"\n", `short escape \u`},
+		{"surrogate", "[sync]\nsecret = \"\\uD800\"\n", `invalid escape \uD800`},
+		{"unterminated string", "[sync]\nsecret = \"abc\n", "unterminated string"},
+		{"unescaped quote", "[sync]\nsecret = \"a\"b\"\n", "unescaped quote"},
+		{"quote in literal string", "[sync]\nsecret = 'it's'\n", "invalid string"},
+		{"missing value", "[sync]\nsecret =\n", "missing value"},
+		{"missing equals", "[sync]\nsecret\n", "line 2: expected key = value"},
+		{"invalid header", "[sync\nsecret = \"x\"\n", "line 1: invalid section header"},
+		{"key set twice", "[ui]\ntheme = \"dark\"\ntheme = \"light\"\n", "line 3: ui.theme is set twice"},
+		{"dotted key repeats section key", "ui.theme = \"dark\"\n[ui]\ntheme = \"light\"\n", "ui.theme is set twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			err := decode([]byte(tt.input), &c)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decode returned %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	values := []string{
+		"",
+		"plain",
+		`say "hi"`,
+		`C:\Users\me\todo`,
+		"tab\there",
+		"two\nlines\r\n",
+		"bell\a escape\x1b delete\x7f",
+		"# not a comment",
+		"it's",
+		"ünïcödé ✓ 😀",
+	}
+
+	for _, value := range values {
+		c := Default()
+		c.Sync.Secret = value
+		c.Storage.DataDir = value
+		c.Window.Width = 1234
+		c.Reminders.Enabled = !c.Reminders.Enabled
+
+		data := encode(&c)
+		for _, escape := range []string{`\a`, `\x`, `\v`, `\'`} {
+			if strings.Contains(string(data), escape) {
+				t.Errorf("%q: encoded with %s, which TOML does not have:\n%s", value, escape, data)
+			}
+		}
+		var got Config
+		if err := decode(data, &got); err != nil {
+			t.Fatalf("%q: decode: %v\n%s", value, err, data)
+		}
+		if got != c {
+			t.Errorf("%q: round trip changed the settings:\ngot  %+v\nwant %+v\n%s", value, got, c, data)
+		}
+	}
+}
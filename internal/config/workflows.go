@@ -0,0 +1,41 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"todo-list/internal/domain"
+)
+
+// WorkflowsPath is the JSON file holding per-project workflows, e.g.
+//
+//	{"": {"todo": ["in_progress"], "in_progress": ["done"], "done": ["todo"]}}
+//
+// where "" is the workflow of projects not listed.
+func (c Config) WorkflowsPath() string {
+	return filepath.Join(c.Storage.DataDir, "workflows.json")
+}
+
+// LoadWorkflows reads the workflows file at path. A missing file means every
+// project uses domain.DefaultWorkflow.
+func LoadWorkflows(path string) (domain.Workflows, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows domain.Workflows
+	if err := json.Unmarshal(data, &workflows); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := workflows.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return workflows, nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Clock is the source of "now" for everything that depends on the date.
+// Now returns the time in the user's time zone and WeekStart the first day
+// of their week.
+type Clock interface {
+	Now() time.Time
+	WeekStart() time.Weekday
+}
+
+type systemClock struct {
+	location  *time.Location
+	weekStart time.Weekday
+}
+
+// NewSystemClock returns a clock reading the system time in location.
+func NewSystemClock(location *time.Location, weekStart time.Weekday) Clock {
+	if location == nil {
+		location = time.Local
+	}
+	return systemClock{location: location, weekStart: weekStart}
+}
+
+func (c systemClock) Now() time.Time          { return time.Now().In(c.location) }
+func (c systemClock) WeekStart() time.Weekday { return c.weekStart }
+
+// SystemClock uses the process's local time zone and weeks starting on
+// Monday.
+var SystemClock = NewSystemClock(time.Local, time.Monday)
+
+// FixedClock always returns the same time, for tests and previews.
+type FixedClock struct {
+	Time     time.Time
+	FirstDay time.Weekday
+}
+
+func (c FixedClock) Now() time.Time          { return c.Time }
+func (c FixedClock) WeekStart() time.Weekday { return c.FirstDay }
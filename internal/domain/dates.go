@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Day arithmetic goes through time.Date so that a day is a calendar day:
+// adding 24h across a DST change lands an hour off midnight, AddDays does
+// not.
+
+// StartOfDay returns midnight at the start of t's day in t's location.
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// AddDays moves t by n calendar days, keeping the wall clock time.
+func AddDays(t time.Time, n int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+n, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// StartOfWeek returns midnight at the start of the week containing t.
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return AddDays(StartOfDay(t), -offset)
+}
+
+// StartOfMonth returns midnight on the first of t's month.
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// DateOnly keeps t's calendar date, in t's own location, as midnight UTC.
+// All-day due dates and start dates are stored this way so the day does not
+// shift when the time zone changes.
+func DateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsDateOnly reports whether t is a date as stored by DateOnly.
+func IsDateOnly(t time.Time) bool {
+	return t.Equal(DateOnly(t.UTC()))
+}
+
+// DateIn returns midnight in loc on the date stored by DateOnly.
+func DateIn(date time.Time, loc *time.Location) time.Time {
+	date = date.UTC()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// DateRange is the half-open interval [From, To).
+type DateRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (r DateRange) Contains(t time.Time) bool {
+	return !t.Before(r.From) && t.Before(r.To)
+}
+
+// Overlaps reports whether [from, to) shares any time with r.
+func (r DateRange) Overlaps(from, to time.Time) bool {
+	return from.Before(r.To) && to.After(r.From)
+}
+
+// Date presets accepted by task filters. "week" is kept as the name the
+// app used for this week.
+const (
+	DateToday     = "today"
+	DateTomorrow  = "tomorrow"
+	DateThisWeek  = "this_week"
+	DateWeek      = "week"
+	DateNextWeek  = "next_week"
+	DateNext7Days = "next_7_days"
+	DateThisMonth = "this_month"
+	DateOverdue   = "overdue"
+	DateNoDate    = "no_date"
+)
+
+// DatePresetRange resolves a preset that is a plain range of due dates.
+// overdue and no_date are not ranges; they report false.
+func DatePresetRange(preset string, clock Clock) (DateRange, bool, error) {
+	now := clock.Now()
+	today := StartOfDay(now)
+
+	switch preset {
+	case DateToday:
+		return DateRange{From: today, To: AddDays(today, 1)}, true, nil
+	case DateTomorrow:
+		return DateRange{From: AddDays(today, 1), To: AddDays(today, 2)}, true, nil
+	case DateThisWeek, DateWeek:
+		start := StartOfWeek(now, clock.WeekStart())
+		return DateRange{From: start, To: AddDays(start, 7)}, true, nil
+	case DateNextWeek:
+		start := AddDays(StartOfWeek(now, clock.WeekStart()), 7)
+		return DateRange{From: start, To: AddDays(start, 7)}, true, nil
+	case DateNext7Days:
+		return DateRange{From: now, To: AddDays(now, 7)}, true, nil
+	case DateThisMonth:
+		start := StartOfMonth(now)
+		return DateRange{From: start, To: start.AddDate(0, 1, 0)}, true, nil
+	case DateOverdue, DateNoDate:
+		return DateRange{}, false, nil
+	}
+
+	return DateRange{}, false, fmt.Errorf("unknown date filter %q", preset)
+}
+
+// MatchesDatePreset reports whether task falls under preset at the
+// clock's current time.
+func (t *Task) MatchesDatePreset(preset string, clock Clock) (bool, error) {
+	dateRange, isRange, err := DatePresetRange(preset, clock)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case preset == DateNoDate:
+		return t.DueDate == nil, nil
+	case preset == DateOverdue:
+		return t.IsOverdue(clock), nil
+	case isRange && t.DueDate != nil:
+		from, to := t.DueSpan(clock.Now().Location())
+		if t.DueAllDay {
+			return dateRange.Overlaps(from, to), nil
+		}
+		return dateRange.Contains(from), nil
+	}
+	return false, nil
+}
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestAddDaysAcrossDST(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		days    int
+		want    time.Time
+		elapsed time.Duration
+	}{
+		{"spring forward", time.Date(2026, 3, 28, 9, 0, 0, 0, berlin), 1, time.Date(2026, 3, 29, 9, 0, 0, 0, berlin), 23 * time.Hour},
+		{"fall back", time.Date(2026, 10, 24, 9, 0, 0, 0, berlin), 1, time.Date(2026, 10, 25, 9, 0, 0, 0, berlin), 25 * time.Hour},
+		{"midnight backwards", time.Date(2026, 3, 30, 0, 0, 0, 0, berlin), -2, time.Date(2026, 3, 28, 0, 0, 0, 0, berlin), 47 * time.Hour},
+		{"over a month end", time.Date(2026, 1, 30, 18, 0, 0, 0, berlin), 3, time.Date(2026, 2, 2, 18, 0, 0, 0, berlin), 72 * time.Hour},
+		{"UTC has no DST", time.Date(2026, 3, 28, 9, 0, 0, 0, time.UTC), 1, time.Date(2026, 3, 29, 9, 0, 0, 0, time.UTC), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddDays(tt.from, tt.days)
+			if !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+				t.Errorf("AddDays(%v, %d) = %v, want %v", tt.from, tt.days, got, tt.want)
+			}
+			if elapsed := got.Sub(tt.from); elapsed != tt.elapsed && elapsed != -tt.elapsed {
+				t.Errorf("%v elapsed, want %v", elapsed, tt.elapsed)
+			}
+		})
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	// 2026-03-11 is a Wednesday.
+	wednesday := time.Date(2026, 3, 11, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		t         time.Time
+		weekStart time.Weekday
+		want      time.Time
+	}{
+		{wednesday, time.Monday, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{wednesday, time.Sunday, time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{wednesday, time.Saturday, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{wednesday, time.Wednesday, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{wednesday, time.Thursday, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		// Across a month and a year end.
+		{time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC), time.Monday, time.Date(2026, 12, 28, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := StartOfWeek(tt.t, tt.weekStart); !got.Equal(tt.want) {
+			t.Errorf("StartOfWeek(%v, %v) = %v, want %v", tt.t, tt.weekStart, got, tt.want)
+		}
+	}
+}
+
+func TestDatePresetRange(t *testing.T) {
+	berlin := loadLocation(t, "Europe/Berlin")
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		weekDay  time.Weekday
+		preset   string
+		from, to time.Time
+	}{
+		{"today", at(2026, 3, 11, 15), time.Monday, DateToday, at(2026, 3, 11, 0), at(2026, 3, 12, 0)},
+		{"today is 23 hours on spring forward", at(2026, 3, 29, 12), time.Monday, DateToday, at(2026, 3, 29, 0), at(2026, 3, 30, 0)},
+		{"tomorrow", at(2026, 3, 31, 23), time.Monday, DateTomorrow, at(2026, 4, 1, 0), at(2026, 4, 2, 0)},
+		{"this week from Monday", at(2026, 3, 11, 15), time.Monday, DateThisWeek, at(2026, 3, 9, 0), at(2026, 3, 16, 0)},
+		{"this week from Sunday", at(2026, 3, 11, 15), time.Sunday, DateThisWeek, at(2026, 3, 8, 0), at(2026, 3, 15, 0)},
+		{"week is this week", at(2026, 3, 11, 15), time.Monday, DateWeek, at(2026, 3, 9, 0), at(2026, 3, 16, 0)},
+		{"week over DST", at(2026, 3, 27, 15), time.Monday, DateThisWeek, at(2026, 3, 23, 0), at(2026, 3, 30, 0)},
+		{"next week", at(2026, 3, 15, 23), time.Monday, DateNextWeek, at(2026, 3, 16, 0), at(2026, 3, 23, 0)},
+		{"next 7 days", at(2026, 3, 25, 15), time.Monday, DateNext7Days, at(2026, 3, 25, 15), at(2026, 4, 1, 15)},
+		{"this month", at(2026, 3, 31, 23), time.Monday, DateThisMonth, at(2026, 3, 1, 0), at(2026, 4, 1, 0)},
+		{"this month in February", at(2026, 2, 28, 12), time.Monday, DateThisMonth, at(2026, 2, 1, 0), at(2026, 3, 1, 0)},
+		{"this month in December", at(2026, 12, 31, 23), time.Monday, DateThisMonth, at(2026, 12, 1, 0), at(2027, 1, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isRange, err := DatePresetRange(tt.preset, FixedClock{Time: tt.now, FirstDay: tt.weekDay})
+			if err != nil || !isRange {
+				t.Fatalf("DatePresetRange(%s) = %v, %v, %v", tt.preset, got, isRange, err)
+			}
+			if !got.From.Equal(tt.from) || !got.To.Equal(tt.to) {
+				t.Errorf("DatePresetRange(%s) = [%v, %v), want [%v, %v)", tt.preset, got.From, got.To, tt.from, tt.to)
+			}
+		})
+	}
+
+	clock := FixedClock{Time: at(2026, 3, 11, 15)}
+	for _, preset := range []string{DateOverdue, DateNoDate} {
+		if _, isRange, err := DatePresetRange(preset, clock); isRange || err != nil {
+			t.Errorf("DatePresetRange(%s) = %v, %v; want no range and no error", preset, isRange, err)
+		}
+	}
+	if _, _, err := DatePresetRange("someday", clock); err == nil {
+		t.Error("an unknown preset gave no error")
+	}
+}
+
+func TestMatchesDatePreset(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	// Late on the last day of the month, when it is already April in UTC.
+	clock := FixedClock{Time: time.Date(2026, 3, 31, 22, 0, 0, 0, newYork), FirstDay: time.Monday}
+
+	allDay := func(year int, month time.Month, day int) *Task {
+		due := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &Task{Status: TodoTask, DueDate: &due, DueAllDay: true}
+	}
+	timed := func(t time.Time) *Task {
+		return &Task{Status: TodoTask, DueDate: &t}
+	}
+
+	tests := []struct {
+		name   string
+		task   *Task
+		preset string
+		want   bool
+	}{
+		{"all-day today", allDay(2026, 3, 31), DateToday, true},
+		{"all-day today is not overdue yet", allDay(2026, 3, 31), DateOverdue, false},
+		{"all-day yesterday is overdue", allDay(2026, 3, 30), DateOverdue, true},
+		{"all-day tomorrow", allDay(2026, 4, 1), DateTomorrow, true},
+		{"all-day tomorrow is next month", allDay(2026, 4, 1), DateThisMonth, false},
+		{"all-day month end", allDay(2026, 3, 31), DateThisMonth, true},
+		{"timed tonight", timed(time.Date(2026, 3, 31, 23, 30, 0, 0, newYork)), DateToday, true},
+		{"timed tonight is this month", timed(time.Date(2026, 3, 31, 23, 30, 0, 0, newYork)), DateThisMonth, true},
+		{"timed past is overdue", timed(time.Date(2026, 3, 31, 21, 0, 0, 0, newYork)), DateOverdue, true},
+		{"timed after midnight is tomorrow", timed(time.Date(2026, 4, 1, 0, 30, 0, 0, newYork)), DateTomorrow, true},
+		{"no due date", &Task{Status: TodoTask}, DateNoDate, true},
+		{"no due date is not today", &Task{Status: TodoTask}, DateToday, false},
+		{"next week starts Monday", allDay(2026, 4, 6), DateNextWeek, true},
+		{"this week ends Sunday", allDay(2026, 4, 5), DateThisWeek, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.task.MatchesDatePreset(tt.preset, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchesDatePreset(%s) = %v, want %v", tt.preset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemClock(t *testing.T) {
+	tokyo := loadLocation(t, "Asia/Tokyo")
+	clock := NewSystemClock(tokyo, time.Sunday)
+	if now := clock.Now(); now.Location() != tokyo || time.Since(now) > time.Minute {
+		t.Errorf("Now = %v, want the current time in Asia/Tokyo", now)
+	}
+	if clock.WeekStart() != time.Sunday {
+		t.Errorf("WeekStart = %v, want Sunday", clock.WeekStart())
+	}
+	if now := NewSystemClock(nil, time.Monday).Now(); now.Location() != time.Local {
+		t.Errorf("a clock without a location reads %v, want local time", now.Location())
+	}
+}
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDependencyCycle is wrapped when a dependency would make a task wait,
+// however indirectly, for itself.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ErrBlocked is wrapped when a task cannot be started or completed because
+// tasks it depends on are still open.
+var ErrBlocked = errors.New("task is blocked")
+
+// DependsOn reports whether the task waits for the task with id.
+func (t *Task) DependsOn(id string) bool {
+	for _, blocker := range t.BlockedBy {
+		if blocker == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Dependencies indexes the BlockedBy lists of a set of tasks. Blockers
+// outside the set, such as deleted tasks, are ignored.
+type Dependencies struct {
+	tasks      map[string]*Task
+	dependents map[string][]*Task
+}
+
+func NewDependencies(tasks []*Task) *Dependencies {
+	d := &Dependencies{
+		tasks:      make(map[string]*Task, len(tasks)),
+		dependents: make(map[string][]*Task),
+	}
+	for _, task := range tasks {
+		d.tasks[task.ID] = task
+	}
+	for _, task := range tasks {
+		for _, id := range task.BlockedBy {
+			if _, ok := d.tasks[id]; ok {
+				d.dependents[id] = append(d.dependents[id], task)
+			}
+		}
+	}
+	return d
+}
+
+// Blockers returns the tasks task waits for.
+func (d *Dependencies) Blockers(task *Task) []*Task {
+	blockers := make([]*Task, 0, len(task.BlockedBy))
+	for _, id := range task.BlockedBy {
+		if blocker, ok := d.tasks[id]; ok {
+			blockers = append(blockers, blocker)
+		}
+	}
+	return blockers
+}
+
+// OpenBlockers returns the tasks task waits for that are not done or
+// cancelled yet.
+func (d *Dependencies) OpenBlockers(task *Task) []*Task {
+	open := make([]*Task, 0)
+	for _, blocker := range d.Blockers(task) {
+		if !blocker.Status.IsClosed() {
+			open = append(open, blocker)
+		}
+	}
+	return open
+}
+
+// Dependents returns the tasks waiting for the task with id.
+func (d *Dependencies) Dependents(id string) []*Task {
+	return d.dependents[id]
+}
+
+// IsBlocked reports whether task cannot be worked on: it is in the blocked
+// state or waits for an open task.
+func (d *Dependencies) IsBlocked(task *Task) bool {
+	return task.Status == BlockedTask || len(d.OpenBlockers(task)) > 0
+}
+
+// IsReady reports whether task is open and can be worked on now.
+func (d *Dependencies) IsReady(task *Task, clock Clock) bool {
+	return !task.Status.IsClosed() && !task.IsDeferred(clock) && !d.IsBlocked(task)
+}
+
+// CheckDependency returns an error if the task with id cannot wait for the
+// task with blockerID because that task already waits for it.
+func (d *Dependencies) CheckDependency(id, blockerID string) error {
+	if id == blockerID {
+		return errors.New("task cannot depend on itself")
+	}
+	if path := d.path(blockerID, id, map[string]bool{}); path != nil {
+		titles := make([]string, 0, len(path)+1)
+		for _, task := range append([]*Task{d.tasks[id]}, path...) {
+			titles = append(titles, fmt.Sprintf("%q", task.Title))
+		}
+		return fmt.Errorf("%s: %w", strings.Join(titles, " → "), ErrDependencyCycle)
+	}
+	return nil
+}
+
+// path returns the tasks from the task with id to the one with target
+// following BlockedBy, or nil if target cannot be reached.
+func (d *Dependencies) path(id, target string, seen map[string]bool) []*Task {
+	task, ok := d.tasks[id]
+	if !ok || seen[id] {
+		return nil
+	}
+	seen[id] = true
+	if id == target {
+		return []*Task{task}
+	}
+	for _, blocker := range task.BlockedBy {
+		if rest := d.path(blocker, target, seen); rest != nil {
+			return append([]*Task{task}, rest...)
+		}
+	}
+	return nil
+}
+
+// Order returns tasks so that every task comes after the tasks it waits for,
+// keeping the given order where dependencies leave a choice. Only
+// dependencies between the given tasks count. Tasks caught in a cycle, which
+// only imported data can contain, come last in the given order.
+func (d *Dependencies) Order(tasks []*Task) []*Task {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+	waiting := make([]int, len(tasks))
+	for i, task := range tasks {
+		for _, id := range task.BlockedBy {
+			if _, ok := index[id]; ok {
+				waiting[i]++
+			}
+		}
+	}
+
+	ordered := make([]*Task, 0, len(tasks))
+	done := make([]bool, len(tasks))
+	for len(ordered) < len(tasks) {
+		next := -1
+		for i := range tasks {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			for i, task := range tasks {
+				if !done[i] {
+					ordered = append(ordered, task)
+				}
+			}
+			break
+		}
+
+		done[next] = true
+		ordered = append(ordered, tasks[next])
+		for _, dependent := range d.dependents[tasks[next].ID] {
+			if i, ok := index[dependent.ID]; ok {
+				waiting[i]--
+			}
+		}
+	}
+	return ordered
+}
+
+// CriticalPath returns the heaviest chain of the given tasks, each waiting
+// for the one before it, as weighed by weight. It is the chain that decides
+// how soon the last of them can be done.
+func (d *Dependencies) CriticalPath(tasks []*Task, weight func(*Task) int) []*Task {
+	byID := make(map[string]*Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	// Walking in dependency order, each task's heaviest chain extends the
+	// heaviest chain of one of its blockers.
+	total := make(map[string]int, len(tasks))
+	previous := make(map[string]string, len(tasks))
+	var last string
+	for _, task := range d.Order(tasks) {
+		best := ""
+		for _, id := range task.BlockedBy {
+			if _, walked := total[id]; walked && (best == "" || total[id] > total[best]) {
+				best = id
+			}
+		}
+		total[task.ID] = weight(task) + total[best]
+		previous[task.ID] = best
+		if last == "" || total[task.ID] > total[last] {
+			last = task.ID
+		}
+	}
+
+	var path []*Task
+	for id := last; id != ""; id = previous[id] {
+		path = append([]*Task{byID[id]}, path...)
+	}
+	return path
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// chain builds tasks from "id:blocker,blocker" specs, titled after their
+// IDs.
+func chain(specs ...string) []*Task {
+	tasks := make([]*Task, len(specs))
+	for i, spec := range specs {
+		id, blockers, _ := strings.Cut(spec, ":")
+		tasks[i] = &Task{ID: id, Title: strings.ToUpper(id), Status: TodoTask, Priority: MediumPriority}
+		if blockers != "" {
+			tasks[i].BlockedBy = strings.Split(blockers, ",")
+		}
+	}
+	return tasks
+}
+
+func taskIDs(tasks []*Task) string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestCheckDependency(t *testing.T) {
+	// c waits for b, which waits for a; d is on its own.
+	deps := NewDependencies(chain("a", "b:a", "c:b", "d"))
+
+	tests := []struct {
+		name      string
+		id        string
+		blockerID string
+		wantCycle string
+	}{
+		{"independent tasks", "d", "a", ""},
+		{"already implied", "c", "a", ""},
+		{"direct cycle", "a", "b", `"A" → "B" → "A"`},
+		{"indirect cycle", "a", "c", `"A" → "C" → "B" → "A"`},
+		{"unknown blocker", "a", "gone", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := deps.CheckDependency(tt.id, tt.blockerID)
+			switch {
+			case tt.wantCycle == "" && err != nil:
+				t.Fatalf("CheckDependency(%s, %s) = %v, want nil", tt.id, tt.blockerID, err)
+			case tt.wantCycle != "" && !errors.Is(err, ErrDependencyCycle):
+				t.Fatalf("CheckDependency(%s, %s) = %v, want %v", tt.id, tt.blockerID, err, ErrDependencyCycle)
+			case tt.wantCycle != "" && !strings.HasPrefix(err.Error(), tt.wantCycle+":"):
+				t.Errorf("error %q does not name the cycle %s", err, tt.wantCycle)
+			}
+		})
+	}
+
+	if err := deps.CheckDependency("a", "a"); err == nil || errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("a task depending on itself gave %v, want a plain error", err)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []*Task
+		want  string
+	}{
+		{"no dependencies keep the given order", chain("c", "a", "b"), "c a b"},
+		{"blockers move first", chain("c:a", "b", "a"), "b a c"},
+		{"ties keep the given order", chain("d:a", "c:a", "b", "a"), "b a d c"},
+		{"diamond", chain("d:b,c", "c:a", "b:a", "a"), "a c b d"},
+		{"blockers outside the list are ignored", chain("b:gone", "a"), "b a"},
+		{"cycle comes last in the given order", chain("x:y", "a", "y:x", "b:a"), "a b x y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewDependencies(tt.tasks).Order(tt.tasks)
+			if taskIDs(got) != tt.want {
+				t.Errorf("Order = %s, want %s", taskIDs(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderOfSubset(t *testing.T) {
+	// Only dependencies between the given tasks count: c's blocker b is not
+	// among them.
+	all := chain("a", "b:a", "c:b")
+	got := NewDependencies(all).Order([]*Task{all[2], all[0]})
+	if taskIDs(got) != "c a" {
+		t.Errorf("Order = %s, want c a", taskIDs(got))
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	weights := map[string]int{"a": 1, "b": 5, "c": 1, "d": 1, "e": 1}
+	byWeight := func(task *Task) int { return weights[task.ID] }
+	one := func(*Task) int { return 1 }
+
+	tests := []struct {
+		name   string
+		tasks  []*Task
+		weight func(*Task) int
+		want   string
+	}{
+		{"empty", nil, one, ""},
+		{"single task", chain("a"), one, "a"},
+		{"longest chain", chain("a", "b:a", "c:b", "d", "e:d"), one, "a b c"},
+		// d → e → c has more tasks, but b weighs more than both.
+		{"heaviest chain wins over longest", chain("a", "b:a", "c:e", "d", "e:d"), byWeight, "a b"},
+		{"heaviest blocker of several", chain("a", "b", "c:a,b"), byWeight, "b c"},
+		{"ties keep the first chain", chain("a", "b", "c:a", "d:b"), one, "a c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewDependencies(tt.tasks).CriticalPath(tt.tasks, tt.weight)
+			if taskIDs(got) != tt.want {
+				t.Errorf("CriticalPath = %s, want %s", taskIDs(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estimate is the effort a task is planned to take: a duration, or story
+// points for teams that estimate relative to other tasks. Exactly one of
+// Minutes and Points is set.
+type Estimate struct {
+	Minutes int     `json:"minutes,omitempty"`
+	Points  float64 `json:"points,omitempty"`
+}
+
+// estimatePointSuffixes mark an estimate as story points, as in "3pt".
+var estimatePointSuffixes = []string{"points", "point", "pts", "pt", "sp"}
+
+// ParseEstimate reads a duration such as "90m" or "1h30m", or story points
+// such as "3pt" or "0.5 points". An empty string is no estimate.
+func ParseEstimate(s string) (*Estimate, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, suffix := range estimatePointSuffixes {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			points, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid story points %q", s)
+			}
+			estimate := &Estimate{Points: points}
+			return estimate, estimate.Validate()
+		}
+	}
+
+	d, err := time.ParseDuration(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid estimate %q: use a duration like 1h30m or points like 3pt", s)
+	}
+	estimate := &Estimate{Minutes: int(d.Round(time.Minute) / time.Minute)}
+	return estimate, estimate.Validate()
+}
+
+func (e Estimate) Validate() error {
+	switch {
+	case e.Minutes < 0 || e.Points < 0:
+		return errors.New("estimate cannot be negative")
+	case e.Minutes > 0 && e.Points > 0:
+		return errors.New("estimate is either a duration or story points, not both")
+	case e.Minutes == 0 && e.Points == 0:
+		return errors.New("estimate must be at least a minute or above zero points")
+	}
+	return nil
+}
+
+func (e Estimate) IsPoints() bool {
+	return e.Points > 0
+}
+
+func (e Estimate) Duration() time.Duration {
+	return time.Duration(e.Minutes) * time.Minute
+}
+
+// String gives the estimate the way ParseEstimate reads it.
+func (e Estimate) String() string {
+	if e.IsPoints() {
+		return strconv.FormatFloat(e.Points, 'f', -1, 64) + "pt"
+	}
+	hours, minutes := e.Minutes/60, e.Minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
+
+// Compare orders durations before story points and each by size.
+func (e Estimate) Compare(other Estimate) int {
+	if e.IsPoints() != other.IsPoints() {
+		if e.IsPoints() {
+			return 1
+		}
+		return -1
+	}
+	switch {
+	case e.Minutes != other.Minutes:
+		return e.Minutes - other.Minutes
+	case e.Points < other.Points:
+		return -1
+	case e.Points > other.Points:
+		return 1
+	}
+	return 0
+}
+
+func (t *Task) SetEstimate(clock Clock, estimate *Estimate) {
+	t.Estimate = estimate
+	t.UpdatedAt = clock.Now()
+}
+
+// IsOverEstimate reports whether tracked time went past the task's
+// estimate. Story points cannot be compared with time, so tasks estimated
+// in points never are.
+func (t *Task) IsOverEstimate(tracked time.Duration) bool {
+	return t.Estimate != nil && !t.Estimate.IsPoints() && tracked > t.Estimate.Duration()
+}
@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// A focus session alternates work phases, the pomodoros, with short breaks
+// and, after every few pomodoros, a long break.
+const (
+	FocusWork       = "work"
+	FocusShortBreak = "short_break"
+	FocusLongBreak  = "long_break"
+)
+
+var (
+	ErrFocusPaused    = errors.New("focus session is already paused")
+	ErrFocusNotPaused = errors.New("focus session is not paused")
+)
+
+type FocusDurations struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	// Cycles is the number of pomodoros before a long break; less than one
+	// counts as one.
+	Cycles int
+}
+
+func (d FocusDurations) Of(phase string) time.Duration {
+	switch phase {
+	case FocusShortBreak:
+		return d.ShortBreak
+	case FocusLongBreak:
+		return d.LongBreak
+	}
+	return d.Work
+}
+
+// FocusSession is a run of pomodoros on one task. Phases follow each other
+// until the session is stopped; time spent paused does not count.
+type FocusSession struct {
+	TaskID    string
+	Phase     string
+	Durations FocusDurations
+	// Round is the pomodoro of the current cycle, from 1.
+	Round     int
+	Completed int
+
+	phaseStart time.Time
+	endsAt     time.Time
+	paused     bool
+	left       time.Duration
+}
+
+// FocusPhase is a phase that came to an end.
+type FocusPhase struct {
+	Phase string
+	Start time.Time
+	End   time.Time
+	// Worked is the time the phase ran, leaving out pauses.
+	Worked time.Duration
+	// Skipped phases were ended before their time was up.
+	Skipped bool
+}
+
+// IsPomodoro reports whether the phase was a work phase that ran its full
+// length.
+func (p FocusPhase) IsPomodoro() bool {
+	return p.Phase == FocusWork && !p.Skipped
+}
+
+func NewFocusSession(taskID string, durations FocusDurations, now time.Time) *FocusSession {
+	s := &FocusSession{TaskID: taskID, Durations: durations, Round: 1}
+	s.begin(FocusWork, now)
+	return s
+}
+
+func (s *FocusSession) begin(phase string, now time.Time) {
+	s.Phase = phase
+	s.phaseStart = now
+	s.endsAt = now.Add(s.Durations.Of(phase))
+	s.paused = false
+	s.left = 0
+}
+
+func (s *FocusSession) IsPaused() bool {
+	return s.paused
+}
+
+// Remaining is the time left in the current phase.
+func (s *FocusSession) Remaining(now time.Time) time.Duration {
+	if s.paused {
+		return s.left
+	}
+	if left := s.endsAt.Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+func (s *FocusSession) Pause(now time.Time) error {
+	if s.paused {
+		return ErrFocusPaused
+	}
+	s.left = s.Remaining(now)
+	s.paused = true
+	return nil
+}
+
+func (s *FocusSession) Resume(now time.Time) error {
+	if !s.paused {
+		return ErrFocusNotPaused
+	}
+	s.endsAt = now.Add(s.left)
+	s.paused = false
+	s.left = 0
+	return nil
+}
+
+// Advance moves on to the next phase once the current one is up and returns
+// the phase that ended, or nil if it is not up yet. The next phase starts
+// now rather than when the last one was up, so a computer waking from sleep
+// does not run through the phases it missed.
+func (s *FocusSession) Advance(now time.Time) *FocusPhase {
+	if s.paused || now.Before(s.endsAt) {
+		return nil
+	}
+	ended := s.end(s.endsAt, false)
+	s.next(now, true)
+	return ended
+}
+
+// Skip ends the current phase early and starts the next one. A skipped work
+// phase is not a pomodoro.
+func (s *FocusSession) Skip(now time.Time) *FocusPhase {
+	ended := s.end(now, true)
+	s.next(now, false)
+	return ended
+}
+
+// Stop ends the session, returning the phase it was in.
+func (s *FocusSession) Stop(now time.Time) *FocusPhase {
+	return s.end(now, true)
+}
+
+func (s *FocusSession) end(at time.Time, skipped bool) *FocusPhase {
+	worked := s.Durations.Of(s.Phase) - s.Remaining(at)
+	return &FocusPhase{Phase: s.Phase, Start: s.phaseStart, End: at, Worked: worked, Skipped: skipped}
+}
+
+func (s *FocusSession) next(now time.Time, completed bool) {
+	switch s.Phase {
+	case FocusWork:
+		if completed {
+			s.Completed++
+		}
+		if s.Round >= s.Durations.Cycles {
+			s.begin(FocusLongBreak, now)
+		} else {
+			s.begin(FocusShortBreak, now)
+		}
+	case FocusLongBreak:
+		s.Round = 1
+		s.begin(FocusWork, now)
+	default:
+		s.Round++
+		s.begin(FocusWork, now)
+	}
+}
+
+// FocusState is a snapshot of a session for display.
+type FocusState struct {
+	Active    bool   `json:"active"`
+	TaskID    string `json:"task_id,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+	Round     int    `json:"round,omitempty"`
+	Cycles    int    `json:"cycles,omitempty"`
+	Completed int    `json:"completed"`
+	Paused    bool   `json:"paused"`
+	// Remaining and Length are seconds of the current phase.
+	Remaining int64 `json:"remaining"`
+	Length    int64 `json:"length"`
+}
+
+// State describes the session; a nil session is no session.
+func (s *FocusSession) State(now time.Time) FocusState {
+	if s == nil {
+		return FocusState{}
+	}
+	return FocusState{
+		Active:    true,
+		TaskID:    s.TaskID,
+		Phase:     s.Phase,
+		Round:     s.Round,
+		Cycles:    s.Durations.Cycles,
+		Completed: s.Completed,
+		Paused:    s.paused,
+		Remaining: int64((s.Remaining(now) + time.Second - 1) / time.Second),
+		Length:    int64(s.Durations.Of(s.Phase) / time.Second),
+	}
+}
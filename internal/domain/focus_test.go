@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var testDurations = FocusDurations{Work: 25 * time.Minute, ShortBreak: 5 * time.Minute, LongBreak: 15 * time.Minute, Cycles: 2}
+
+var focusStart = time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+func TestFocusSessionPhases(t *testing.T) {
+	s := NewFocusSession("t1", testDurations, focusStart)
+	now := focusStart
+
+	steps := []struct {
+		phase     string
+		round     int
+		completed int
+	}{
+		{FocusShortBreak, 1, 1},
+		{FocusWork, 2, 1},
+		{FocusLongBreak, 2, 2},
+		{FocusWork, 1, 2},
+		{FocusShortBreak, 1, 3},
+	}
+	for i, step := range steps {
+		length := s.Durations.Of(s.Phase)
+		if ended := s.Advance(now.Add(length - time.Second)); ended != nil {
+			t.Fatalf("step %d: %s ended a second early", i, s.Phase)
+		}
+		now = now.Add(length)
+		previous := s.Phase
+		ended := s.Advance(now)
+		if ended == nil {
+			t.Fatalf("step %d: %s did not end after %v", i, previous, length)
+		}
+		if ended.Phase != previous || ended.Worked != length || ended.Skipped {
+			t.Errorf("step %d: ended %+v, want a full %s of %v", i, ended, previous, length)
+		}
+		if ended.IsPomodoro() != (previous == FocusWork) {
+			t.Errorf("step %d: IsPomodoro = %v for %s", i, ended.IsPomodoro(), previous)
+		}
+		if s.Phase != step.phase || s.Round != step.round || s.Completed != step.completed {
+			t.Errorf("step %d: phase %s round %d completed %d, want %s round %d completed %d",
+				i, s.Phase, s.Round, s.Completed, step.phase, step.round, step.completed)
+		}
+	}
+}
+
+func TestFocusSessionAdvanceAfterSleep(t *testing.T) {
+	s := NewFocusSession("t1", testDurations, focusStart)
+
+	// Waking an hour later ends the work phase at its time and starts the
+	// break now instead of running through the missed phases.
+	wake := focusStart.Add(time.Hour)
+	ended := s.Advance(wake)
+	if ended == nil || !ended.End.Equal(focusStart.Add(testDurations.Work)) {
+		t.Fatalf("ended %+v, want the work phase ending at %v", ended, focusStart.Add(testDurations.Work))
+	}
+	if s.Phase != FocusShortBreak || s.Remaining(wake) != testDurations.ShortBreak {
+		t.Errorf("phase %s with %v left, want a full short break", s.Phase, s.Remaining(wake))
+	}
+}
+
+func TestFocusSessionSkip(t *testing.T) {
+	s := NewFocusSession("t1", testDurations, focusStart)
+
+	ended := s.Skip(focusStart.Add(10 * time.Minute))
+	if ended.Phase != FocusWork || !ended.Skipped || ended.Worked != 10*time.Minute || ended.IsPomodoro() {
+		t.Errorf("skipped %+v, want 10m of work that is not a pomodoro", ended)
+	}
+	if s.Phase != FocusShortBreak || s.Completed != 0 {
+		t.Errorf("phase %s completed %d, want a short break and no pomodoro", s.Phase, s.Completed)
+	}
+
+	// Skipping the break moves on to the next round.
+	s.Skip(focusStart.Add(11 * time.Minute))
+	if s.Phase != FocusWork || s.Round != 2 {
+		t.Errorf("phase %s round %d, want work in round 2", s.Phase, s.Round)
+	}
+}
+
+func TestFocusSessionPauseAndResume(t *testing.T) {
+	s := NewFocusSession("t1", testDurations, focusStart)
+
+	paused := focusStart.Add(10 * time.Minute)
+	if err := s.Pause(paused); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Pause(paused); !errors.Is(err, ErrFocusPaused) {
+		t.Errorf("second Pause = %v, want %v", err, ErrFocusPaused)
+	}
+
+	// Time spent paused does not count.
+	later := paused.Add(time.Hour)
+	if got := s.Remaining(later); got != 15*time.Minute {
+		t.Errorf("Remaining while paused = %v, want 15m", got)
+	}
+	if ended := s.Advance(later); ended != nil {
+		t.Errorf("a paused phase ended: %+v", ended)
+	}
+	if state := s.State(later); !state.Paused || state.Remaining != 15*60 {
+		t.Errorf("State = %+v, want paused with 900s left", state)
+	}
+
+	if err := s.Resume(later); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Resume(later); !errors.Is(err, ErrFocusNotPaused) {
+		t.Errorf("second Resume = %v, want %v", err, ErrFocusNotPaused)
+	}
+	if ended := s.Advance(later.Add(15*time.Minute - time.Second)); ended != nil {
+		t.Fatalf("phase ended before its unpaused time was up: %+v", ended)
+	}
+	ended := s.Advance(later.Add(15 * time.Minute))
+	if ended == nil || ended.Worked != testDurations.Work || !ended.IsPomodoro() {
+		t.Errorf("ended %+v, want a full pomodoro", ended)
+	}
+}
+
+func TestFocusSessionWithoutCycles(t *testing.T) {
+	durations := testDurations
+	durations.Cycles = 0
+	s := NewFocusSession("t1", durations, focusStart)
+
+	s.Advance(focusStart.Add(durations.Work))
+	if s.Phase != FocusLongBreak {
+		t.Errorf("phase %s, want a long break after every pomodoro", s.Phase)
+	}
+}
+
+func TestFocusSessionStop(t *testing.T) {
+	s := NewFocusSession("t1", testDurations, focusStart)
+	ended := s.Stop(focusStart.Add(20 * time.Minute))
+	if ended.Phase != FocusWork || !ended.Skipped || ended.Worked != 20*time.Minute {
+		t.Errorf("stopped %+v, want 20m of unfinished work", ended)
+	}
+
+	var none *FocusSession
+	if state := none.State(focusStart); state.Active {
+		t.Errorf("State of no session = %+v, want inactive", state)
+	}
+}
@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ranks order tasks by plain string comparison, so a task can be moved
+// between two others by giving it a rank between theirs without touching
+// any other task. Ranks use the digits below and never end in the lowest
+// one, which keeps room between any two of them.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const rankBase = len(rankDigits)
+
+// ValidRank reports whether rank can be stored. The empty rank means the
+// task was never placed.
+func ValidRank(rank string) bool {
+	if rank == "" {
+		return true
+	}
+	if rank[len(rank)-1] == rankDigits[0] {
+		return false
+	}
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RankBetween returns a rank that sorts after before and ahead of after.
+// An empty before means the start of the list and an empty after its end.
+func RankBetween(before, after string) (string, error) {
+	if !ValidRank(before) || !ValidRank(after) {
+		return "", fmt.Errorf("invalid rank %q or %q", before, after)
+	}
+	if after != "" && before >= after {
+		return "", fmt.Errorf("rank %q does not sort before %q", before, after)
+	}
+	return rankMidpoint(before, after), nil
+}
+
+// rankMidpoint needs a < b, where an empty b stands for the end.
+func rankMidpoint(a, b string) string {
+	// Skip the common prefix, reading missing digits of a as the lowest.
+	n := 0
+	for n < len(b) && rankDigitAt(a, n) == b[n] {
+		n++
+	}
+	if n > 0 {
+		rest := ""
+		if n < len(a) {
+			rest = a[n:]
+		}
+		return b[:n] + rankMidpoint(rest, b[n:])
+	}
+
+	low := 0
+	if a != "" {
+		low = strings.IndexByte(rankDigits, a[0])
+	}
+	high := rankBase
+	if b != "" {
+		high = strings.IndexByte(rankDigits, b[0])
+	}
+	if high-low > 1 {
+		return string(rankDigits[(low+high)/2])
+	}
+
+	// The first digits are adjacent. A longer b can be cut short; otherwise
+	// keep a's digit and find room after it.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(rankDigits[low]) + rankMidpoint(rest, "")
+}
+
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+// SpreadRanks returns n ascending ranks spaced evenly, as short as possible
+// while leaving room between neighbours.
+func SpreadRanks(n int) []string {
+	width := 1
+	for capacity := rankBase; capacity < 2*(n+1); capacity *= rankBase {
+		width++
+	}
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= rankBase
+	}
+	step := space / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := step * (i + 1)
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%rankBase]
+			value /= rankBase
+		}
+		ranks[i] = strings.TrimRight(string(digits), rankDigits[:1])
+	}
+	return ranks
+}
+
+// RankLess orders tasks by rank, tasks never placed after the others by
+// creation time.
+func RankLess(a, b *Task) bool {
+	if (a.Rank == "") != (b.Rank == "") {
+		return a.Rank != ""
+	}
+	if a.Rank != b.Rank {
+		return a.Rank < b.Rank
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
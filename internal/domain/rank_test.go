@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		before, after string
+	}{
+		{"", ""},
+		{"", "1"},
+		{"", "01"},
+		{"z", ""},
+		{"zz", ""},
+		{"i", "j"},
+		{"i", "i1"},
+		{"i", "i01"},
+		{"iz", "j"},
+		{"izz", "j1"},
+		{"a1", "a2"},
+		{"0001", "0002"},
+	}
+	for _, tt := range tests {
+		got, err := RankBetween(tt.before, tt.after)
+		if err != nil {
+			t.Errorf("RankBetween(%q, %q) = %v", tt.before, tt.after, err)
+			continue
+		}
+		if !ValidRank(got) || got == "" || got <= tt.before || (tt.after != "" && got >= tt.after) {
+			t.Errorf("RankBetween(%q, %q) = %q, want a valid rank between them", tt.before, tt.after, got)
+		}
+	}
+}
+
+func TestRankBetweenErrors(t *testing.T) {
+	tests := []struct {
+		before, after string
+	}{
+		{"b", "a"},
+		{"a", "a"},
+		{"a0", "b"},
+		{"a", "B"},
+		{"a-", ""},
+	}
+	for _, tt := range tests {
+		if got, err := RankBetween(tt.before, tt.after); err == nil {
+			t.Errorf("RankBetween(%q, %q) = %q, want an error", tt.before, tt.after, got)
+		}
+	}
+}
+
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+	// Dropping cards at the top, at the bottom, or always right after the
+	// same card must keep producing valid ranks in order.
+	for name, pick := range map[string]func(ranks []string) int{
+		"top":    func([]string) int { return 0 },
+		"bottom": func(ranks []string) int { return len(ranks) },
+		"second": func(ranks []string) int { return min(1, len(ranks)) },
+		"random": func(ranks []string) int { return rand.New(rand.NewSource(int64(len(ranks)))).Intn(len(ranks) + 1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ranks []string
+			for i := 0; i < 500; i++ {
+				at := pick(ranks)
+				var before, after string
+				if at > 0 {
+					before = ranks[at-1]
+				}
+				if at < len(ranks) {
+					after = ranks[at]
+				}
+				rank, err := RankBetween(before, after)
+				if err != nil {
+					t.Fatalf("insert %d: RankBetween(%q, %q) = %v", i, before, after, err)
+				}
+				ranks = append(ranks[:at], append([]string{rank}, ranks[at:]...)...)
+			}
+			assertRanksAscend(t, ranks)
+		})
+	}
+}
+
+func TestSpreadRanks(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 17, 35, 36, 100, 5000} {
+		ranks := SpreadRanks(n)
+		if len(ranks) != n {
+			t.Fatalf("SpreadRanks(%d) returned %d ranks", n, len(ranks))
+		}
+		assertRanksAscend(t, ranks)
+		for i := 0; i+1 < len(ranks); i++ {
+			if _, err := RankBetween(ranks[i], ranks[i+1]); err != nil {
+				t.Errorf("SpreadRanks(%d): no room between %q and %q: %v", n, ranks[i], ranks[i+1], err)
+			}
+		}
+	}
+
+	if got := SpreadRanks(3); len(got[0]) != 1 || len(got[2]) != 1 {
+		t.Errorf("SpreadRanks(3) = %q, want single digits", got)
+	}
+	if got := SpreadRanks(100); len(got[99]) > 2 {
+		t.Errorf("SpreadRanks(100) ends with %q, want at most two digits", got[99])
+	}
+}
+
+func assertRanksAscend(t *testing.T, ranks []string) {
+	t.Helper()
+	for i, rank := range ranks {
+		if rank == "" || !ValidRank(rank) {
+			t.Fatalf("rank %d = %q is not valid", i, rank)
+		}
+		if i > 0 && ranks[i-1] >= rank {
+			t.Fatalf("ranks %d and %d are %q and %q, want them ascending", i-1, i, ranks[i-1], rank)
+		}
+	}
+}
+
+func TestRankLess(t *testing.T) {
+	created := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	task := func(rank string, minutes int) *Task {
+		return &Task{Rank: rank, CreatedAt: created.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	tests := []struct {
+		a, b *Task
+		want bool
+	}{
+		{task("a", 5), task("b", 0), true},
+		{task("b", 0), task("a", 5), false},
+		{task("z", 5), task("", 0), true},
+		{task("", 0), task("a", 5), false},
+		{task("", 0), task("", 5), true},
+		{task("a", 0), task("a", 5), true},
+	}
+	for _, tt := range tests {
+		if got := RankLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("RankLess(%q at %v, %q at %v) = %v, want %v", tt.a.Rank, tt.a.CreatedAt, tt.b.Rank, tt.b.CreatedAt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+type SortField string
+
+const (
+	SortCreated     SortField = "created"
+	SortUpdated     SortField = "updated"
+	SortPriority    SortField = "priority"
+	SortDueDate     SortField = "due_date"
+	SortTitle       SortField = "title"
+	SortCompletedAt SortField = "completed_at"
+	SortEstimate    SortField = "estimate"
+	// SortManual is the order the user arranged by hand; see RankBetween.
+	SortManual SortField = "manual"
+)
+
+var SortFields = []SortField{SortCreated, SortUpdated, SortPriority, SortDueDate, SortTitle, SortCompletedAt, SortEstimate, SortManual}
+
+func (f SortField) IsValid() bool {
+	for _, field := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+type SortKey struct {
+	Field SortField `json:"field"`
+	Desc  bool      `json:"desc,omitempty"`
+}
+
+// SortSpec orders tasks by its first key, ties by the next one and so on.
+// Tasks equal on every key are ordered by ID, so a spec always gives the
+// same order. Tasks without a value for a key, such as a due date, go after
+// the others whichever way the key sorts. Estimates sort durations before
+// story points.
+type SortSpec []SortKey
+
+// ParseSortSpec reads keys like "priority desc, due_date, title asc".
+// Keys sort ascending unless followed by desc.
+func ParseSortSpec(s string) (SortSpec, error) {
+	var spec SortSpec
+	for _, part := range strings.Split(s, ",") {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid sort key %q", strings.TrimSpace(part))
+		}
+		key := SortKey{Field: SortField(words[0])}
+		if len(words) == 2 {
+			switch words[1] {
+			case "asc":
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("sort order must be asc or desc, not %q", words[1])
+			}
+		}
+		spec = append(spec, key)
+	}
+	return spec, spec.Validate()
+}
+
+func (s SortSpec) Validate() error {
+	if len(s) == 0 {
+		return errors.New("sort needs at least one key")
+	}
+	for _, key := range s {
+		if !key.Field.IsValid() {
+			return fmt.Errorf("unknown sort field %q", key.Field)
+		}
+	}
+	return nil
+}
+
+func (s SortSpec) String() string {
+	keys := make([]string, len(s))
+	for i, key := range s {
+		keys[i] = string(key.Field)
+		if key.Desc {
+			keys[i] += " desc"
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// SortLocale is what an order depends on besides the tasks: the time zone
+// all-day due dates start their day in and the language titles are
+// collated for. The zero Language collates for no language in particular.
+type SortLocale struct {
+	Location *time.Location
+	Language language.Tag
+}
+
+// SortTasks sorts tasks by spec in place.
+func SortTasks(tasks []*Task, spec SortSpec, locale SortLocale) {
+	loc := locale.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	titles := collate.New(locale.Language)
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		for _, key := range spec {
+			aMissing, bMissing := key.Field.missing(a), key.Field.missing(b)
+			if aMissing != bMissing {
+				return bMissing
+			}
+			if aMissing {
+				continue
+			}
+			n := key.Field.compare(a, b, loc, titles)
+			if key.Desc {
+				n = -n
+			}
+			if n != 0 {
+				return n < 0
+			}
+		}
+		return a.ID < b.ID
+	})
+}
+
+func (f SortField) missing(task *Task) bool {
+	switch f {
+	case SortDueDate:
+		return task.DueDate == nil
+	case SortCompletedAt:
+		return task.CompletedAt == nil
+	case SortEstimate:
+		return task.Estimate == nil
+	case SortManual:
+		return task.Rank == ""
+	}
+	return false
+}
+
+func (f SortField) compare(a, b *Task, loc *time.Location, titles *collate.Collator) int {
+	switch f {
+	case SortCreated:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case SortUpdated:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortPriority:
+		return priorityWeight(a.Priority) - priorityWeight(b.Priority)
+	case SortDueDate:
+		// By day, all-day tasks first, then by time.
+		aFrom, _ := a.DueSpan(loc)
+		bFrom, _ := b.DueSpan(loc)
+		if n := aFrom.Compare(bFrom); n != 0 {
+			return n
+		}
+		if a.DueAllDay != b.DueAllDay {
+			if a.DueAllDay {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	case SortTitle:
+		return titles.CompareString(a.Title, b.Title)
+	case SortCompletedAt:
+		return a.CompletedAt.Compare(*b.CompletedAt)
+	case SortEstimate:
+		return a.Estimate.Compare(*b.Estimate)
+	case SortManual:
+		return strings.Compare(a.Rank, b.Rank)
+	}
+	return 0
+}
+
+func priorityWeight(priority Priority) int {
+	switch priority {
+	case HighPriority:
+		return 3
+	case MediumPriority:
+		return 2
+	}
+	return 1
+}
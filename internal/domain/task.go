@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
@@ -12,13 +13,19 @@ const (
 	HighPriority   Priority = "high"
 )
 
-type TaskStatus string
-
-const (
-	ActiveTask    TaskStatus = "active"
-	CompletedTask TaskStatus = "completed"
-)
+func (p Priority) IsValid() bool {
+	return p == LowPriority || p == MediumPriority || p == HighPriority
+}
 
+// Task is one to-do item. With DueAllDay set, DueDate is a date without a
+// time of day (see DateOnly) and the task is due until that day ends
+// wherever the user is. StartDate is also a date; before it arrives the
+// task is deferred and left out of active views. StartedAt and CompletedAt
+// record when the task last entered in_progress and a closed state.
+// Rank places the task in the manual order shared by the list and the board
+// (see RankBetween); it is empty for tasks never placed.
+// BlockedBy lists the IDs of the tasks that have to be done first.
+// Estimate is the planned effort, if any.
 type Task struct {
 	ID          string     `json:"id"`
 	Title       string     `json:"title"`
@@ -26,46 +33,176 @@ type Task struct {
 	Status      TaskStatus `json:"status"`
 	Priority    Priority   `json:"priority"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueAllDay   bool       `json:"due_all_day,omitempty"`
+	StartDate   *time.Time `json:"start_date,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	BlockedBy   []string   `json:"blocked_by,omitempty"`
+	Estimate    *Estimate  `json:"estimate,omitempty"`
+	Rank        string     `json:"rank,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 func NewTask(title, description string) *Task {
-	now := time.Now()
+	return NewTaskAt(title, description, time.Now())
+}
+
+// NewTaskAt creates a task as if it was created at now.
+func NewTaskAt(title, description string, now time.Time) *Task {
 	return &Task{
 		ID:          generateID(),
 		Title:       title,
 		Description: description,
-		Status:      ActiveTask,
+		Status:      TodoTask,
 		Priority:    MediumPriority,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 }
 
-func (t *Task) MarkComplete() {
-	t.Status = CompletedTask
-	t.UpdatedAt = time.Now()
-}
+// Transition moves the task to status and keeps StartedAt and CompletedAt
+// in step. It does not check the workflow; TaskService does.
+func (t *Task) Transition(clock Clock, status TaskStatus) {
+	now := clock.Now()
 
-func (t *Task) MarkActive() {
-	t.Status = ActiveTask
-	t.UpdatedAt = time.Now()
+	switch {
+	case status == TodoTask:
+		t.StartedAt = nil
+	case status == InProgressTask && t.StartedAt == nil:
+		t.StartedAt = &now
+	}
+	if status.IsClosed() {
+		if !t.Status.IsClosed() || t.CompletedAt == nil {
+			t.CompletedAt = &now
+		}
+	} else {
+		t.CompletedAt = nil
+	}
+
+	t.Status = status
+	t.UpdatedAt = now
 }
 
-func (t *Task) SetPriority(priority Priority) {
+func (t *Task) SetPriority(clock Clock, priority Priority) {
 	t.Priority = priority
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = clock.Now()
 }
 
-func (t *Task) SetDueDate(dueDate *time.Time) {
+// SetDueDate sets a timed due date, or with allDay only its calendar date.
+func (t *Task) SetDueDate(clock Clock, dueDate *time.Time, allDay bool) {
 	t.DueDate = dueDate
-	t.UpdatedAt = time.Now()
+	t.DueAllDay = dueDate != nil && allDay
+	if t.DueAllDay {
+		date := DateOnly(*dueDate)
+		t.DueDate = &date
+	}
+	t.UpdatedAt = clock.Now()
+}
+
+func (t *Task) SetStartDate(clock Clock, startDate *time.Time) {
+	t.StartDate = nil
+	if startDate != nil {
+		date := DateOnly(*startDate)
+		t.StartDate = &date
+	}
+	t.UpdatedAt = clock.Now()
+}
+
+func (t *Task) SetProject(clock Clock, project string) {
+	t.Project = strings.TrimSpace(project)
+	t.UpdatedAt = clock.Now()
+}
+
+func (t *Task) SetRank(clock Clock, rank string) {
+	t.Rank = rank
+	t.UpdatedAt = clock.Now()
+}
+
+// AddTags adds tags the task does not have yet, ignoring blanks.
+func (t *Task) AddTags(clock Clock, tags ...string) {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || t.HasTag(tag) {
+			continue
+		}
+		t.Tags = append(t.Tags, tag)
+	}
+	t.UpdatedAt = clock.Now()
 }
 
-func (t *Task) IsOverdue() bool {
-	if t.DueDate == nil || t.Status == CompletedTask {
+func (t *Task) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// DueSpan returns when the task is due in loc: the whole day for an all-day
+// due date, otherwise the instant twice.
+func (t *Task) DueSpan(loc *time.Location) (from, to time.Time) {
+	if !t.DueAllDay {
+		return *t.DueDate, *t.DueDate
+	}
+	from = DateIn(*t.DueDate, loc)
+	return from, AddDays(from, 1)
+}
+
+// IsOverdue reports whether the due date has passed. An all-day task only
+// becomes overdue once its day is over.
+func (t *Task) IsOverdue(clock Clock) bool {
+	if t.DueDate == nil || t.Status.IsClosed() {
+		return false
+	}
+	now := clock.Now()
+	_, deadline := t.DueSpan(now.Location())
+	if t.DueAllDay {
+		return !now.Before(deadline)
+	}
+	return now.After(deadline)
+}
+
+// IsDeferred reports whether an open task's start date is still ahead.
+func (t *Task) IsDeferred(clock Clock) bool {
+	if t.StartDate == nil || t.Status.IsClosed() {
 		return false
 	}
-	return time.Now().After(*t.DueDate)
-}
\ No newline at end of file
+	now := clock.Now()
+	return now.Before(DateIn(*t.StartDate, now.Location()))
+}
+
+func (t *Task) Clone() *Task {
+	clone := *t
+	if t.DueDate != nil {
+		dueDate := *t.DueDate
+		clone.DueDate = &dueDate
+	}
+	if t.StartDate != nil {
+		startDate := *t.StartDate
+		clone.StartDate = &startDate
+	}
+	if t.StartedAt != nil {
+		startedAt := *t.StartedAt
+		clone.StartedAt = &startedAt
+	}
+	if t.CompletedAt != nil {
+		completedAt := *t.CompletedAt
+		clone.CompletedAt = &completedAt
+	}
+	if t.Tags != nil {
+		clone.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.BlockedBy != nil {
+		clone.BlockedBy = append([]string(nil), t.BlockedBy...)
+	}
+	if t.Estimate != nil {
+		estimate := *t.Estimate
+		clone.Estimate = &estimate
+	}
+	return &clone
+}
@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrNoTimer is returned when a timer is stopped while none is running.
+var ErrNoTimer = errors.New("no timer is running")
+
+// TimeEntry is time spent on a task. An entry without an End is the running
+// timer; there is at most one. Focus entries are completed pomodoros.
+type TimeEntry struct {
+	ID     string     `json:"id"`
+	TaskID string     `json:"task_id"`
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"`
+	Note   string     `json:"note,omitempty"`
+	Focus  bool       `json:"focus,omitempty"`
+}
+
+func NewTimeEntry(taskID string, start time.Time, end *time.Time, note string) *TimeEntry {
+	return &TimeEntry{
+		ID:     generateID(),
+		TaskID: taskID,
+		Start:  start,
+		End:    end,
+		Note:   note,
+	}
+}
+
+func (e *TimeEntry) IsRunning() bool {
+	return e.End == nil
+}
+
+func (e *TimeEntry) Validate() error {
+	if e.TaskID == "" {
+		return errors.New("time entry needs a task")
+	}
+	if e.Start.IsZero() {
+		return errors.New("time entry needs a start time")
+	}
+	if e.End != nil && e.End.Before(e.Start) {
+		return fmt.Errorf("time entry ends at %s, before it starts at %s", e.End.Format("2006-01-02 15:04"), e.Start.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// Span returns when the entry started and ended; a running entry ends now.
+func (e *TimeEntry) Span(now time.Time) (start, end time.Time) {
+	if e.End == nil {
+		if now.Before(e.Start) {
+			return e.Start, e.Start
+		}
+		return e.Start, now
+	}
+	return e.Start, *e.End
+}
+
+func (e *TimeEntry) Duration(now time.Time) time.Duration {
+	start, end := e.Span(now)
+	return end.Sub(start)
+}
+
+func (e *TimeEntry) Clone() *TimeEntry {
+	clone := *e
+	if e.End != nil {
+		end := *e.End
+		clone.End = &end
+	}
+	return &clone
+}
+
+// Time can be grouped by the task it was spent on, the task's project or
+// the day it was spent.
+const (
+	TimeByTask    = "task"
+	TimeByProject = "project"
+	TimeByDay     = "day"
+)
+
+// TimeSegment is the part of an entry that counts towards one group. Entries
+// are cut at the edges of the period asked for and, grouped by day, at
+// midnight.
+type TimeSegment struct {
+	Entry *TimeEntry
+	Group string
+	Start time.Time
+	End   time.Time
+}
+
+func (s TimeSegment) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// TimeTotal is the time spent in one group. Group is the task ID, project or
+// day as 2006-01-02; Label is what to show for it.
+type TimeTotal struct {
+	Group   string `json:"group"`
+	Label   string `json:"label"`
+	Seconds int64  `json:"seconds"`
+	Entries int    `json:"entries"`
+}
+
+// TimeLog groups time entries of a set of tasks. Entries of tasks outside
+// the set, such as deleted tasks, still count.
+type TimeLog struct {
+	tasks map[string]*Task
+	loc   *time.Location
+	now   time.Time
+}
+
+func NewTimeLog(tasks []*Task, clock Clock) *TimeLog {
+	now := clock.Now()
+	log := &TimeLog{tasks: make(map[string]*Task, len(tasks)), loc: now.Location(), now: now}
+	for _, task := range tasks {
+		log.tasks[task.ID] = task
+	}
+	return log
+}
+
+// Task returns the task an entry was spent on, or nil if it is gone.
+func (l *TimeLog) Task(entry *TimeEntry) *Task {
+	return l.tasks[entry.TaskID]
+}
+
+// Segments cuts entries into the segments within r that count towards each
+// group, ordered by label and then time, in the clock's time zone. A zero
+// From or To leaves r open on that side.
+func (l *TimeLog) Segments(entries []*TimeEntry, groupBy string, r DateRange) ([]TimeSegment, error) {
+	switch groupBy {
+	case TimeByTask, TimeByProject, TimeByDay:
+	default:
+		return nil, fmt.Errorf("unknown time grouping %q", groupBy)
+	}
+
+	segments := make([]TimeSegment, 0, len(entries))
+	for _, entry := range entries {
+		start, end := entry.Span(l.now)
+		start, end = start.In(l.loc), end.In(l.loc)
+		if !r.From.IsZero() && start.Before(r.From) {
+			start = r.From
+		}
+		if !r.To.IsZero() && end.After(r.To) {
+			end = r.To
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		for start.Before(end) {
+			segment := TimeSegment{Entry: entry, Group: l.group(entry, groupBy, start), Start: start, End: end}
+			if groupBy == TimeByDay {
+				if midnight := AddDays(StartOfDay(start), 1); midnight.Before(end) {
+					segment.End = midnight
+				}
+			}
+			segments = append(segments, segment)
+			start = segment.End
+		}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		a, b := segments[i], segments[j]
+		if a.Group != b.Group {
+			if aLabel, bLabel := l.Label(groupBy, a.Group), l.Label(groupBy, b.Group); aLabel != bLabel {
+				return aLabel < bLabel
+			}
+			return a.Group < b.Group
+		}
+		return a.Start.Before(b.Start)
+	})
+	return segments, nil
+}
+
+func (l *TimeLog) group(entry *TimeEntry, groupBy string, start time.Time) string {
+	switch groupBy {
+	case TimeByProject:
+		if task := l.Task(entry); task != nil {
+			return task.Project
+		}
+		return ""
+	case TimeByDay:
+		return start.Format("2006-01-02")
+	}
+	return entry.TaskID
+}
+
+// Label is what to show for a group: the task's title, the project or the
+// day.
+func (l *TimeLog) Label(groupBy, group string) string {
+	switch groupBy {
+	case TimeByTask:
+		if task, ok := l.tasks[group]; ok {
+			return task.Title
+		}
+		return "Deleted task"
+	case TimeByProject:
+		if group == "" {
+			return "No project"
+		}
+	}
+	return group
+}
+
+// Totals adds up segments by group, keeping their order.
+func (l *TimeLog) Totals(segments []TimeSegment, groupBy string) []TimeTotal {
+	totals := make([]TimeTotal, 0)
+	var durations []time.Duration
+	for _, segment := range segments {
+		if len(totals) == 0 || totals[len(totals)-1].Group != segment.Group {
+			totals = append(totals, TimeTotal{Group: segment.Group, Label: l.Label(groupBy, segment.Group)})
+			durations = append(durations, 0)
+		}
+		last := len(totals) - 1
+		durations[last] += segment.Duration()
+		totals[last].Entries++
+	}
+	for i := range totals {
+		totals[i].Seconds = int64(durations[i].Round(time.Second) / time.Second)
+	}
+	return totals
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type TaskStatus string
+
+const (
+	TodoTask       TaskStatus = "todo"
+	InProgressTask TaskStatus = "in_progress"
+	BlockedTask    TaskStatus = "blocked"
+	InReviewTask   TaskStatus = "in_review"
+	DoneTask       TaskStatus = "done"
+	CancelledTask  TaskStatus = "cancelled"
+)
+
+// Statuses lists every state in workflow order.
+var Statuses = []TaskStatus{TodoTask, InProgressTask, BlockedTask, InReviewTask, DoneTask, CancelledTask}
+
+func (s TaskStatus) IsValid() bool {
+	return s.order() >= 0
+}
+
+// IsClosed reports whether no more work is expected: done or cancelled.
+func (s TaskStatus) IsClosed() bool {
+	return s == DoneTask || s == CancelledTask
+}
+
+func (s TaskStatus) order() int {
+	for i, status := range Statuses {
+		if status == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// UpgradeStatus maps the active and completed statuses of older versions
+// onto workflow states and leaves everything else alone.
+func UpgradeStatus(status TaskStatus) TaskStatus {
+	switch status {
+	case "active":
+		return TodoTask
+	case "completed":
+		return DoneTask
+	}
+	return status
+}
+
+var ErrTransitionNotAllowed = errors.New("transition not allowed")
+
+// Workflow maps each state a project uses to the states a task may move to
+// from it. New tasks start in todo, so every workflow has it.
+type Workflow map[TaskStatus][]TaskStatus
+
+// DefaultWorkflow is used for projects without a workflow of their own:
+// todo → in_progress → blocked → in_review → done, with cancelled and
+// reopening reachable from anywhere it makes sense.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		TodoTask:       {InProgressTask, DoneTask, CancelledTask},
+		InProgressTask: {TodoTask, BlockedTask, InReviewTask, DoneTask, CancelledTask},
+		BlockedTask:    {InProgressTask, TodoTask, CancelledTask},
+		InReviewTask:   {InProgressTask, DoneTask, CancelledTask},
+		DoneTask:       {TodoTask, InProgressTask},
+		CancelledTask:  {TodoTask},
+	}
+}
+
+// Allows reports whether a task may move from one state to another. Staying
+// in the same state is always allowed.
+func (w Workflow) Allows(from, to TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range w[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// States lists the states the workflow uses, in workflow order.
+func (w Workflow) States() []TaskStatus {
+	seen := make(map[TaskStatus]bool)
+	for from, targets := range w {
+		seen[from] = true
+		for _, to := range targets {
+			seen[to] = true
+		}
+	}
+
+	states := make([]TaskStatus, 0, len(seen))
+	for status := range seen {
+		states = append(states, status)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].order() < states[j].order()
+	})
+	return states
+}
+
+func (w Workflow) Validate() error {
+	for _, status := range w.States() {
+		if !status.IsValid() {
+			return fmt.Errorf("unknown state %q", status)
+		}
+	}
+	if _, ok := w[TodoTask]; !ok {
+		return fmt.Errorf("workflow has no transitions out of %s", TodoTask)
+	}
+	for _, status := range w.States() {
+		if !status.IsClosed() && len(w[status]) == 0 {
+			return fmt.Errorf("tasks in %s cannot move anywhere", status)
+		}
+	}
+	return nil
+}
+
+// Workflows holds a workflow per project. The entry for "" applies to
+// every project without one; without it DefaultWorkflow does.
+type Workflows map[string]Workflow
+
+func (ws Workflows) For(project string) Workflow {
+	if w, ok := ws[project]; ok {
+		return w
+	}
+	if w, ok := ws[""]; ok {
+		return w
+	}
+	return DefaultWorkflow()
+}
+
+func (ws Workflows) Validate() error {
+	for project, w := range ws {
+		if err := w.Validate(); err != nil {
+			if project == "" {
+				return fmt.Errorf("default workflow: %w", err)
+			}
+			return fmt.Errorf("workflow of project %q: %w", project, err)
+		}
+	}
+	return nil
+}
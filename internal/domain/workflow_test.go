@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultWorkflowTransitions(t *testing.T) {
+	w := DefaultWorkflow()
+	if err := w.Validate(); err != nil {
+		t.Fatalf("DefaultWorkflow is invalid: %v", err)
+	}
+
+	tests := []struct {
+		from, to TaskStatus
+		want     bool
+	}{
+		{TodoTask, InProgressTask, true},
+		{TodoTask, DoneTask, true},
+		{TodoTask, CancelledTask, true},
+		{TodoTask, InReviewTask, false},
+		{TodoTask, BlockedTask, false},
+		{InProgressTask, BlockedTask, true},
+		{InProgressTask, InReviewTask, true},
+		{BlockedTask, InProgressTask, true},
+		{BlockedTask, DoneTask, false},
+		{InReviewTask, DoneTask, true},
+		{InReviewTask, TodoTask, false},
+		{DoneTask, TodoTask, true},
+		{DoneTask, CancelledTask, false},
+		{CancelledTask, TodoTask, true},
+		{CancelledTask, InProgressTask, false},
+		// Staying put is always allowed.
+		{InReviewTask, InReviewTask, true},
+		{CancelledTask, CancelledTask, true},
+	}
+	for _, tt := range tests {
+		if got := w.Allows(tt.from, tt.to); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWorkflowStates(t *testing.T) {
+	w := Workflow{
+		DoneTask:     {TodoTask},
+		InReviewTask: {DoneTask},
+		TodoTask:     {InReviewTask},
+	}
+	if got := fmt.Sprint(w.States()); got != "[todo in_review done]" {
+		t.Errorf("States = %s, want them in workflow order", got)
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		w    Workflow
+		want string
+	}{
+		{"simple", Workflow{TodoTask: {DoneTask}, DoneTask: {TodoTask}}, ""},
+		{"closed states may be final", Workflow{TodoTask: {DoneTask, CancelledTask}}, ""},
+		{"unknown state", Workflow{TodoTask: {"waiting"}}, `unknown state "waiting"`},
+		{"no todo", Workflow{InProgressTask: {DoneTask}}, "no transitions out of todo"},
+		{"dead end", Workflow{TodoTask: {InReviewTask}, InReviewTask: {}}, "tasks in in_review cannot move anywhere"},
+		{"dead end by omission", Workflow{TodoTask: {BlockedTask}}, "tasks in blocked cannot move anywhere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.w.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowsFor(t *testing.T) {
+	simple := Workflow{TodoTask: {DoneTask}, DoneTask: {TodoTask}}
+	fallback := Workflow{TodoTask: {CancelledTask}}
+
+	ws := Workflows{"acme": simple}
+	if !ws.For("acme").Allows(TodoTask, DoneTask) || ws.For("acme").Allows(TodoTask, InProgressTask) {
+		t.Error("the project workflow is not used for its project")
+	}
+	if !ws.For("other").Allows(TodoTask, InProgressTask) {
+		t.Error("projects without a workflow do not get DefaultWorkflow")
+	}
+
+	ws[""] = fallback
+	if ws.For("other").Allows(TodoTask, InProgressTask) || !ws.For("").Allows(TodoTask, CancelledTask) {
+		t.Error("the workflow for \"\" does not apply to projects without one")
+	}
+
+	ws["broken"] = Workflow{InProgressTask: {DoneTask}}
+	if err := ws.Validate(); err == nil || !strings.Contains(err.Error(), `workflow of project "broken"`) {
+		t.Errorf("Validate = %v, want it to name the broken project", err)
+	}
+	delete(ws, "broken")
+	ws[""] = Workflow{InProgressTask: {DoneTask}}
+	if err := ws.Validate(); err == nil || !strings.HasPrefix(err.Error(), "default workflow:") {
+		t.Errorf("Validate = %v, want it to name the default workflow", err)
+	}
+}
+
+func TestUpgradeStatus(t *testing.T) {
+	tests := []struct {
+		status, want TaskStatus
+	}{
+		{"active", TodoTask},
+		{"completed", DoneTask},
+		{TodoTask, TodoTask},
+		{InReviewTask, InReviewTask},
+		{DoneTask, DoneTask},
+		{CancelledTask, CancelledTask},
+		{"", ""},
+		{"unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := UpgradeStatus(tt.status); got != tt.want {
+			t.Errorf("UpgradeStatus(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionKeepsTimesInStep(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	task := NewTaskAt("Write report", "", start)
+	at := func(hours int) Clock { return FixedClock{Time: start.Add(time.Duration(hours) * time.Hour)} }
+
+	task.Transition(at(1), InProgressTask)
+	if task.StartedAt == nil || !task.StartedAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("StartedAt = %v, want the time work started", task.StartedAt)
+	}
+
+	// Blocked and back keeps the original start.
+	task.Transition(at(2), BlockedTask)
+	task.Transition(at(3), InProgressTask)
+	if !task.StartedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("StartedAt = %v after a block, want the first start", task.StartedAt)
+	}
+
+	task.Transition(at(4), DoneTask)
+	if task.CompletedAt == nil || !task.CompletedAt.Equal(start.Add(4*time.Hour)) || !task.UpdatedAt.Equal(start.Add(4*time.Hour)) {
+		t.Fatalf("CompletedAt %v UpdatedAt %v, want both at the hour it was done", task.CompletedAt, task.UpdatedAt)
+	}
+
+	// Moving between closed states keeps the completion time.
+	task.Transition(at(5), CancelledTask)
+	if !task.CompletedAt.Equal(start.Add(4 * time.Hour)) {
+		t.Errorf("CompletedAt = %v after cancelling, want it kept", task.CompletedAt)
+	}
+
+	task.Transition(at(6), TodoTask)
+	if task.StartedAt != nil || task.CompletedAt != nil {
+		t.Errorf("reopened task has StartedAt %v CompletedAt %v, want neither", task.StartedAt, task.CompletedAt)
+	}
+}
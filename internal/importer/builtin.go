@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"bytes"
+
+	"todo-list/internal/interchange"
+)
+
+func init() {
+	Register(csvImporter{})
+	Register(icalImporter{})
+	Register(todoTxtImporter{})
+	Register(markdownImporter{})
+}
+
+// The formats this app also writes, so its own exports can be re-imported
+// through the same preview.
+
+type csvImporter struct{}
+
+func (csvImporter) Name() string        { return "csv" }
+func (csvImporter) Description() string { return "CSV with a header row" }
+
+func (csvImporter) Detect(filename string, data []byte) bool {
+	// Todoist's CSV is more specific and claims its files first.
+	return hasExtension(filename, ".csv") && !isTodoistCSV(data)
+}
+
+func (csvImporter) Read(data []byte) ([]interchange.Record, error) {
+	return interchange.ReadCSV(bytes.NewReader(data), interchange.CSVImportOptions{})
+}
+
+type icalImporter struct{}
+
+func (icalImporter) Name() string        { return "ical" }
+func (icalImporter) Description() string { return "iCalendar (.ics) tasks" }
+
+func (icalImporter) Detect(filename string, data []byte) bool {
+	return hasExtension(filename, ".ics") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("BEGIN:VCALENDAR"))
+}
+
+func (icalImporter) Read(data []byte) ([]interchange.Record, error) {
+	return interchange.ReadICalendar(bytes.NewReader(data))
+}
+
+type todoTxtImporter struct{}
+
+func (todoTxtImporter) Name() string        { return "todotxt" }
+func (todoTxtImporter) Description() string { return "todo.txt" }
+
+func (todoTxtImporter) Detect(filename string, data []byte) bool {
+	return hasExtension(filename, ".txt")
+}
+
+func (todoTxtImporter) Read(data []byte) ([]interchange.Record, error) {
+	return interchange.ReadTodoTxt(bytes.NewReader(data))
+}
+
+type markdownImporter struct{}
+
+func (markdownImporter) Name() string        { return "markdown" }
+func (markdownImporter) Description() string { return "Markdown checklist" }
+
+func (markdownImporter) Detect(filename string, data []byte) bool {
+	return hasExtension(filename, ".md", ".markdown")
+}
+
+func (markdownImporter) Read(data []byte) ([]interchange.Record, error) {
+	return interchange.ReadMarkdown(bytes.NewReader(data))
+}
@@ -0,0 +1,163 @@
+// Package importer reads task exports of other to-do apps. Each source format
+// is an Importer registered under a short name; the usecase layer turns the
+// decoded records into tasks.
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+type Importer interface {
+	// Name is the identifier used by the CLI and the app, e.g. "trello".
+	Name() string
+	Description() string
+	// Detect reports whether data, read from a file called filename, looks
+	// like this format.
+	Detect(filename string, data []byte) bool
+	Read(data []byte) ([]interchange.Record, error)
+}
+
+// IDKeeper is implemented by importers whose tasks keep the IDs of the app
+// they come from (see sourceID). Those tasks are recognised by ID alone when
+// imported again, as titles repeat across projects.
+type IDKeeper interface {
+	KeepsIDs() bool
+}
+
+var registry = make(map[string]Importer)
+
+// Register makes an importer available by name. It panics on duplicate
+// names, which can only happen through a programming error.
+func Register(importer Importer) {
+	if _, exists := registry[importer.Name()]; exists {
+		panic("importer: duplicate format " + importer.Name())
+	}
+	registry[importer.Name()] = importer
+}
+
+func Lookup(name string) (Importer, error) {
+	importer, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q", name)
+	}
+	return importer, nil
+}
+
+type Format struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func Formats() []Format {
+	formats := make([]Format, 0, len(registry))
+	for _, importer := range registry {
+		formats = append(formats, Format{Name: importer.Name(), Description: importer.Description()})
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		return formats[i].Name < formats[j].Name
+	})
+	return formats
+}
+
+// Detect picks the importer for data. Formats are tried in name order so the
+// result does not depend on registration order.
+func Detect(filename string, data []byte) (Importer, error) {
+	for _, format := range Formats() {
+		if importer := registry[format.Name]; importer.Detect(filename, data) {
+			return importer, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot tell the format of %s", filename)
+}
+
+func hasExtension(filename string, extensions ...string) bool {
+	lower := strings.ToLower(filename)
+	for _, extension := range extensions {
+		if strings.HasSuffix(lower, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRecord(line int, title string) interchange.Record {
+	return interchange.Record{Line: line, Task: domain.NewTask(strings.TrimSpace(title), "")}
+}
+
+// sourceID prefixes an ID from another app with the app, as in
+// "todoist:123", so it cannot collide with IDs of this app or another one.
+func sourceID(source, id string) string {
+	return source + ":" + id
+}
+
+func warn(record *interchange.Record, format string, args ...interface{}) {
+	record.Warnings = append(record.Warnings, fmt.Sprintf(format, args...))
+}
+
+// finish applies the checks every importer shares once a record is filled.
+func finish(record *interchange.Record) {
+	task := record.Task
+	if task.Title == "" {
+		record.Errors = append(record.Errors, "task has no title")
+	}
+	if task.UpdatedAt.Before(task.CreatedAt) {
+		task.UpdatedAt = task.CreatedAt
+	}
+	if task.Status.IsClosed() && task.CompletedAt == nil {
+		completedAt := task.UpdatedAt
+		task.CompletedAt = &completedAt
+	}
+}
+
+// parseTime tries layouts in order; date-only layouts are read in local
+// time, the others honour their own zone.
+func parseTime(value string, layouts ...string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// setDue sets the due date from value. A layout without a time of day
+// makes it an all-day due date.
+func setDue(task *domain.Task, value string, layouts ...string) bool {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		parsed, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		task.DueDate, task.DueAllDay = &parsed, !strings.Contains(layout, "15")
+		if task.DueAllDay {
+			*task.DueDate = domain.DateOnly(parsed)
+		}
+		return true
+	}
+	return false
+}
+
+// setStart sets the start date to the local date of t.
+func setStart(task *domain.Task, t time.Time) {
+	start := domain.DateOnly(t.In(time.Local))
+	task.StartDate = &start
+}
+
+// setTimes copies creation and modification times when the source has them.
+func setTimes(task *domain.Task, created, updated time.Time) {
+	if !created.IsZero() {
+		task.CreatedAt = created
+		task.UpdatedAt = created
+	}
+	if !updated.IsZero() {
+		task.UpdatedAt = updated
+	}
+}
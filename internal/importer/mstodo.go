@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+func init() {
+	Register(msTodoImporter{})
+}
+
+// msTodoImporter reads Microsoft To Do data as returned by the Microsoft
+// Graph todo API, which is what export tools for To Do produce: either a
+// single list's {"value": [tasks]} page or {"lists": [{displayName, tasks}]}.
+// Lists become projects and checklist items subtasks.
+type msTodoImporter struct{}
+
+func (msTodoImporter) Name() string        { return "mstodo" }
+func (msTodoImporter) Description() string { return "Microsoft To Do (Graph API JSON)" }
+func (msTodoImporter) KeepsIDs() bool      { return true }
+
+type msTodoDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type msTodoTask struct {
+	ID                   string          `json:"id"`
+	Title                string          `json:"title"`
+	Importance           string          `json:"importance"`
+	Status               string          `json:"status"`
+	Categories           []string        `json:"categories"`
+	CreatedDateTime      string          `json:"createdDateTime"`
+	LastModifiedDateTime string          `json:"lastModifiedDateTime"`
+	DueDateTime          *msTodoDateTime `json:"dueDateTime"`
+	StartDateTime        *msTodoDateTime `json:"startDateTime"`
+	Recurrence           json.RawMessage `json:"recurrence"`
+	Body                 *struct {
+		Content     string `json:"content"`
+		ContentType string `json:"contentType"`
+	} `json:"body"`
+	ChecklistItems []struct {
+		ID              string `json:"id"`
+		DisplayName     string `json:"displayName"`
+		IsChecked       bool   `json:"isChecked"`
+		CreatedDateTime string `json:"createdDateTime"`
+	} `json:"checklistItems"`
+}
+
+type msTodoList struct {
+	DisplayName string       `json:"displayName"`
+	Tasks       []msTodoTask `json:"tasks"`
+}
+
+type msTodoExport struct {
+	Lists []msTodoList `json:"lists"`
+	Value []msTodoTask `json:"value"`
+}
+
+func (msTodoImporter) Detect(filename string, data []byte) bool {
+	var export msTodoExport
+	if json.Unmarshal(bytes.TrimSpace(data), &export) != nil {
+		return false
+	}
+	if len(export.Lists) > 0 {
+		return export.Lists[0].DisplayName != ""
+	}
+	return len(export.Value) > 0 && export.Value[0].Importance != ""
+}
+
+// msTodoTime parses Graph's dateTimeTimeZone. The time zone is an IANA or
+// Windows name; only IANA names and UTC can be resolved without a lookup
+// table, anything else is read as local time.
+func msTodoTime(value *msTodoDateTime) (time.Time, bool) {
+	if value == nil || value.DateTime == "" {
+		return time.Time{}, false
+	}
+
+	loc := time.Local
+	if value.TimeZone == "UTC" || value.TimeZone == "" {
+		loc = time.UTC
+	} else if tz, err := time.LoadLocation(value.TimeZone); err == nil {
+		loc = tz
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05.9999999", "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.ParseInLocation(layout, value.DateTime, loc); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (msTodoImporter) Read(data []byte) ([]interchange.Record, error) {
+	var export msTodoExport
+	if err := json.Unmarshal(bytes.TrimSpace(data), &export); err != nil {
+		return nil, err
+	}
+	if len(export.Lists) == 0 {
+		export.Lists = []msTodoList{{Tasks: export.Value}}
+	}
+
+	records := make([]interchange.Record, 0)
+	item := 0
+	for _, list := range export.Lists {
+		for _, todo := range list.Tasks {
+			item++
+
+			record := newRecord(item, todo.Title)
+			task := record.Task
+			if todo.ID != "" {
+				task.ID = sourceID("mstodo", todo.ID)
+			}
+			task.Project = list.DisplayName
+			task.AddTags(domain.SystemClock, todo.Categories...)
+
+			switch strings.ToLower(todo.Importance) {
+			case "high":
+				task.Priority = domain.HighPriority
+			case "low":
+				task.Priority = domain.LowPriority
+			}
+			switch todo.Status {
+			case "inProgress":
+				task.Status = domain.InProgressTask
+			case "waitingOnOthers":
+				task.Status = domain.BlockedTask
+			case "completed":
+				task.Status = domain.DoneTask
+			}
+
+			if todo.Body != nil {
+				task.Description = strings.TrimSpace(todo.Body.Content)
+				if strings.EqualFold(todo.Body.ContentType, "html") {
+					warn(&record, "description is HTML and was imported as is")
+				}
+			}
+			// To Do only has due days; the time part is always midnight.
+			if due, ok := msTodoTime(todo.DueDateTime); ok {
+				date := domain.DateOnly(due)
+				task.DueDate, task.DueAllDay = &date, true
+			}
+			if start, ok := msTodoTime(todo.StartDateTime); ok {
+				date := domain.DateOnly(start)
+				task.StartDate = &date
+			}
+			if len(todo.Recurrence) > 0 && string(todo.Recurrence) != "null" {
+				warn(&record, "recurring tasks are not supported, only the next due date was kept")
+			}
+
+			created, _ := parseTime(todo.CreatedDateTime, time.RFC3339Nano)
+			modified, _ := parseTime(todo.LastModifiedDateTime, time.RFC3339Nano)
+			setTimes(task, created, modified)
+
+			finish(&record)
+			records = append(records, record)
+
+			for _, checklistItem := range todo.ChecklistItems {
+				sub := newRecord(item, checklistItem.DisplayName)
+				subtask := sub.Task
+				if checklistItem.ID != "" {
+					subtask.ID = sourceID("mstodo", checklistItem.ID)
+				}
+				subtask.ParentID = task.ID
+				subtask.Project = task.Project
+				if checklistItem.IsChecked {
+					subtask.Status = domain.DoneTask
+				}
+				subCreated, _ := parseTime(checklistItem.CreatedDateTime, time.RFC3339Nano)
+				setTimes(subtask, subCreated, time.Time{})
+				finish(&sub)
+				records = append(records, sub)
+			}
+		}
+	}
+
+	return records, nil
+}
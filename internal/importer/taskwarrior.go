@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+func init() {
+	Register(taskwarriorImporter{})
+}
+
+const taskwarriorDate = "20060102T150405Z"
+
+// taskwarriorImporter reads the output of `task export`. Deleted tasks and
+// recurrence templates are skipped; instances of recurring tasks are kept.
+type taskwarriorImporter struct{}
+
+func (taskwarriorImporter) Name() string        { return "taskwarrior" }
+func (taskwarriorImporter) Description() string { return "Taskwarrior `task export` JSON" }
+func (taskwarriorImporter) KeepsIDs() bool      { return true }
+
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Entry       string   `json:"entry"`
+	Modified    string   `json:"modified"`
+	End         string   `json:"end"`
+	Due         string   `json:"due"`
+	Start       string   `json:"start"`
+	Wait        string   `json:"wait"`
+	Scheduled   string   `json:"scheduled"`
+	Priority    string   `json:"priority"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Parent      string   `json:"parent"`
+	Annotations []struct {
+		Entry       string `json:"entry"`
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+func (taskwarriorImporter) Detect(filename string, data []byte) bool {
+	tasks, err := decodeTaskwarrior(data)
+	return err == nil && len(tasks) > 0 && tasks[0].UUID != "" && tasks[0].Entry != ""
+}
+
+// decodeTaskwarrior accepts both the JSON array of Taskwarrior 2.4+ and the
+// one-object-per-line output of older versions.
+func decodeTaskwarrior(data []byte) ([]taskwarriorTask, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	var tasks []taskwarriorTask
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		err := json.Unmarshal(trimmed, &tasks)
+		return tasks, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+		if text == "" {
+			continue
+		}
+		var task taskwarriorTask
+		if err := json.Unmarshal([]byte(text), &task); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, scanner.Err()
+}
+
+func (taskwarriorImporter) Read(data []byte) ([]interchange.Record, error) {
+	tasks, err := decodeTaskwarrior(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]interchange.Record, 0, len(tasks))
+	for i, tw := range tasks {
+		if tw.Status == "deleted" || tw.Status == "recurring" {
+			continue
+		}
+
+		record := newRecord(i+1, tw.Description)
+		task := record.Task
+		if tw.UUID != "" {
+			task.ID = sourceID("taskwarrior", tw.UUID)
+		}
+		task.Project = tw.Project
+		task.AddTags(domain.SystemClock, tw.Tags...)
+
+		switch tw.Priority {
+		case "H":
+			task.Priority = domain.HighPriority
+		case "L":
+			task.Priority = domain.LowPriority
+		case "", "M":
+		default:
+			warn(&record, "unknown priority %q", tw.Priority)
+		}
+
+		if tw.Status == "completed" {
+			task.Status = domain.DoneTask
+		}
+		// start is set while a task is being worked on.
+		if started, ok := parseTime(tw.Start, taskwarriorDate); ok {
+			task.StartedAt = &started
+			if task.Status == domain.TodoTask {
+				task.Status = domain.InProgressTask
+			}
+		}
+		if tw.Status == "waiting" {
+			task.AddTags(domain.SystemClock, "waiting")
+		}
+		if tw.Parent != "" {
+			warn(&record, "recurring task instance imported as a one-off task")
+		}
+
+		if tw.Due != "" {
+			if due, ok := parseTime(tw.Due, taskwarriorDate); ok {
+				task.DueDate = &due
+			} else {
+				warn(&record, "cannot parse due date %q", tw.Due)
+			}
+		}
+		// Waiting tasks are hidden until wait, scheduled ones are not ready
+		// before; both map to the start date.
+		for _, value := range []string{tw.Wait, tw.Scheduled} {
+			if start, ok := parseTime(value, taskwarriorDate); ok {
+				setStart(task, start)
+				break
+			}
+		}
+
+		// Annotations are Taskwarrior's notes.
+		notes := make([]string, 0, len(tw.Annotations))
+		for _, annotation := range tw.Annotations {
+			notes = append(notes, annotation.Description)
+		}
+		task.Description = strings.Join(notes, "\n")
+
+		created, _ := parseTime(tw.Entry, taskwarriorDate)
+		modified, _ := parseTime(tw.Modified, taskwarriorDate)
+		if modified.IsZero() {
+			modified, _ = parseTime(tw.End, taskwarriorDate)
+		}
+		setTimes(task, created, modified)
+
+		finish(&record)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,309 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+func init() {
+	Register(todoistCSVImporter{})
+	Register(todoistJSONImporter{})
+}
+
+var todoistDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"Jan 2 2006 15:04",
+	"Jan 2 2006",
+	"2 Jan 2006",
+	"01/02/2006",
+}
+
+// todoistPriority maps Todoist's p1 (most urgent) to p4 (no priority).
+// p4 is the default in Todoist, so it becomes our default rather than low.
+func todoistPriority(p int) domain.Priority {
+	switch p {
+	case 1:
+		return domain.HighPriority
+	case 3:
+		return domain.LowPriority
+	}
+	return domain.MediumPriority
+}
+
+// todoistLabels moves @labels out of the task content into tags.
+func todoistLabels(task *domain.Task) {
+	var title []string
+	for _, word := range strings.Fields(task.Title) {
+		if len(word) > 1 && word[0] == '@' {
+			task.AddTags(domain.SystemClock, word[1:])
+			continue
+		}
+		title = append(title, word)
+	}
+	task.Title = strings.Join(title, " ")
+}
+
+// todoistCSVImporter reads the per-project CSV files Todoist exports and
+// puts in its backups. Sections become projects and INDENT nests subtasks.
+type todoistCSVImporter struct{}
+
+func (todoistCSVImporter) Name() string        { return "todoist-csv" }
+func (todoistCSVImporter) Description() string { return "Todoist project CSV export or backup" }
+
+func (todoistCSVImporter) Detect(filename string, data []byte) bool {
+	return isTodoistCSV(data)
+}
+
+func isTodoistCSV(data []byte) bool {
+	firstLine, _, _ := bytes.Cut(bytes.TrimPrefix(data, []byte("\ufeff")), []byte("\n"))
+	header := strings.ToUpper(string(firstLine))
+	return strings.HasPrefix(header, "TYPE,CONTENT") && strings.Contains(header, "INDENT")
+}
+
+func (todoistCSVImporter) Read(data []byte) ([]interchange.Record, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	records := make([]interchange.Record, 0)
+	section := ""
+	// parents[n] is the last task seen at indent n+1.
+	var parents []string
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			records = append(records, interchange.Record{Line: line, Errors: []string{err.Error()}})
+			continue
+		}
+
+		switch strings.ToLower(field(row, "TYPE")) {
+		case "section":
+			section = field(row, "CONTENT")
+			parents = parents[:0]
+			continue
+		case "note":
+			// Comments attach to the task above them.
+			if len(records) > 0 && records[len(records)-1].Task != nil {
+				task := records[len(records)-1].Task
+				task.Description = strings.TrimSpace(task.Description + "\n\n" + field(row, "CONTENT"))
+			}
+			continue
+		case "task":
+		default:
+			continue
+		}
+
+		record := newRecord(line, field(row, "CONTENT"))
+		task := record.Task
+		task.Description = field(row, "DESCRIPTION")
+		task.Project = section
+		todoistLabels(task)
+
+		if value := field(row, "PRIORITY"); value != "" {
+			p, err := strconv.Atoi(value)
+			if err != nil {
+				warn(&record, "unknown priority %q", value)
+			}
+			task.Priority = todoistPriority(p)
+		}
+
+		if value := field(row, "DATE"); value != "" {
+			if !setDue(task, value, todoistDateLayouts...) {
+				warn(&record, "due date %q is not a plain date and was dropped", value)
+			}
+		}
+
+		indent, _ := strconv.Atoi(field(row, "INDENT"))
+		if indent < 1 {
+			indent = 1
+		}
+		if indent > len(parents)+1 {
+			indent = len(parents) + 1
+		}
+		parents = append(parents[:indent-1], task.ID)
+		if indent > 1 {
+			task.ParentID = parents[indent-2]
+		}
+
+		finish(&record)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// todoistJSONImporter reads Todoist API data: either the object returned by
+// the Sync API (items, projects, sections) or the REST API's task array.
+type todoistJSONImporter struct{}
+
+func (todoistJSONImporter) Name() string        { return "todoist-json" }
+func (todoistJSONImporter) Description() string { return "Todoist Sync or REST API JSON" }
+func (todoistJSONImporter) KeepsIDs() bool      { return true }
+
+type todoistItem struct {
+	ID          string   `json:"id"`
+	Content     string   `json:"content"`
+	Description string   `json:"description"`
+	Priority    int      `json:"priority"`
+	ProjectID   string   `json:"project_id"`
+	SectionID   string   `json:"section_id"`
+	ParentID    string   `json:"parent_id"`
+	Labels      []string `json:"labels"`
+	Checked     bool     `json:"checked"`
+	IsCompleted bool     `json:"is_completed"`
+	IsDeleted   bool     `json:"is_deleted"`
+	AddedAt     string   `json:"added_at"`
+	CreatedAt   string   `json:"created_at"`
+	CompletedAt string   `json:"completed_at"`
+	Due         *struct {
+		Date        string `json:"date"`
+		Datetime    string `json:"datetime"`
+		IsRecurring bool   `json:"is_recurring"`
+	} `json:"due"`
+}
+
+type todoistNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type todoistSync struct {
+	Items    []todoistItem  `json:"items"`
+	Projects []todoistNamed `json:"projects"`
+	Sections []todoistNamed `json:"sections"`
+}
+
+func (todoistJSONImporter) Detect(filename string, data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if !hasExtension(filename, ".json") {
+		return false
+	}
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var probe map[string]json.RawMessage
+		if json.Unmarshal(trimmed, &probe) != nil {
+			return false
+		}
+		_, hasItems := probe["items"]
+		_, hasProjects := probe["projects"]
+		return hasItems && hasProjects
+	}
+
+	var probe []map[string]json.RawMessage
+	if json.Unmarshal(trimmed, &probe) != nil || len(probe) == 0 {
+		return false
+	}
+	_, hasContent := probe[0]["content"]
+	_, hasProject := probe[0]["project_id"]
+	return hasContent && hasProject
+}
+
+func (todoistJSONImporter) Read(data []byte) ([]interchange.Record, error) {
+	var sync todoistSync
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &sync.Items); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(trimmed, &sync); err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]string, len(sync.Projects))
+	for _, project := range sync.Projects {
+		projects[project.ID] = project.Name
+	}
+	sections := make(map[string]string, len(sync.Sections))
+	for _, section := range sync.Sections {
+		sections[section.ID] = section.Name
+	}
+
+	records := make([]interchange.Record, 0, len(sync.Items))
+	for i, item := range sync.Items {
+		if item.IsDeleted {
+			continue
+		}
+
+		record := newRecord(i+1, item.Content)
+		task := record.Task
+		if item.ID != "" {
+			task.ID = sourceID("todoist", item.ID)
+		}
+		task.Description = item.Description
+		if item.ParentID != "" {
+			task.ParentID = sourceID("todoist", item.ParentID)
+		}
+		task.Project = projects[item.ProjectID]
+		if section := sections[item.SectionID]; section != "" {
+			task.AddTags(domain.SystemClock, section)
+		}
+		task.AddTags(domain.SystemClock, item.Labels...)
+		todoistLabels(task)
+
+		// The API numbers priorities the other way round: 4 is p1.
+		if item.Priority >= 1 && item.Priority <= 4 {
+			task.Priority = todoistPriority(5 - item.Priority)
+		}
+
+		if item.Due != nil {
+			value := item.Due.Datetime
+			if value == "" {
+				value = item.Due.Date
+			}
+			if !setDue(task, value, todoistDateLayouts...) && value != "" {
+				warn(&record, "cannot parse due date %q", value)
+			}
+			if item.Due.IsRecurring {
+				warn(&record, "recurring tasks are not supported, only the next due date was kept")
+			}
+		}
+
+		created := item.AddedAt
+		if created == "" {
+			created = item.CreatedAt
+		}
+		createdAt, _ := parseTime(created, time.RFC3339Nano)
+		completedAt, _ := parseTime(item.CompletedAt, time.RFC3339Nano)
+		setTimes(task, createdAt, completedAt)
+		if item.Checked || item.IsCompleted {
+			task.Status = domain.DoneTask
+		}
+
+		finish(&record)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,197 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+func init() {
+	Register(trelloImporter{})
+}
+
+// trelloImporter reads a board exported via "Print and export > JSON". Lists
+// become projects, cards tasks and checklist items subtasks of their card.
+// Archived lists and cards are left out.
+type trelloImporter struct{}
+
+func (trelloImporter) Name() string        { return "trello" }
+func (trelloImporter) Description() string { return "Trello board JSON export" }
+func (trelloImporter) KeepsIDs() bool      { return true }
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID               string `json:"id"`
+		Name             string `json:"name"`
+		Desc             string `json:"desc"`
+		IDList           string `json:"idList"`
+		Closed           bool   `json:"closed"`
+		Due              string `json:"due"`
+		DueComplete      bool   `json:"dueComplete"`
+		DateLastActivity string `json:"dateLastActivity"`
+		Labels           []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Checklists []struct {
+		ID         string `json:"id"`
+		IDCard     string `json:"idCard"`
+		Name       string `json:"name"`
+		CheckItems []struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			State string `json:"state"`
+			Due   string `json:"due"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+func (trelloImporter) Detect(filename string, data []byte) bool {
+	var probe map[string]json.RawMessage
+	if json.Unmarshal(bytes.TrimSpace(data), &probe) != nil {
+		return false
+	}
+	_, hasCards := probe["cards"]
+	_, hasLists := probe["lists"]
+	return hasCards && hasLists
+}
+
+// trelloPriority reads priority from labels, the usual Trello workaround.
+func trelloPriority(label string) (domain.Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "high", "high priority", "urgent", "important", "p1":
+		return domain.HighPriority, true
+	case "medium", "medium priority", "p2":
+		return domain.MediumPriority, true
+	case "low", "low priority", "p3":
+		return domain.LowPriority, true
+	}
+	return "", false
+}
+
+// trelloCreatedAt decodes the creation time embedded in Trello's object IDs,
+// which start with a hex Unix timestamp like MongoDB ObjectIDs.
+func trelloCreatedAt(id string) time.Time {
+	if len(id) < 8 {
+		return time.Time{}
+	}
+	var seconds int64
+	for _, c := range id[:8] {
+		var digit int64
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int64(c - '0')
+		case c >= 'a' && c <= 'f':
+			digit = int64(c-'a') + 10
+		default:
+			return time.Time{}
+		}
+		seconds = seconds*16 + digit
+	}
+	return time.Unix(seconds, 0)
+}
+
+func (trelloImporter) Read(data []byte) ([]interchange.Record, error) {
+	var board trelloBoard
+	if err := json.Unmarshal(bytes.TrimSpace(data), &board); err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	closedLists := make(map[string]bool)
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+
+	checklists := make(map[string][]int)
+	for i, checklist := range board.Checklists {
+		checklists[checklist.IDCard] = append(checklists[checklist.IDCard], i)
+	}
+
+	records := make([]interchange.Record, 0, len(board.Cards))
+	item := 0
+	for _, card := range board.Cards {
+		item++
+		if card.Closed || closedLists[card.IDList] {
+			continue
+		}
+
+		record := newRecord(item, card.Name)
+		task := record.Task
+		if card.ID != "" {
+			task.ID = sourceID("trello", card.ID)
+		}
+		task.Description = card.Desc
+		task.Project = lists[card.IDList]
+
+		for _, label := range card.Labels {
+			if priority, ok := trelloPriority(label.Name); ok {
+				task.Priority = priority
+				continue
+			}
+			name := label.Name
+			if name == "" {
+				// Unnamed labels are only a colour.
+				name = label.Color
+			}
+			task.AddTags(domain.SystemClock, name)
+		}
+
+		if card.Due != "" {
+			if due, ok := parseTime(card.Due, time.RFC3339Nano); ok {
+				task.DueDate = &due
+			} else {
+				warn(&record, "cannot parse due date %q", card.Due)
+			}
+		}
+		if card.DueComplete {
+			task.Status = domain.DoneTask
+		}
+
+		lastActivity, _ := parseTime(card.DateLastActivity, time.RFC3339Nano)
+		setTimes(task, trelloCreatedAt(card.ID), lastActivity)
+		finish(&record)
+		records = append(records, record)
+
+		for _, index := range checklists[card.ID] {
+			checklist := board.Checklists[index]
+			for _, checkItem := range checklist.CheckItems {
+				sub := newRecord(item, checkItem.Name)
+				subtask := sub.Task
+				if checkItem.ID != "" {
+					subtask.ID = sourceID("trello", checkItem.ID)
+				}
+				subtask.ParentID = task.ID
+				subtask.Project = task.Project
+				if len(checklists[card.ID]) > 1 && checklist.Name != "" {
+					subtask.AddTags(domain.SystemClock, checklist.Name)
+				}
+				if checkItem.State == "complete" {
+					subtask.Status = domain.DoneTask
+				}
+				if checkItem.Due != "" {
+					if due, ok := parseTime(checkItem.Due, time.RFC3339Nano); ok {
+						subtask.DueDate = &due
+					}
+				}
+				setTimes(subtask, trelloCreatedAt(checkItem.ID), time.Time{})
+				finish(&sub)
+				records = append(records, sub)
+			}
+		}
+	}
+
+	return records, nil
+}
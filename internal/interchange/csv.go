@@ -0,0 +1,356 @@
+package interchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+const (
+	ColumnID          = "id"
+	ColumnTitle       = "title"
+	ColumnDescription = "description"
+	ColumnStatus      = "status"
+	ColumnPriority    = "priority"
+	ColumnDueDate     = "due_date"
+	ColumnStartDate   = "start_date"
+	ColumnProject     = "project"
+	ColumnTags        = "tags"
+	ColumnCreatedAt   = "created_at"
+	ColumnUpdatedAt   = "updated_at"
+	ColumnParentID    = "parent_id"
+	ColumnStartedAt   = "started_at"
+	ColumnCompletedAt = "completed_at"
+	ColumnBlockedBy   = "blocked_by"
+	ColumnEstimate    = "estimate"
+)
+
+var DefaultCSVColumns = []string{
+	ColumnTitle,
+	ColumnDescription,
+	ColumnStatus,
+	ColumnPriority,
+	ColumnDueDate,
+	ColumnStartDate,
+	ColumnProject,
+	ColumnTags,
+	ColumnCreatedAt,
+}
+
+const DefaultCSVDateFormat = "2006-01-02 15:04"
+
+// csvDate is used for all-day due dates and start dates, which have no
+// time to format.
+const csvDate = "2006-01-02"
+
+// csvHeaderAliases maps common spreadsheet headers onto our columns.
+var csvHeaderAliases = map[string]string{
+	"name":     ColumnTitle,
+	"task":     ColumnTitle,
+	"summary":  ColumnTitle,
+	"notes":    ColumnDescription,
+	"details":  ColumnDescription,
+	"state":    ColumnStatus,
+	"done":     ColumnStatus,
+	"due":      ColumnDueDate,
+	"deadline": ColumnDueDate,
+	"start":    ColumnStartDate,
+	"defer":    ColumnStartDate,
+	"list":     ColumnProject,
+	"labels":   ColumnTags,
+	"created":  ColumnCreatedAt,
+	"updated":  ColumnUpdatedAt,
+	"modified": ColumnUpdatedAt,
+	"started":  ColumnStartedAt,
+	"finished": ColumnCompletedAt,
+}
+
+type CSVOptions struct {
+	Columns    []string `json:"columns,omitempty"`
+	DateFormat string   `json:"date_format,omitempty"`
+}
+
+type CSVImportOptions struct {
+	// HeaderMap maps source headers to column names and takes precedence
+	// over the built-in aliases.
+	HeaderMap  map[string]string `json:"header_map,omitempty"`
+	DateFormat string            `json:"date_format,omitempty"`
+}
+
+func isCSVColumn(name string) bool {
+	switch name {
+	case ColumnID, ColumnTitle, ColumnDescription, ColumnStatus, ColumnPriority,
+		ColumnDueDate, ColumnStartDate, ColumnProject, ColumnTags, ColumnCreatedAt, ColumnUpdatedAt, ColumnParentID,
+		ColumnStartedAt, ColumnCompletedAt, ColumnBlockedBy, ColumnEstimate:
+		return true
+	}
+	return false
+}
+
+func normalizeHeader(header string) string {
+	header = strings.TrimPrefix(header, "\ufeff")
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+func WriteCSV(w io.Writer, tasks []*domain.Task, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	for _, column := range columns {
+		if !isCSVColumn(column) {
+			return fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	dateFormat := opts.DateFormat
+	if dateFormat == "" {
+		dateFormat = DefaultCSVDateFormat
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = csvValue(task, column, dateFormat)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvValue(task *domain.Task, column, dateFormat string) string {
+	switch column {
+	case ColumnID:
+		return task.ID
+	case ColumnTitle:
+		return task.Title
+	case ColumnDescription:
+		return task.Description
+	case ColumnStatus:
+		return string(task.Status)
+	case ColumnPriority:
+		return string(task.Priority)
+	case ColumnDueDate:
+		if task.DueDate == nil {
+			return ""
+		}
+		if task.DueAllDay {
+			return task.DueDate.UTC().Format(csvDate)
+		}
+		return task.DueDate.Format(dateFormat)
+	case ColumnStartDate:
+		if task.StartDate == nil {
+			return ""
+		}
+		return task.StartDate.UTC().Format(csvDate)
+	case ColumnProject:
+		return task.Project
+	case ColumnTags:
+		return strings.Join(task.Tags, ", ")
+	case ColumnCreatedAt:
+		return task.CreatedAt.Format(dateFormat)
+	case ColumnUpdatedAt:
+		return task.UpdatedAt.Format(dateFormat)
+	case ColumnParentID:
+		return task.ParentID
+	case ColumnBlockedBy:
+		return strings.Join(task.BlockedBy, ", ")
+	case ColumnEstimate:
+		if task.Estimate == nil {
+			return ""
+		}
+		return task.Estimate.String()
+	case ColumnStartedAt:
+		return formatOptionalTime(task.StartedAt, dateFormat)
+	case ColumnCompletedAt:
+		return formatOptionalTime(task.CompletedAt, dateFormat)
+	}
+	return ""
+}
+
+// ReadCSV decodes a CSV with a header row. Rows that fail validation are
+// returned with their errors instead of aborting the whole file.
+func ReadCSV(r io.Reader, opts CSVImportOptions) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	headerMap := make(map[string]string, len(opts.HeaderMap))
+	for source, column := range opts.HeaderMap {
+		headerMap[normalizeHeader(source)] = column
+	}
+
+	columns := make([]string, len(header))
+	hasTitle := false
+	for i, name := range header {
+		normalized := normalizeHeader(name)
+		column, mapped := headerMap[normalized]
+		if !mapped {
+			column = normalized
+			if alias, ok := csvHeaderAliases[normalized]; ok {
+				column = alias
+			}
+		}
+		if isCSVColumn(column) {
+			columns[i] = column
+			hasTitle = hasTitle || column == ColumnTitle
+		}
+	}
+	if !hasTitle {
+		return nil, errors.New("csv header has no title column")
+	}
+
+	records := make([]Record, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			records = append(records, Record{Line: line, Errors: []string{err.Error()}})
+			continue
+		}
+
+		records = append(records, csvRecord(line, columns, row, opts.DateFormat))
+	}
+
+	return records, nil
+}
+
+func csvRecord(line int, columns, row []string, dateFormat string) Record {
+	record := Record{Line: line, Task: domain.NewTask("", "")}
+	task := record.Task
+
+	for i, value := range row {
+		if i >= len(columns) || columns[i] == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch columns[i] {
+		case ColumnID:
+			if value != "" {
+				task.ID = value
+			}
+		case ColumnTitle:
+			task.Title = value
+		case ColumnDescription:
+			task.Description = value
+		case ColumnStatus:
+			status, ok := parseStatus(value)
+			if !ok {
+				record.addError(fmt.Sprintf("unknown status %q", value))
+			}
+			task.Status = status
+		case ColumnPriority:
+			priority, ok := parsePriority(value)
+			if !ok {
+				record.addError(fmt.Sprintf("unknown priority %q", value))
+			}
+			task.Priority = priority
+		case ColumnDueDate:
+			if value == "" {
+				continue
+			}
+			due, allDay, ok := parseDateOrTime(value, dateFormat, time.Local)
+			if !ok {
+				record.addError(fmt.Sprintf("cannot parse due date %q", value))
+				continue
+			}
+			task.DueDate, task.DueAllDay = &due, allDay
+			if allDay {
+				*task.DueDate = domain.DateOnly(due)
+			}
+		case ColumnStartDate:
+			if value == "" {
+				continue
+			}
+			start, ok := parseDate(value, dateFormat, time.Local)
+			if !ok {
+				record.addError(fmt.Sprintf("cannot parse start date %q", value))
+				continue
+			}
+			start = domain.DateOnly(start)
+			task.StartDate = &start
+		case ColumnProject:
+			task.Project = value
+		case ColumnParentID:
+			task.ParentID = value
+		case ColumnBlockedBy:
+			task.BlockedBy = splitIDs(value)
+		case ColumnEstimate:
+			estimate, err := domain.ParseEstimate(value)
+			if err != nil {
+				record.addError(err.Error())
+				continue
+			}
+			task.Estimate = estimate
+		case ColumnTags:
+			addTags(task, strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' })...)
+		case ColumnCreatedAt, ColumnUpdatedAt, ColumnStartedAt, ColumnCompletedAt:
+			if value == "" {
+				continue
+			}
+			parsed, ok := parseDate(value, dateFormat, time.Local)
+			if !ok {
+				record.addError(fmt.Sprintf("cannot parse %s %q", columns[i], value))
+				continue
+			}
+			switch columns[i] {
+			case ColumnCreatedAt:
+				task.CreatedAt = parsed
+			case ColumnUpdatedAt:
+				task.UpdatedAt = parsed
+			case ColumnStartedAt:
+				task.StartedAt = &parsed
+			case ColumnCompletedAt:
+				task.CompletedAt = &parsed
+			}
+		}
+	}
+
+	if task.Title == "" {
+		record.addError("title is required")
+	}
+	if task.UpdatedAt.Before(task.CreatedAt) {
+		task.UpdatedAt = task.CreatedAt
+	}
+	if !task.Status.IsClosed() {
+		task.CompletedAt = nil
+	}
+	closeTask(task)
+
+	return record
+}
+
+func formatOptionalTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
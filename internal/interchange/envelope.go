@@ -0,0 +1,152 @@
+package interchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+// EnvelopeVersion is the version of the JSON task format this build writes.
+// Bump it together with a new entry in upgrades whenever a change to
+// domain.Task needs old files to be rewritten on load.
+const EnvelopeVersion = 2
+
+// Envelope is the JSON document used for tasks.json and for backups.
+type Envelope struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Tasks      []*domain.Task `json:"tasks"`
+}
+
+// rawEnvelope holds tasks as plain JSON objects so upgrades can rename,
+// convert or drop fields that domain.Task no longer has.
+type rawEnvelope struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Tasks      []map[string]interface{} `json:"tasks"`
+}
+
+// upgrades[n] turns a version n document into version n+1.
+var upgrades = []func(doc *rawEnvelope) error{
+	// 0 -> 1: tasks.json used to be a bare array of tasks; the envelope
+	// only wraps it.
+	func(doc *rawEnvelope) error { return nil },
+	// 1 -> 2: active and completed became workflow states, and completed
+	// tasks record when they were completed.
+	func(doc *rawEnvelope) error {
+		for _, task := range doc.Tasks {
+			if task == nil {
+				continue
+			}
+			status, _ := task["status"].(string)
+			upgraded := domain.UpgradeStatus(domain.TaskStatus(status))
+			task["status"] = string(upgraded)
+			if upgraded == domain.DoneTask && task["completed_at"] == nil {
+				task["completed_at"] = task["updated_at"]
+			}
+		}
+		return nil
+	},
+}
+
+// MarshalEnvelope encodes tasks in the current version.
+func MarshalEnvelope(tasks []*domain.Task, exportedAt time.Time) ([]byte, error) {
+	if tasks == nil {
+		tasks = []*domain.Task{}
+	}
+
+	return json.MarshalIndent(Envelope{
+		Version:    EnvelopeVersion,
+		ExportedAt: exportedAt.UTC(),
+		Tasks:      tasks,
+	}, "", "  ")
+}
+
+// UnmarshalEnvelope decodes a document of any known version, upgrading it
+// to the current one. Files written by a newer build are rejected rather
+// than read with fields silently missing.
+func UnmarshalEnvelope(data []byte) (*Envelope, error) {
+	data = bytes.TrimSpace(data)
+
+	var doc rawEnvelope
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &doc.Tasks); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	} else if doc.Version < 1 {
+		return nil, fmt.Errorf("missing or invalid version")
+	}
+
+	if doc.Version > EnvelopeVersion {
+		return nil, fmt.Errorf("version %d was written by a newer version of the app (this one reads up to %d)", doc.Version, EnvelopeVersion)
+	}
+
+	for doc.Version < EnvelopeVersion {
+		if err := upgrades[doc.Version](&doc); err != nil {
+			return nil, fmt.Errorf("upgrading from version %d: %w", doc.Version, err)
+		}
+		doc.Version++
+	}
+
+	tasksJSON, err := json.Marshal(doc.Tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &Envelope{Version: doc.Version, ExportedAt: doc.ExportedAt}
+	if err := json.Unmarshal(tasksJSON, &envelope.Tasks); err != nil {
+		return nil, err
+	}
+	if envelope.Tasks == nil {
+		envelope.Tasks = []*domain.Task{}
+	}
+
+	return envelope, nil
+}
+
+// WriteJSON writes a full-fidelity backup of tasks.
+func WriteJSON(w io.Writer, tasks []*domain.Task) error {
+	data, err := MarshalEnvelope(tasks, time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadJSON reads a backup into records, one per task, so it can go through
+// the same import preview as every other format. Tasks keep their IDs and
+// timestamps.
+func ReadJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := UnmarshalEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(envelope.Tasks))
+	for i, task := range envelope.Tasks {
+		record := Record{Line: i + 1, Task: task}
+		switch {
+		case task == nil:
+			record.Task = nil
+			record.addError("task is null")
+		case task.ID == "":
+			record.addError("task has no id")
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,523 @@
+package interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+const (
+	icalUIDSuffix     = "@todo-list"
+	icalDateTimeUTC   = "20060102T150405Z"
+	icalDateTimeLocal = "20060102T150405"
+	icalDate          = "20060102"
+	icalProjectProp   = "X-TODOLIST-PROJECT"
+	// icalStateProp keeps the workflow state that STATUS can only
+	// approximate.
+	icalStateProp = "X-TODOLIST-STATE"
+	// icalRankProp keeps the manual order of tasks.
+	icalRankProp = "X-TODOLIST-RANK"
+	// icalEstimateProp keeps the estimate, a duration or story points.
+	icalEstimateProp = "X-TODOLIST-ESTIMATE"
+
+	icalParentProp    = "RELATED-TO;RELTYPE=PARENT"
+	icalDependsOnProp = "RELATED-TO;RELTYPE=DEPENDS-ON"
+)
+
+// ICalStatus maps a workflow state onto the VTODO STATUS values.
+func ICalStatus(status domain.TaskStatus) string {
+	switch status {
+	case domain.InProgressTask, domain.InReviewTask:
+		return "IN-PROCESS"
+	case domain.DoneTask:
+		return "COMPLETED"
+	case domain.CancelledTask:
+		return "CANCELLED"
+	}
+	return "NEEDS-ACTION"
+}
+
+// ICalPriority maps our priorities onto the RFC 5545 1 (highest) to 9
+// (lowest) scale.
+func ICalPriority(priority domain.Priority) int {
+	switch priority {
+	case domain.HighPriority:
+		return 1
+	case domain.LowPriority:
+		return 9
+	}
+	return 5
+}
+
+func priorityFromICal(value int) domain.Priority {
+	switch {
+	case value >= 1 && value <= 4:
+		return domain.HighPriority
+	case value >= 6:
+		return domain.LowPriority
+	}
+	return domain.MediumPriority
+}
+
+func ICalUID(task *domain.Task) string {
+	return task.ID + icalUIDSuffix
+}
+
+// WriteICalendar writes tasks as VTODO components of a single VCALENDAR.
+// Tasks carry no recurrence rule yet, so no RRULE is emitted.
+func WriteICalendar(w io.Writer, tasks []*domain.Task, name string) error {
+	enc := &icalWriter{w: bufio.NewWriter(w)}
+
+	enc.line("BEGIN", "VCALENDAR")
+	enc.line("VERSION", "2.0")
+	enc.line("PRODID", "-//todo-list//Tasks//EN")
+	enc.line("CALSCALE", "GREGORIAN")
+	if name != "" {
+		enc.line("X-WR-CALNAME", escapeICalText(name))
+	}
+	for _, task := range tasks {
+		writeVTODO(enc, task)
+	}
+	enc.line("END", "VCALENDAR")
+
+	if enc.err != nil {
+		return enc.err
+	}
+	return enc.w.Flush()
+}
+
+func writeVTODO(enc *icalWriter, task *domain.Task) {
+	enc.line("BEGIN", "VTODO")
+	enc.line("UID", ICalUID(task))
+	enc.line("DTSTAMP", task.UpdatedAt.UTC().Format(icalDateTimeUTC))
+	enc.line("SUMMARY", escapeICalText(task.Title))
+	if task.Description != "" {
+		enc.line("DESCRIPTION", escapeICalText(task.Description))
+	}
+	// DTSTART and DUE must share a value type, so a start date goes out as
+	// local midnight next to a timed due date.
+	if task.StartDate != nil {
+		if task.DueDate == nil || task.DueAllDay {
+			enc.line("DTSTART;VALUE=DATE", task.StartDate.UTC().Format(icalDate))
+		} else {
+			enc.line("DTSTART", domain.DateIn(*task.StartDate, time.Local).UTC().Format(icalDateTimeUTC))
+		}
+	}
+	if task.DueDate != nil {
+		if task.DueAllDay {
+			enc.line("DUE;VALUE=DATE", task.DueDate.UTC().Format(icalDate))
+		} else {
+			enc.line("DUE", task.DueDate.UTC().Format(icalDateTimeUTC))
+		}
+	}
+	enc.line("PRIORITY", strconv.Itoa(ICalPriority(task.Priority)))
+	enc.line("STATUS", ICalStatus(task.Status))
+	if task.Status != domain.TodoTask {
+		enc.line(icalStateProp, string(task.Status))
+	}
+	if task.Status == domain.DoneTask {
+		completedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+		enc.line("COMPLETED", completedAt.UTC().Format(icalDateTimeUTC))
+		enc.line("PERCENT-COMPLETE", "100")
+	}
+	if len(task.Tags) > 0 {
+		escaped := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			escaped[i] = escapeICalText(tag)
+		}
+		enc.line("CATEGORIES", strings.Join(escaped, ","))
+	}
+	if task.Project != "" {
+		enc.line(icalProjectProp, escapeICalText(task.Project))
+	}
+	if task.ParentID != "" {
+		enc.line(icalParentProp, task.ParentID+icalUIDSuffix)
+	}
+	// RFC 9253 relates a task to the ones it depends on.
+	for _, blocker := range task.BlockedBy {
+		enc.line(icalDependsOnProp, blocker+icalUIDSuffix)
+	}
+	if task.Rank != "" {
+		enc.line(icalRankProp, task.Rank)
+	}
+	if task.Estimate != nil {
+		enc.line(icalEstimateProp, task.Estimate.String())
+	}
+	enc.line("CREATED", task.CreatedAt.UTC().Format(icalDateTimeUTC))
+	enc.line("LAST-MODIFIED", task.UpdatedAt.UTC().Format(icalDateTimeUTC))
+	enc.line("END", "VTODO")
+}
+
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line folded at 75 octets as RFC 5545 requires,
+// without splitting UTF-8 sequences.
+func (e *icalWriter) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, e.err = e.w.WriteString(b.String())
+}
+
+func escapeICalText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+func unescapeICalText(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type icalProperty struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// readICalLines unfolds content lines and splits them into properties.
+func readICalLines(r io.Reader) ([]icalProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var props []icalProperty
+	var current strings.Builder
+	start, lineNo := 0, 0
+
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		if prop, ok := parseICalProperty(current.String()); ok {
+			prop.line = start
+			props = append(props, prop)
+		}
+		current.Reset()
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if lineNo == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			current.WriteString(text[1:])
+			continue
+		}
+
+		flush()
+		start = lineNo
+		current.WriteString(text)
+	}
+	flush()
+
+	return props, scanner.Err()
+}
+
+func parseICalProperty(content string) (icalProperty, bool) {
+	// The value starts at the first colon outside a quoted parameter.
+	inQuotes := false
+	split := -1
+	for i, r := range content {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		return icalProperty{}, false
+	}
+
+	parts := strings.Split(content[:split], ";")
+	prop := icalProperty{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  content[split+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, true
+}
+
+func isICalDate(prop icalProperty) bool {
+	return prop.params["VALUE"] == "DATE" || len(strings.TrimSpace(prop.value)) == len(icalDate)
+}
+
+func parseICalTime(prop icalProperty) (time.Time, error) {
+	value := strings.TrimSpace(prop.value)
+
+	if isICalDate(prop) {
+		return time.ParseInLocation(icalDate, value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalDateTimeUTC, value)
+	}
+
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	return time.ParseInLocation(icalDateTimeLocal, value, loc)
+}
+
+// ReadICalendar decodes every VTODO in an iCalendar stream. Other
+// components are skipped. UIDs we issued map back to task IDs, foreign UIDs
+// are used as IDs verbatim.
+func ReadICalendar(r io.Reader) ([]Record, error) {
+	props, err := readICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0)
+	var record *Record
+	var state domain.TaskStatus
+	depth := 0
+
+	for _, prop := range props {
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VTODO"):
+			record = &Record{Line: prop.line, Task: domain.NewTask("", ""), properties: make(map[string]bool)}
+			state = ""
+			depth = 0
+			continue
+		case record == nil:
+			continue
+		case prop.name == "BEGIN":
+			// Nested components such as VALARM.
+			depth++
+			continue
+		case prop.name == "END" && depth > 0:
+			depth--
+			continue
+		case depth > 0:
+			continue
+		case prop.name == "END" && strings.EqualFold(prop.value, "VTODO"):
+			if record.Task.Title == "" {
+				record.addError("SUMMARY is required")
+			}
+			if record.Task.UpdatedAt.Before(record.Task.CreatedAt) {
+				record.Task.UpdatedAt = record.Task.CreatedAt
+			}
+			// Clients that only know STATUS keep our state property when
+			// they change it, so the state only counts while they agree.
+			if state.IsValid() && ICalStatus(state) == ICalStatus(record.Task.Status) {
+				record.Task.Status = state
+			}
+			if !record.Task.Status.IsClosed() {
+				record.Task.CompletedAt = nil
+			}
+			closeTask(record.Task)
+			records = append(records, *record)
+			record = nil
+			continue
+		case prop.name == icalStateProp:
+			state = domain.TaskStatus(strings.TrimSpace(prop.value))
+			record.properties[prop.name] = true
+			continue
+		}
+
+		applyICalProperty(record, prop)
+	}
+
+	return records, nil
+}
+
+// MergeICalendar returns the task a client's VTODO, read by ReadICalendar,
+// makes of current, the stored task it overwrites (nil if there is none).
+// Clients rewrite the properties every VTODO has, so those replace the
+// stored values and leaving one out clears it. Many clients drop the
+// properties only this app writes and task relations they do not know, so
+// where those are missing current keeps its own.
+func MergeICalendar(current *domain.Task, record Record) *domain.Task {
+	read := record.Task
+	if current == nil {
+		return read
+	}
+
+	task := current.Clone()
+	task.Title = read.Title
+	task.Description = read.Description
+	task.Priority = read.Priority
+	task.DueDate, task.DueAllDay = read.DueDate, read.DueAllDay
+	task.StartDate = read.StartDate
+	task.Tags = read.Tags
+
+	// Without our state property STATUS is all there is; the stored state
+	// stays as long as STATUS agrees with it.
+	if record.properties[icalStateProp] || ICalStatus(read.Status) != ICalStatus(current.Status) {
+		task.Status = read.Status
+	}
+	if !task.Status.IsClosed() {
+		task.CompletedAt = nil
+	} else if record.properties["COMPLETED"] || task.CompletedAt == nil {
+		task.CompletedAt = read.CompletedAt
+	}
+
+	if record.properties[icalProjectProp] {
+		task.Project = read.Project
+	}
+	if record.properties[icalRankProp] {
+		task.Rank = read.Rank
+	}
+	if record.properties[icalEstimateProp] {
+		task.Estimate = read.Estimate
+	}
+	if record.properties[icalParentProp] {
+		task.ParentID = read.ParentID
+	}
+	if record.properties[icalDependsOnProp] {
+		task.BlockedBy = read.BlockedBy
+	}
+	return task
+}
+
+func applyICalProperty(record *Record, prop icalProperty) {
+	task := record.Task
+	record.properties[prop.name] = true
+
+	switch prop.name {
+	case "UID":
+		task.ID = strings.TrimSuffix(strings.TrimSpace(prop.value), icalUIDSuffix)
+	case "SUMMARY":
+		task.Title = strings.TrimSpace(unescapeICalText(prop.value))
+	case "DESCRIPTION":
+		task.Description = unescapeICalText(prop.value)
+	case "PRIORITY":
+		value, err := strconv.Atoi(strings.TrimSpace(prop.value))
+		if err != nil {
+			record.addError(fmt.Sprintf("line %d: invalid PRIORITY %q", prop.line, prop.value))
+			return
+		}
+		task.Priority = priorityFromICal(value)
+	case "STATUS":
+		switch strings.ToUpper(strings.TrimSpace(prop.value)) {
+		case "IN-PROCESS":
+			task.Status = domain.InProgressTask
+		case "COMPLETED":
+			task.Status = domain.DoneTask
+		case "CANCELLED":
+			task.Status = domain.CancelledTask
+		default:
+			task.Status = domain.TodoTask
+		}
+	case "CATEGORIES":
+		for _, tag := range strings.Split(prop.value, ",") {
+			addTags(task, unescapeICalText(tag))
+		}
+	case icalProjectProp:
+		task.Project = unescapeICalText(prop.value)
+	case icalRankProp:
+		rank := strings.TrimSpace(prop.value)
+		if !domain.ValidRank(rank) {
+			delete(record.properties, prop.name)
+			record.addWarning(fmt.Sprintf("line %d: ignoring invalid %s %q", prop.line, icalRankProp, prop.value))
+			return
+		}
+		task.Rank = rank
+	case icalEstimateProp:
+		estimate, err := domain.ParseEstimate(prop.value)
+		if err != nil {
+			delete(record.properties, prop.name)
+			record.addWarning(fmt.Sprintf("line %d: ignoring %v", prop.line, err))
+			return
+		}
+		task.Estimate = estimate
+	case "RELATED-TO":
+		id := strings.TrimSuffix(strings.TrimSpace(prop.value), icalUIDSuffix)
+		switch reltype := prop.params["RELTYPE"]; {
+		case reltype == "" || strings.EqualFold(reltype, "PARENT"):
+			task.ParentID = id
+			record.properties[icalParentProp] = true
+		case strings.EqualFold(reltype, "DEPENDS-ON"):
+			record.properties[icalDependsOnProp] = true
+			if id != "" && !task.DependsOn(id) {
+				task.BlockedBy = append(task.BlockedBy, id)
+			}
+		}
+	case "DUE", "DTSTART", "COMPLETED", "CREATED", "LAST-MODIFIED":
+		parsed, err := parseICalTime(prop)
+		if err != nil {
+			record.addError(fmt.Sprintf("line %d: invalid %s %q", prop.line, prop.name, prop.value))
+			return
+		}
+		switch prop.name {
+		case "DUE":
+			task.DueDate, task.DueAllDay = &parsed, isICalDate(prop)
+			if task.DueAllDay {
+				*task.DueDate = domain.DateOnly(parsed)
+			}
+		case "DTSTART":
+			start := domain.DateOnly(parsed.In(time.Local))
+			task.StartDate = &start
+		case "COMPLETED":
+			task.CompletedAt = &parsed
+		case "CREATED":
+			task.CreatedAt = parsed
+		case "LAST-MODIFIED":
+			task.UpdatedAt = parsed
+		}
+	case "RRULE":
+		record.addWarning(fmt.Sprintf("line %d: recurring tasks are not supported, RRULE ignored", prop.line))
+	}
+}
@@ -0,0 +1,446 @@
+package interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+// Markdown task lines follow the Obsidian Tasks plugin, e.g.
+//
+//   - [ ] Write report #work [project:: Acme] 🛫 2026-10-15 📅 2026-10-20 ⏫ ➕ 2026-10-01 <!-- id:1a2b -->
+//     Description lines are indented below the item.
+//
+// A due time, which the plugin does not have, follows the due date as
+// 📅 2026-10-20 17:00. The box is [/] in progress, [x] done and [-]
+// cancelled; blocked and in review tasks keep [ ] and add [status:: ...].
+const (
+	mdDue       = "📅"
+	mdStart     = "🛫"
+	mdCreated   = "➕"
+	mdDone      = "✅"
+	mdCancelled = "❌"
+	mdHighest   = "🔺"
+	mdHigh      = "⏫"
+	mdMedium    = "🔼"
+	mdLow       = "🔽"
+	mdLowest    = "⏬"
+	mdDate      = "2006-01-02"
+	mdTime      = "15:04"
+	mdIndent    = "  "
+	mdIDPrefix  = "<!-- id:"
+	mdIDSuffix  = " -->"
+	mdFieldOpen = "[project:: "
+	mdParent    = "[parent:: "
+	mdStatus    = "[status:: "
+	mdRank      = "[rank:: "
+	mdBlockedBy = "[blocked_by:: "
+	mdEstimate  = "[estimate:: "
+)
+
+const (
+	MarkdownGroupNone     = ""
+	MarkdownGroupDueDate  = "due_date"
+	MarkdownGroupPriority = "priority"
+	MarkdownGroupProject  = "project"
+)
+
+type MarkdownOptions struct {
+	Title   string `json:"title,omitempty"`
+	GroupBy string `json:"group_by,omitempty"`
+}
+
+var (
+	mdTaskLine  = regexp.MustCompile(`^[-*+] \[(.)\] (.*)$`)
+	mdIDComment = regexp.MustCompile(`\s*<!-- id:(\S+) -->\s*$`)
+	mdProject   = regexp.MustCompile(`\s*\[project:: ([^\]]*)\]`)
+	mdParentID  = regexp.MustCompile(`\s*\[parent:: ([^\]\s]*)\]`)
+	mdStatusTag = regexp.MustCompile(`\s*\[status:: ([^\]\s]*)\]`)
+	mdRankField = regexp.MustCompile(`\s*\[rank:: ([0-9a-z]+)\]`)
+	mdBlockers  = regexp.MustCompile(`\s*\[blocked_by:: ([^\]]*)\]`)
+	mdEstimated = regexp.MustCompile(`\s*\[estimate:: ([^\]]*)\]`)
+	mdTag       = regexp.MustCompile(`^#[^\s#]*[^\s#0-9][^\s#]*$`)
+)
+
+func markdownPriority(priority domain.Priority) string {
+	switch priority {
+	case domain.HighPriority:
+		return mdHigh
+	case domain.LowPriority:
+		return mdLow
+	}
+	return ""
+}
+
+// FormatMarkdown renders a task as a list item plus indented description
+// lines.
+func FormatMarkdown(task *domain.Task) string {
+	var b strings.Builder
+
+	box := " "
+	switch task.Status {
+	case domain.InProgressTask:
+		box = "/"
+	case domain.DoneTask:
+		box = "x"
+	case domain.CancelledTask:
+		box = "-"
+	}
+	b.WriteString("- [" + box + "] " + strings.Join(strings.Fields(task.Title), " "))
+
+	for _, tag := range task.Tags {
+		b.WriteString(" #" + todoTxtWord(tag))
+	}
+	if task.Project != "" {
+		b.WriteString(" " + mdFieldOpen + strings.ReplaceAll(task.Project, "]", "") + "]")
+	}
+	if task.ParentID != "" {
+		b.WriteString(" " + mdParent + task.ParentID + "]")
+	}
+	if task.Status == domain.BlockedTask || task.Status == domain.InReviewTask {
+		b.WriteString(" " + mdStatus + string(task.Status) + "]")
+	}
+	if task.Rank != "" {
+		b.WriteString(" " + mdRank + task.Rank + "]")
+	}
+	if len(task.BlockedBy) > 0 {
+		b.WriteString(" " + mdBlockedBy + strings.Join(task.BlockedBy, ", ") + "]")
+	}
+	if task.Estimate != nil {
+		b.WriteString(" " + mdEstimate + task.Estimate.String() + "]")
+	}
+	if task.StartDate != nil {
+		b.WriteString(" " + mdStart + " " + task.StartDate.UTC().Format(mdDate))
+	}
+	if task.DueDate != nil {
+		b.WriteString(" " + mdDue + " " + formatDue(task, mdDate, mdDate+" "+mdTime))
+	}
+	if emoji := markdownPriority(task.Priority); emoji != "" {
+		b.WriteString(" " + emoji)
+	}
+	b.WriteString(" " + mdCreated + " " + task.CreatedAt.Local().Format(mdDate))
+	if task.Status.IsClosed() {
+		completedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+		marker := mdDone
+		if task.Status == domain.CancelledTask {
+			marker = mdCancelled
+		}
+		b.WriteString(" " + marker + " " + completedAt.Local().Format(mdDate))
+	}
+	b.WriteString(" " + mdIDPrefix + task.ID + mdIDSuffix + "\n")
+
+	if task.Description != "" {
+		for _, line := range strings.Split(task.Description, "\n") {
+			if strings.TrimSpace(line) == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString(mdIndent + line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// WriteMarkdown writes tasks as a Markdown checklist, optionally under one
+// heading per due date bucket, priority or project. Tasks keep their order
+// within a group.
+func WriteMarkdown(w io.Writer, tasks []*domain.Task, opts MarkdownOptions) error {
+	buf := bufio.NewWriter(w)
+
+	if opts.Title != "" {
+		fmt.Fprintf(buf, "# %s\n\n", opts.Title)
+	}
+
+	if opts.GroupBy == MarkdownGroupNone {
+		for _, task := range tasks {
+			buf.WriteString(FormatMarkdown(task))
+		}
+		return buf.Flush()
+	}
+
+	groups, order, err := groupTasks(tasks, opts.GroupBy, time.Now())
+	if err != nil {
+		return err
+	}
+	for i, name := range order {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "## %s\n\n", name)
+		for _, task := range groups[name] {
+			buf.WriteString(FormatMarkdown(task))
+		}
+	}
+
+	return buf.Flush()
+}
+
+func groupTasks(tasks []*domain.Task, groupBy string, now time.Time) (map[string][]*domain.Task, []string, error) {
+	var order []string
+	switch groupBy {
+	case MarkdownGroupDueDate:
+		order = []string{"Overdue", "Today", "Tomorrow", "Next 7 days", "Later", "No due date", "Done"}
+	case MarkdownGroupPriority:
+		order = []string{"High", "Medium", "Low"}
+	case MarkdownGroupProject:
+	default:
+		return nil, nil, fmt.Errorf("unknown group %q", groupBy)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	groups := make(map[string][]*domain.Task)
+
+	for _, task := range tasks {
+		var name string
+		switch groupBy {
+		case MarkdownGroupDueDate:
+			name = dueBucket(task, now, today)
+		case MarkdownGroupPriority:
+			name = capitalize(string(task.Priority))
+		case MarkdownGroupProject:
+			name = task.Project
+			if name == "" {
+				name = "No project"
+			}
+			if _, seen := groups[name]; !seen {
+				order = append(order, name)
+			}
+		}
+		groups[name] = append(groups[name], task)
+	}
+
+	// Skip empty buckets of the fixed orders.
+	present := order[:0]
+	for _, name := range order {
+		if len(groups[name]) > 0 {
+			present = append(present, name)
+		}
+	}
+
+	return groups, present, nil
+}
+
+func capitalize(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+func dueBucket(task *domain.Task, now, today time.Time) string {
+	switch {
+	case task.Status.IsClosed():
+		return "Done"
+	case task.DueDate == nil:
+		return "No due date"
+	case task.IsOverdue(domain.FixedClock{Time: now}):
+		return "Overdue"
+	}
+
+	due, _ := task.DueSpan(now.Location())
+	switch {
+	case due.Before(domain.AddDays(today, 1)):
+		return "Today"
+	case due.Before(domain.AddDays(today, 2)):
+		return "Tomorrow"
+	case due.Before(domain.AddDays(today, 8)):
+		return "Next 7 days"
+	}
+	return "Later"
+}
+
+// ReadMarkdown decodes every checklist item of a Markdown document. Headings
+// and other text are skipped; indented lines following an item become its
+// description.
+func ReadMarkdown(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	records := make([]Record, 0)
+	var current *Record
+	var description []string
+	blank := 0
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.Task.Description = strings.Join(description, "\n")
+		records = append(records, *current)
+		current, description, blank = nil, nil, 0
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if match := mdTaskLine.FindStringSubmatch(text); match != nil {
+			finish()
+			record := parseMarkdownItem(match[1], match[2])
+			record.Line = line
+			current = &record
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			blank++
+		case strings.HasPrefix(text, mdIndent) || strings.HasPrefix(text, "\t"):
+			for ; blank > 0; blank-- {
+				description = append(description, "")
+			}
+			// Only the indent goes; the line may be indented itself.
+			if strings.HasPrefix(text, mdIndent) {
+				text = text[len(mdIndent):]
+			} else {
+				text = text[1:]
+			}
+			description = append(description, text)
+		default:
+			finish()
+		}
+	}
+	finish()
+
+	return records, scanner.Err()
+}
+
+func parseMarkdownItem(box, rest string) Record {
+	record := Record{Task: domain.NewTask("", "")}
+	task := record.Task
+	task.CreatedAt = today()
+
+	switch box {
+	case "/":
+		task.Status = domain.InProgressTask
+	case "x", "X":
+		task.Status = domain.DoneTask
+	case "-":
+		task.Status = domain.CancelledTask
+	}
+
+	if match := mdIDComment.FindStringSubmatch(rest); match != nil {
+		task.ID = match[1]
+		rest = rest[:len(rest)-len(match[0])]
+	}
+	if match := mdProject.FindStringSubmatch(rest); match != nil {
+		task.Project = strings.TrimSpace(match[1])
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+	if match := mdParentID.FindStringSubmatch(rest); match != nil {
+		task.ParentID = match[1]
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+	if match := mdRankField.FindStringSubmatch(rest); match != nil && domain.ValidRank(match[1]) {
+		task.Rank = match[1]
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+	if match := mdBlockers.FindStringSubmatch(rest); match != nil {
+		task.BlockedBy = splitIDs(match[1])
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+	if match := mdEstimated.FindStringSubmatch(rest); match != nil {
+		if estimate, err := domain.ParseEstimate(match[1]); err != nil {
+			record.addWarning(err.Error())
+		} else {
+			task.Estimate = estimate
+			rest = strings.Replace(rest, match[0], "", 1)
+		}
+	}
+	// A ticked box wins over a status field left behind by an editor that
+	// does not know it.
+	if match := mdStatusTag.FindStringSubmatch(rest); match != nil {
+		if status := domain.TaskStatus(match[1]); status.IsValid() && task.Status == domain.TodoTask {
+			task.Status = status
+		} else if !status.IsValid() {
+			record.addWarning(fmt.Sprintf("unknown status %q", match[1]))
+		}
+		rest = strings.Replace(rest, match[0], "", 1)
+	}
+
+	var title []string
+	var completedAt *time.Time
+	fields := strings.Fields(rest)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		switch field {
+		case mdHighest, mdHigh:
+			task.Priority = domain.HighPriority
+			continue
+		case mdMedium:
+			task.Priority = domain.MediumPriority
+			continue
+		case mdLow, mdLowest:
+			task.Priority = domain.LowPriority
+			continue
+		case mdDue, mdStart, mdCreated, mdDone, mdCancelled:
+			if i+1 >= len(fields) {
+				break
+			}
+			date, err := time.ParseInLocation(mdDate, fields[i+1], time.Local)
+			if err != nil {
+				record.addWarning(fmt.Sprintf("cannot parse date %q after %s", fields[i+1], field))
+				break
+			}
+			i++
+			switch field {
+			case mdDue:
+				value := fields[i]
+				if i+1 < len(fields) {
+					if _, err := time.Parse(mdTime, fields[i+1]); err == nil {
+						i++
+						value += " " + fields[i]
+					}
+				}
+				setDue(task, value, mdDate, mdDate+" "+mdTime)
+			case mdStart:
+				start := domain.DateOnly(date)
+				task.StartDate = &start
+			case mdCreated:
+				task.CreatedAt = date
+			case mdDone, mdCancelled:
+				completedAt = &date
+			}
+			continue
+		}
+
+		if mdTag.MatchString(field) {
+			addTags(task, readTodoTxtWord(field[1:]))
+			continue
+		}
+		title = append(title, field)
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		record.addError("task has no title")
+	}
+
+	task.UpdatedAt = task.CreatedAt
+	if completedAt != nil && task.Status.IsClosed() {
+		if completedAt.After(task.CreatedAt) {
+			task.UpdatedAt = *completedAt
+		}
+		task.CompletedAt = completedAt
+	}
+	closeTask(task)
+
+	return record
+}
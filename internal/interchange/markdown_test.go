@@ -0,0 +1,56 @@
+package interchange
+
+import (
+	"strings"
+	"testing"
+
+	"todo-list/internal/domain"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	useLocal(t, "America/New_York")
+
+	for _, tt := range lineFormatTasks(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			text := FormatMarkdown(tt.task)
+			records, err := ReadMarkdown(strings.NewReader(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || !records[0].Valid() || len(records[0].Warnings) > 0 {
+				t.Fatalf("read %+v from:\n%s", records, text)
+			}
+			assertSameTask(t, records[0].Task, tt.task)
+			if t.Failed() {
+				t.Logf("written as:\n%s", text)
+			}
+		})
+	}
+}
+
+func TestMarkdownBoxes(t *testing.T) {
+	tests := []struct {
+		line string
+		want domain.TaskStatus
+	}{
+		{"- [ ] Task", domain.TodoTask},
+		{"- [/] Task", domain.InProgressTask},
+		{"- [x] Task", domain.DoneTask},
+		{"- [X] Task", domain.DoneTask},
+		{"- [-] Task", domain.CancelledTask},
+		{"- [ ] Task [status:: blocked]", domain.BlockedTask},
+		{"- [ ] Task [status:: in_review]", domain.InReviewTask},
+		// Ticked in an editor that does not know the status field.
+		{"- [x] Task [status:: in_review]", domain.DoneTask},
+	}
+
+	for _, tt := range tests {
+		records, err := ReadMarkdown(strings.NewReader(tt.line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Task.Status != tt.want {
+			t.Errorf("%q read as %+v, want status %s", tt.line, records, tt.want)
+		}
+	}
+}
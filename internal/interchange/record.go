@@ -0,0 +1,172 @@
+// Package interchange converts tasks to and from external file formats.
+package interchange
+
+import (
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+// Record is one task decoded from an external format. Line points back into
+// the source so validation problems can be reported per row.
+type Record struct {
+	Line     int          `json:"line"`
+	Task     *domain.Task `json:"task,omitempty"`
+	Errors   []string     `json:"errors,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+
+	// properties holds the names of the properties a VTODO had, for
+	// MergeICalendar.
+	properties map[string]bool
+}
+
+func (r *Record) addError(msg string) {
+	r.Errors = append(r.Errors, msg)
+}
+
+// addWarning notes data that was dropped without making the record invalid.
+func (r *Record) addWarning(msg string) {
+	r.Warnings = append(r.Warnings, msg)
+}
+
+func (r *Record) Valid() bool {
+	return len(r.Errors) == 0 && r.Task != nil
+}
+
+// addTags is domain.Task.AddTags without touching UpdatedAt, which decoders
+// take from the source.
+func addTags(task *domain.Task, tags ...string) {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !task.HasTag(tag) {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+}
+
+// splitIDs reads a comma-separated list of task IDs, dropping blanks and
+// repeats.
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		id = strings.TrimSpace(id)
+		if id != "" && !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func containsID(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// today is used for items that carry no creation date, so a file read twice
+// yields the same task as long as it is read on the same day.
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// dateLayouts are tried in order when a format does not pin one down.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"02.01.2006",
+}
+
+func parseDate(value, preferred string, loc *time.Location) (time.Time, bool) {
+	parsed, _, ok := parseDateOrTime(value, preferred, loc)
+	return parsed, ok
+}
+
+// parseDateOrTime is parseDate that also tells whether the value was only a
+// date, by the layout that matched.
+func parseDateOrTime(value, preferred string, loc *time.Location) (time.Time, bool, bool) {
+	value = strings.TrimSpace(value)
+	layouts := dateLayouts
+	if preferred != "" {
+		layouts = append([]string{preferred}, dateLayouts...)
+	}
+
+	for _, layout := range layouts {
+		if parsed, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return parsed, !strings.Contains(layout, "15"), true
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// formatDue writes an all-day due date with dateLayout and a timed one in
+// local time with timeLayout.
+func formatDue(task *domain.Task, dateLayout, timeLayout string) string {
+	if task.DueAllDay {
+		return task.DueDate.UTC().Format(dateLayout)
+	}
+	return task.DueDate.Local().Format(timeLayout)
+}
+
+// setDue parses value with dateLayout as an all-day due date or with
+// timeLayout as a local date and time.
+func setDue(task *domain.Task, value, dateLayout, timeLayout string) bool {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		task.DueDate, task.DueAllDay = &date, true
+		return true
+	}
+	if due, err := time.ParseInLocation(timeLayout, value, time.Local); err == nil {
+		task.DueDate, task.DueAllDay = &due, false
+		return true
+	}
+	return false
+}
+
+func parsePriority(value string) (domain.Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "medium", "normal", "2":
+		return domain.MediumPriority, true
+	case "low", "3", "4":
+		return domain.LowPriority, true
+	case "high", "urgent", "1":
+		return domain.HighPriority, true
+	}
+	return "", false
+}
+
+func parseStatus(value string) (domain.TaskStatus, bool) {
+	value = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(value)))
+	switch value {
+	case "", "active", "open", "todo", "to_do", "false", "no":
+		return domain.TodoTask, true
+	case "in_progress", "doing", "started":
+		return domain.InProgressTask, true
+	case "blocked", "waiting", "on_hold":
+		return domain.BlockedTask, true
+	case "in_review", "review":
+		return domain.InReviewTask, true
+	case "completed", "done", "closed", "x", "true", "yes":
+		return domain.DoneTask, true
+	case "cancelled", "canceled":
+		return domain.CancelledTask, true
+	}
+	return "", false
+}
+
+// closeTask fills in CompletedAt for a closed task read from a format that
+// only has a modification time.
+func closeTask(task *domain.Task) {
+	if task.Status.IsClosed() && task.CompletedAt == nil {
+		completedAt := task.UpdatedAt
+		task.CompletedAt = &completedAt
+	}
+}
@@ -0,0 +1,72 @@
+package interchange
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+// TimeLogOptions says how a time log export is grouped: by task, project
+// or day. Summary writes one total per group instead of every entry.
+type TimeLogOptions struct {
+	GroupBy string `json:"group_by"`
+	Summary bool   `json:"summary,omitempty"`
+}
+
+// WriteTimeLogCSV writes segments, as cut by log.Segments, in their order.
+// Durations are decimal hours, the unit invoices use.
+func WriteTimeLogCSV(w io.Writer, log *domain.TimeLog, segments []domain.TimeSegment, opts TimeLogOptions) error {
+	writer := csv.NewWriter(w)
+
+	if opts.Summary {
+		if err := writer.Write([]string{opts.GroupBy, "hours", "entries"}); err != nil {
+			return err
+		}
+		var total time.Duration
+		for _, sum := range log.Totals(segments, opts.GroupBy) {
+			duration := time.Duration(sum.Seconds) * time.Second
+			total += duration
+			if err := writer.Write([]string{sum.Label, formatHours(duration), strconv.Itoa(sum.Entries)}); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write([]string{"Total", formatHours(total), strconv.Itoa(len(segments))}); err != nil {
+			return err
+		}
+	} else {
+		header := []string{opts.GroupBy, "date", "start", "end", "hours", "task", "project", "note"}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		for _, segment := range segments {
+			title, project := "Deleted task", ""
+			if task := log.Task(segment.Entry); task != nil {
+				title, project = task.Title, task.Project
+			}
+			row := []string{
+				log.Label(opts.GroupBy, segment.Group),
+				segment.Start.Format(csvDate),
+				segment.Start.Format("15:04"),
+				segment.End.Format("15:04"),
+				formatHours(segment.Duration()),
+				title,
+				project,
+				segment.Entry.Note,
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatHours(d time.Duration) string {
+	return fmt.Sprintf("%.2f", d.Hours())
+}
@@ -0,0 +1,306 @@
+package interchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+const (
+	todoTxtDate     = "2006-01-02"
+	todoTxtDateTime = "2006-01-02T15:04"
+)
+
+// Keys we read and write as key:value pairs. Unknown pairs stay in the title
+// so nothing the user typed is lost. t: is the threshold date other todo.txt
+// apps use for start dates; dep: lists the IDs of the tasks to finish first
+// and est: holds the estimate, such as est:1h30m or est:3pt.
+const (
+	todoTxtDue         = "due"
+	todoTxtThreshold   = "t"
+	todoTxtID          = "id"
+	todoTxtPriority    = "pri"
+	todoTxtDescription = "desc"
+	todoTxtParent      = "parent"
+	todoTxtStatus      = "status"
+	todoTxtRank        = "rank"
+	todoTxtDependency  = "dep"
+	todoTxtEstimate    = "est"
+)
+
+// todoTxtPriorityLetter maps our priorities onto todo.txt letters. Medium is the
+// default and written without a priority, so a plain line round-trips.
+func todoTxtPriorityLetter(priority domain.Priority) string {
+	switch priority {
+	case domain.HighPriority:
+		return "A"
+	case domain.LowPriority:
+		return "C"
+	}
+	return ""
+}
+
+func priorityFromTodoTxt(letter byte) domain.Priority {
+	switch {
+	case letter == 'A':
+		return domain.HighPriority
+	case letter == 'B':
+		return domain.MediumPriority
+	}
+	return domain.LowPriority
+}
+
+// FormatTodoTxt renders a task as one todo.txt line. Done and cancelled
+// tasks are marked x and drop their priority as the format asks, keeping it
+// in a pri: pair instead. States other than todo and done go in a status:
+// pair. Contexts (@) carry tags and the first +project the project.
+func FormatTodoTxt(task *domain.Task) string {
+	var parts []string
+
+	letter := todoTxtPriorityLetter(task.Priority)
+	if task.Status.IsClosed() {
+		completedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+		parts = append(parts, "x", completedAt.Local().Format(todoTxtDate))
+	} else if letter != "" {
+		parts = append(parts, "("+letter+")")
+	}
+	parts = append(parts, task.CreatedAt.Local().Format(todoTxtDate))
+	parts = append(parts, strings.Fields(task.Title)...)
+
+	if task.Project != "" {
+		parts = append(parts, "+"+todoTxtWord(task.Project))
+	}
+	for _, tag := range task.Tags {
+		parts = append(parts, "@"+todoTxtWord(tag))
+	}
+	if task.DueDate != nil {
+		parts = append(parts, todoTxtDue+":"+formatDue(task, todoTxtDate, todoTxtDateTime))
+	}
+	if task.StartDate != nil {
+		parts = append(parts, todoTxtThreshold+":"+task.StartDate.UTC().Format(todoTxtDate))
+	}
+	if task.Status != domain.TodoTask && task.Status != domain.DoneTask {
+		parts = append(parts, todoTxtStatus+":"+string(task.Status))
+	}
+	if task.Status.IsClosed() && letter != "" {
+		parts = append(parts, todoTxtPriority+":"+letter)
+	}
+	if task.Description != "" {
+		parts = append(parts, todoTxtDescription+":"+url.PathEscape(task.Description))
+	}
+	if task.ParentID != "" {
+		parts = append(parts, todoTxtParent+":"+task.ParentID)
+	}
+	if task.Rank != "" {
+		parts = append(parts, todoTxtRank+":"+task.Rank)
+	}
+	if len(task.BlockedBy) > 0 {
+		parts = append(parts, todoTxtDependency+":"+strings.Join(task.BlockedBy, ","))
+	}
+	if task.Estimate != nil {
+		parts = append(parts, todoTxtEstimate+":"+task.Estimate.String())
+	}
+	parts = append(parts, todoTxtID+":"+task.ID)
+
+	return strings.Join(parts, " ")
+}
+
+var (
+	wordEscaper   = strings.NewReplacer("%", "%25", " ", "%20")
+	wordUnescaper = strings.NewReplacer("%25", "%", "%20", " ")
+)
+
+// todoTxtWord keeps multi-word projects and tags as a single token, with
+// the spaces escaped as %20 so readTodoTxtWord restores them.
+func todoTxtWord(value string) string {
+	return wordEscaper.Replace(strings.Join(strings.Fields(value), " "))
+}
+
+func readTodoTxtWord(word string) string {
+	return wordUnescaper.Replace(word)
+}
+
+func WriteTodoTxt(w io.Writer, tasks []*domain.Task) error {
+	buf := bufio.NewWriter(w)
+	for _, task := range tasks {
+		if _, err := buf.WriteString(FormatTodoTxt(task) + "\n"); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
+
+// ParseTodoTxt decodes one todo.txt line. Lines without an id: pair get a
+// fresh ID. Values that cannot be understood are kept in the title and
+// reported as warnings.
+func ParseTodoTxt(line string) Record {
+	record := Record{Task: domain.NewTask("", "")}
+	task := record.Task
+	task.CreatedAt = today()
+
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && fields[0] == "x" {
+		task.Status = domain.DoneTask
+		fields = fields[1:]
+		if completed, ok := todoTxtDateField(fields); ok {
+			task.UpdatedAt = completed
+			task.CompletedAt = &completed
+			fields = fields[1:]
+		}
+	}
+	if len(fields) > 0 && isTodoTxtPriority(fields[0]) {
+		task.Priority = priorityFromTodoTxt(fields[0][1])
+		fields = fields[1:]
+	}
+	if created, ok := todoTxtDateField(fields); ok {
+		task.CreatedAt = created
+		fields = fields[1:]
+	}
+
+	var title []string
+	for _, field := range fields {
+		switch {
+		case len(field) > 1 && field[0] == '+':
+			if task.Project == "" {
+				task.Project = readTodoTxtWord(field[1:])
+			} else {
+				addTags(task, readTodoTxtWord(field[1:]))
+			}
+			continue
+		case len(field) > 1 && field[0] == '@':
+			addTags(task, readTodoTxtWord(field[1:]))
+			continue
+		}
+
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			title = append(title, field)
+			continue
+		}
+
+		switch key {
+		case todoTxtDue:
+			if !setDue(task, value, todoTxtDate, todoTxtDateTime) {
+				record.addWarning(fmt.Sprintf("cannot parse due date %q", value))
+				title = append(title, field)
+			}
+		case todoTxtThreshold:
+			start, err := time.Parse(todoTxtDate, value)
+			if err != nil {
+				record.addWarning(fmt.Sprintf("cannot parse start date %q", value))
+				title = append(title, field)
+				continue
+			}
+			task.StartDate = &start
+		case todoTxtID:
+			task.ID = value
+		case todoTxtParent:
+			task.ParentID = value
+		case todoTxtDependency:
+			for _, id := range splitIDs(value) {
+				if !task.DependsOn(id) {
+					task.BlockedBy = append(task.BlockedBy, id)
+				}
+			}
+		case todoTxtEstimate:
+			estimate, err := domain.ParseEstimate(value)
+			if err != nil {
+				record.addWarning(err.Error())
+				title = append(title, field)
+				continue
+			}
+			task.Estimate = estimate
+		case todoTxtRank:
+			if !domain.ValidRank(value) {
+				title = append(title, field)
+				continue
+			}
+			task.Rank = value
+		case todoTxtStatus:
+			// The x decides whether the task is closed; another tool may
+			// have ticked or unticked it without knowing the pair.
+			status := domain.TaskStatus(value)
+			if !status.IsValid() {
+				record.addWarning(fmt.Sprintf("unknown status %q", value))
+				title = append(title, field)
+			} else if status.IsClosed() == task.Status.IsClosed() {
+				task.Status = status
+			}
+		case todoTxtPriority:
+			if len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z' {
+				task.Priority = priorityFromTodoTxt(value[0])
+			} else {
+				title = append(title, field)
+			}
+		case todoTxtDescription:
+			description, err := url.PathUnescape(value)
+			if err != nil {
+				description = value
+			}
+			task.Description = description
+		default:
+			title = append(title, field)
+		}
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		// A line of only projects and contexts still names something.
+		task.Title = strings.Join(fields, " ")
+	}
+	if !task.Status.IsClosed() || task.UpdatedAt.Before(task.CreatedAt) {
+		task.UpdatedAt = task.CreatedAt
+	}
+	if !task.Status.IsClosed() {
+		task.CompletedAt = nil
+	}
+	closeTask(task)
+
+	return record
+}
+
+func isTodoTxtPriority(field string) bool {
+	return len(field) == 3 && field[0] == '(' && field[2] == ')' && field[1] >= 'A' && field[1] <= 'Z'
+}
+
+func todoTxtDateField(fields []string) (time.Time, bool) {
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	parsed, err := time.ParseInLocation(todoTxtDate, fields[0], time.Local)
+	return parsed, err == nil
+}
+
+// ReadTodoTxt decodes every non-blank line of a todo.txt file.
+func ReadTodoTxt(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	records := make([]Record, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if text == "" {
+			continue
+		}
+
+		record := ParseTodoTxt(text)
+		record.Line = line
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
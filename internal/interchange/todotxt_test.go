@@ -0,0 +1,210 @@
+package interchange
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+// useLocal runs the test with time.Local set to the named zone, so dates
+// written in local time and in UTC differ.
+func useLocal(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s: %v", name, err)
+	}
+	saved := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = saved })
+	return loc
+}
+
+// roundTripTask is a task as the line formats keep it: creation and
+// completion times are dates in the local zone.
+func roundTripTask(id, title string) *domain.Task {
+	created := time.Date(2026, 3, 7, 23, 30, 0, 0, time.Local)
+	return &domain.Task{
+		ID:        id,
+		Title:     title,
+		Status:    domain.TodoTask,
+		Priority:  domain.MediumPriority,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func localDate(t *time.Time) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// assertSameTask compares what the line formats promise to keep.
+func assertSameTask(t *testing.T, got, want *domain.Task) {
+	t.Helper()
+	checks := []struct {
+		field     string
+		got, want interface{}
+	}{
+		{"ID", got.ID, want.ID},
+		{"Title", got.Title, want.Title},
+		{"Description", got.Description, want.Description},
+		{"Status", got.Status, want.Status},
+		{"Priority", got.Priority, want.Priority},
+		{"DueDate", timeString(got.DueDate), timeString(want.DueDate)},
+		{"DueAllDay", got.DueAllDay, want.DueAllDay},
+		{"StartDate", timeString(got.StartDate), timeString(want.StartDate)},
+		{"Project", got.Project, want.Project},
+		{"Tags", fmt.Sprint(got.Tags), fmt.Sprint(want.Tags)},
+		{"ParentID", got.ParentID, want.ParentID},
+		{"BlockedBy", fmt.Sprint(got.BlockedBy), fmt.Sprint(want.BlockedBy)},
+		{"Estimate", fmt.Sprint(got.Estimate), fmt.Sprint(want.Estimate)},
+		{"Rank", got.Rank, want.Rank},
+		{"CreatedAt", localDate(&got.CreatedAt), localDate(&want.CreatedAt)},
+		{"CompletedAt", localDate(got.CompletedAt), localDate(want.CompletedAt)},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("%s = %q, want %q", check.field, check.got, check.want)
+		}
+	}
+}
+
+func lineFormatTasks(t *testing.T) []struct {
+	name string
+	task *domain.Task
+} {
+	t.Helper()
+	completed := time.Date(2026, 3, 9, 23, 45, 0, 0, time.Local)
+	allDay := domain.DateOnly(time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC))
+	timed := time.Date(2026, 3, 8, 1, 30, 0, 0, time.Local)
+	start := domain.DateOnly(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	estimate, err := domain.ParseEstimate("1h30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests []struct {
+		name string
+		task *domain.Task
+	}
+	add := func(name string, edit func(task *domain.Task)) {
+		task := roundTripTask(fmt.Sprintf("t%d", len(tests)+1), "Write report")
+		edit(task)
+		tests = append(tests, struct {
+			name string
+			task *domain.Task
+		}{name, task})
+	}
+
+	add("plain", func(task *domain.Task) {})
+	add("medium priority", func(task *domain.Task) { task.Priority = domain.MediumPriority })
+	add("high priority", func(task *domain.Task) { task.Priority = domain.HighPriority })
+	add("low priority", func(task *domain.Task) { task.Priority = domain.LowPriority })
+	add("in progress", func(task *domain.Task) { task.Status = domain.InProgressTask })
+	add("blocked", func(task *domain.Task) { task.Status = domain.BlockedTask })
+	add("in review", func(task *domain.Task) { task.Status = domain.InReviewTask })
+	add("done", func(task *domain.Task) {
+		task.Status, task.CompletedAt, task.Priority = domain.DoneTask, &completed, domain.HighPriority
+	})
+	add("cancelled", func(task *domain.Task) {
+		task.Status, task.CompletedAt, task.Priority = domain.CancelledTask, &completed, domain.LowPriority
+	})
+	add("all-day due date", func(task *domain.Task) { task.DueDate, task.DueAllDay = &allDay, true })
+	add("timed due date", func(task *domain.Task) { task.DueDate = &timed })
+	add("start date", func(task *domain.Task) { task.StartDate = &start })
+	add("multi-word project and tags", func(task *domain.Task) {
+		task.Project = "Acme Corp"
+		task.Tags = []string{"deep work", "q1_review", "100%"}
+	})
+	add("relations, rank and estimate", func(task *domain.Task) {
+		task.ParentID = "parent1"
+		task.BlockedBy = []string{"a1", "b2"}
+		task.Rank = "m"
+		task.Estimate = estimate
+	})
+	add("description with blank and indented lines", func(task *domain.Task) {
+		task.Description = "First line\n\n  indented by two\n\ttabbed\nlast line"
+	})
+	return tests
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	useLocal(t, "America/New_York")
+
+	for _, tt := range lineFormatTasks(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			line := FormatTodoTxt(tt.task)
+			record := ParseTodoTxt(line)
+			if !record.Valid() || len(record.Warnings) > 0 {
+				t.Fatalf("read %+v from %q", record, line)
+			}
+			assertSameTask(t, record.Task, tt.task)
+			if t.Failed() {
+				t.Logf("written as %q", line)
+			}
+		})
+	}
+}
+
+// Creation and completion dates are the local dates, which differ from the
+// UTC ones late in the evening west of Greenwich.
+func TestTodoTxtWritesLocalDates(t *testing.T) {
+	loc := useLocal(t, "America/Los_Angeles")
+	task := roundTripTask("a1", "Call mom")
+	task.CreatedAt = time.Date(2026, 3, 7, 20, 0, 0, 0, loc)
+	completed := time.Date(2026, 3, 8, 22, 0, 0, 0, loc)
+	task.Status, task.CompletedAt = domain.DoneTask, &completed
+
+	line := FormatTodoTxt(task)
+	if want := "x 2026-03-08 2026-03-07 Call mom id:a1"; line != want {
+		t.Fatalf("FormatTodoTxt = %q, want %q", line, want)
+	}
+
+	record := ParseTodoTxt(line)
+	if got := record.Task.CreatedAt; !got.Equal(time.Date(2026, 3, 7, 0, 0, 0, 0, loc)) {
+		t.Errorf("CreatedAt = %v, want local midnight of 2026-03-07", got)
+	}
+	if got := record.Task.CompletedAt; got == nil || !got.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, loc)) {
+		t.Errorf("CompletedAt = %v, want local midnight of 2026-03-08", got)
+	}
+}
+
+func TestTodoTxtWords(t *testing.T) {
+	task := roundTripTask("a1", "Plan")
+	task.Project = "Acme  Corp"
+	task.Tags = []string{"deep work", "50%", "a%20b"}
+
+	line := FormatTodoTxt(task)
+	for _, want := range []string{"+Acme%20Corp", "@deep%20work", "@50%25", "@a%2520b"} {
+		if !strings.Contains(line, " "+want+" ") {
+			t.Errorf("%q lacks %s", line, want)
+		}
+	}
+
+	record := ParseTodoTxt(line)
+	if record.Task.Project != "Acme Corp" {
+		t.Errorf("Project = %q, want %q", record.Task.Project, "Acme Corp")
+	}
+	if got := strings.Join(record.Task.Tags, "|"); got != "deep work|50%|a%20b" {
+		t.Errorf("Tags = %q, want %q", got, "deep work|50%|a%20b")
+	}
+
+	// Underscores written by other apps stay.
+	record = ParseTodoTxt("Plan +acme_corp @deep_work")
+	if record.Task.Project != "acme_corp" || strings.Join(record.Task.Tags, "|") != "deep_work" {
+		t.Errorf("read project %q and tags %v, want them unchanged", record.Task.Project, record.Task.Tags)
+	}
+}
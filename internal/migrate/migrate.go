@@ -0,0 +1,168 @@
+// Package migrate copies tasks between storage backends.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+// Result counts what Copy did. Target is the number of tasks in the target
+// after the copy; every source task has been checked to be there.
+type Result struct {
+	DryRun   bool `json:"dry_run"`
+	Source   int  `json:"source"`
+	Copied   int  `json:"copied"`
+	Existing int  `json:"existing"`
+	Target   int  `json:"target"`
+}
+
+// Open opens the backend described by spec:
+//
+//	file:DIR          tasks.json in DIR
+//	todotxt:DIR       todo.txt and done.txt in DIR
+//	markdown:FILE     a Markdown checklist
+//	postgres://...    a PostgreSQL database
+//
+// A leading ~ in paths is expanded. The returned closer releases the
+// backend and is never nil.
+func Open(spec string) (repository.TaskRepository, io.Closer, error) {
+	if strings.HasPrefix(spec, "postgres://") || strings.HasPrefix(spec, "postgresql://") {
+		repo, err := repository.NewPostgresTaskRepository(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, repo, nil
+	}
+
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		return nil, nil, fmt.Errorf("invalid backend %q, expected file:DIR, todotxt:DIR, markdown:FILE or a postgres:// URL", spec)
+	}
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var repo repository.TaskRepository
+	switch kind {
+	case "file":
+		repo, err = repository.NewFileTaskRepository(path)
+	case "todotxt":
+		repo, err = repository.NewTodoTxtTaskRepository(path)
+	case "markdown":
+		repo, err = repository.NewMarkdownTaskRepository(path)
+	default:
+		return nil, nil, fmt.Errorf("unknown backend %q", kind)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, io.NopCloser(nil), nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// Pending reports how many tasks of from are missing in to.
+func Pending(ctx context.Context, from, to repository.TaskRepository) (int, error) {
+	result, err := Copy(ctx, from, to, true)
+	if err != nil {
+		return 0, err
+	}
+	return result.Copied, nil
+}
+
+// Copy writes every task of from that to does not have yet, keeping IDs and
+// timestamps. Tasks with an ID already in to are left alone: not every
+// backend keeps full timestamps, so they cannot tell which copy is newer.
+// Running Copy again therefore changes nothing. All writes happen in one
+// transaction on to, after which the target is read back to verify that no
+// task is missing.
+func Copy(ctx context.Context, from, to repository.TaskRepository, dryRun bool) (*Result, error) {
+	tasks, err := from.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+
+	result := &Result{DryRun: dryRun, Source: len(tasks)}
+
+	err = to.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		existing, err := tx.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		ids := make(map[string]bool, len(existing))
+		for _, task := range existing {
+			ids[task.ID] = true
+		}
+
+		for _, task := range tasks {
+			if ids[task.ID] {
+				result.Existing++
+				continue
+			}
+
+			result.Copied++
+			if dryRun {
+				continue
+			}
+			if err := tx.Create(ctx, task); err != nil {
+				return fmt.Errorf("task %s: %w", task.ID, err)
+			}
+		}
+
+		result.Target = len(existing) + result.Copied
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write target: %w", err)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	return result, verify(ctx, tasks, to, result)
+}
+
+func verify(ctx context.Context, tasks []*domain.Task, to repository.TaskRepository, result *Result) error {
+	stored, err := to.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify target: %w", err)
+	}
+	result.Target = len(stored)
+
+	ids := make(map[string]bool, len(stored))
+	for _, task := range stored {
+		ids[task.ID] = true
+	}
+
+	missing := 0
+	for _, task := range tasks {
+		if !ids[task.ID] {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("verification failed: %d of %d tasks are missing in the target", missing, len(tasks))
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package replication
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timestamp is a hybrid logical clock reading. Node breaks ties so that two
+// timestamps are only equal when they come from the same event.
+type Timestamp struct {
+	Wall    int64  `json:"wall"`
+	Logical uint32 `json:"logical"`
+	Node    string `json:"node"`
+}
+
+func (t Timestamp) IsZero() bool {
+	return t.Wall == 0 && t.Logical == 0 && t.Node == ""
+}
+
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.Wall < other.Wall:
+		return -1
+	case t.Wall > other.Wall:
+		return 1
+	case t.Logical < other.Logical:
+		return -1
+	case t.Logical > other.Logical:
+		return 1
+	}
+	return strings.Compare(t.Node, other.Node)
+}
+
+func (t Timestamp) After(other Timestamp) bool {
+	return t.Compare(other) > 0
+}
+
+// DefaultMaxOffset is how far ahead of the local clock a remote timestamp
+// may be. It allows for devices whose clocks are a few minutes off.
+const DefaultMaxOffset = 10 * time.Minute
+
+// ErrClockDrift is returned for a remote timestamp too far in the future.
+// Accepting it would move the clock there for good, so that every later
+// local write would lose to it.
+var ErrClockDrift = errors.New("remote timestamp is too far ahead of the local clock")
+
+type Clock struct {
+	node      string
+	now       func() time.Time
+	maxOffset time.Duration
+	mutex     sync.Mutex
+	last      Timestamp
+}
+
+func NewClock(node string, now func() time.Time) *Clock {
+	if now == nil {
+		now = time.Now
+	}
+	return &Clock{
+		node:      node,
+		now:       now,
+		maxOffset: DefaultMaxOffset,
+	}
+}
+
+// SetMaxOffset changes how far ahead remote timestamps may be.
+func (c *Clock) SetMaxOffset(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.maxOffset = d
+}
+
+// Now returns a timestamp for a local event, strictly greater than every
+// timestamp this clock has issued or observed.
+func (c *Clock) Now() Timestamp {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	wall := c.now().UnixNano()
+	if wall > c.last.Wall {
+		c.last = Timestamp{Wall: wall, Node: c.node}
+	} else {
+		c.last = Timestamp{Wall: c.last.Wall, Logical: c.last.Logical + 1, Node: c.node}
+	}
+
+	return c.last
+}
+
+// Observe advances the clock past a timestamp received from another
+// replica. A timestamp more than the maximum offset ahead of physical time
+// is refused with ErrClockDrift and leaves the clock alone.
+func (c *Clock) Observe(remote Timestamp) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if remote.Wall > c.now().Add(c.maxOffset).UnixNano() {
+		return ErrClockDrift
+	}
+	if remote.Wall > c.last.Wall || (remote.Wall == c.last.Wall && remote.Logical > c.last.Logical) {
+		c.last = Timestamp{Wall: remote.Wall, Logical: remote.Logical, Node: c.node}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const deltaPath = "/replication/delta"
+
+// maxDeltaBytes caps a pushed delta. A delta holds only changed fields, so
+// even a first sync of thousands of tasks stays well under it.
+const maxDeltaBytes = 32 << 20
+
+// HTTPTransport syncs directly with a peer that serves NewHTTPHandler. Both
+// sides must share the same secret.
+type HTTPTransport struct {
+	peerURL string
+	secret  string
+	client  *http.Client
+}
+
+func NewHTTPTransport(peerURL, secret string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPTransport{
+		peerURL: strings.TrimRight(peerURL, "/"),
+		secret:  secret,
+		client:  client,
+	}
+}
+
+func (t *HTTPTransport) authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+t.secret)
+}
+
+func (t *HTTPTransport) Push(ctx context.Context, delta Delta) error {
+	if delta.IsEmpty() {
+		return nil
+	}
+
+	data, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.peerURL+deltaPath, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	t.authorize(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrClockDrift
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer rejected delta: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (t *HTTPTransport) Pull(ctx context.Context, since VersionVector) ([]Delta, error) {
+	version, err := json.Marshal(since)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := t.peerURL + deltaPath + "?since=" + url.QueryEscape(string(version))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.authorize(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer refused delta request: %s", resp.Status)
+	}
+
+	var delta Delta
+	if err := json.NewDecoder(resp.Body).Decode(&delta); err != nil {
+		return nil, err
+	}
+
+	return []Delta{delta}, nil
+}
+
+// NewHTTPHandler exposes replica to HTTPTransport peers that present
+// secret. An empty secret refuses every peer. onMerge is called after a
+// pushed delta has been merged.
+func NewHTTPHandler(replica *Replica, secret string, onMerge func()) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(deltaPath, func(w http.ResponseWriter, r *http.Request) {
+		if !peerAuthorized(r, secret) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="todo-list"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			since := make(VersionVector)
+			if raw := r.URL.Query().Get("since"); raw != "" {
+				if err := json.Unmarshal([]byte(raw), &since); err != nil {
+					http.Error(w, "invalid version vector", http.StatusBadRequest)
+					return
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(replica.DeltaSince(since))
+		case http.MethodPost:
+			var delta Delta
+			body := http.MaxBytesReader(w, r.Body, maxDeltaBytes)
+			if err := json.NewDecoder(body).Decode(&delta); err != nil {
+				http.Error(w, "invalid delta", http.StatusBadRequest)
+				return
+			}
+
+			drift := replica.Merge(delta)
+			if onMerge != nil {
+				onMerge()
+			}
+			if drift != nil {
+				http.Error(w, drift.Error(), http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	return mux
+}
+
+func peerAuthorized(r *http.Request, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
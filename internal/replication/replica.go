@@ -0,0 +1,377 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"todo-list/internal/domain"
+)
+
+// Register is a last-writer-wins register holding the JSON encoding of one
+// task field.
+type Register struct {
+	Value json.RawMessage `json:"value"`
+	Stamp Timestamp       `json:"stamp"`
+}
+
+func (r Register) wins(other Register) bool {
+	if cmp := r.Stamp.Compare(other.Stamp); cmp != 0 {
+		return cmp > 0
+	}
+	return bytes.Compare(r.Value, other.Value) > 0
+}
+
+// Record is the replicated state of a single task: one register per JSON
+// field of domain.Task plus a tombstone register.
+type Record struct {
+	ID      string              `json:"id"`
+	Fields  map[string]Register `json:"fields"`
+	Deleted Register            `json:"deleted"`
+}
+
+func (r *Record) isDeleted() bool {
+	return bytes.Equal(r.Deleted.Value, []byte("true"))
+}
+
+type Delta struct {
+	Origin  string   `json:"origin"`
+	Records []Record `json:"records"`
+}
+
+func (d Delta) IsEmpty() bool {
+	return len(d.Records) == 0
+}
+
+// VersionVector holds the highest timestamp seen from each node.
+type VersionVector map[string]Timestamp
+
+func (v VersionVector) observe(stamp Timestamp) {
+	if stamp.After(v[stamp.Node]) {
+		v[stamp.Node] = stamp
+	}
+}
+
+var (
+	nullValue  = json.RawMessage("null")
+	trueValue  = json.RawMessage("true")
+	falseValue = json.RawMessage("false")
+)
+
+type Replica struct {
+	node    string
+	clock   *Clock
+	mutex   sync.Mutex
+	records map[string]*Record
+	seen    VersionVector
+}
+
+func NewReplica(node string, clock *Clock) *Replica {
+	return &Replica{
+		node:    node,
+		clock:   clock,
+		records: make(map[string]*Record),
+		seen:    make(VersionVector),
+	}
+}
+
+func (r *Replica) Node() string {
+	return r.node
+}
+
+// Put records a local write of task. Only fields whose value changed get a
+// new timestamp, so concurrent edits of different fields both survive.
+func (r *Replica) Put(task *domain.Task) error {
+	fields, err := taskFields(task)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	record := r.record(task.ID)
+	for name := range record.Fields {
+		if _, ok := fields[name]; !ok {
+			fields[name] = nullValue
+		}
+	}
+	r.write(record, fields)
+
+	return nil
+}
+
+// PutChanges records a local write of only the fields of task that differ
+// from previous, the task's JSON as last written to the repository. Fields
+// the replica has since merged from other devices are left as they are.
+func (r *Replica) PutChanges(task *domain.Task, previous string) error {
+	if previous == "" {
+		return r.Put(task)
+	}
+
+	fields, err := taskFields(task)
+	if err != nil {
+		return err
+	}
+	var before map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(previous), &before); err != nil {
+		return err
+	}
+
+	for name := range before {
+		if _, ok := fields[name]; !ok && name != "id" {
+			fields[name] = nullValue
+		}
+	}
+	for name, value := range fields {
+		if old, ok := before[name]; ok && bytes.Equal(old, value) {
+			delete(fields, name)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.write(r.record(task.ID), fields)
+
+	return nil
+}
+
+// record must be called with r.mutex held.
+func (r *Replica) record(id string) *Record {
+	record, exists := r.records[id]
+	if !exists {
+		record = &Record{ID: id, Fields: make(map[string]Register)}
+		r.records[id] = record
+	}
+	return record
+}
+
+// write stamps the fields that differ from record and makes sure the task
+// is live. It must be called with r.mutex held.
+func (r *Replica) write(record *Record, fields map[string]json.RawMessage) {
+	for name, value := range fields {
+		if current, ok := record.Fields[name]; ok && bytes.Equal(current.Value, value) {
+			continue
+		}
+		record.Fields[name] = r.stamp(value)
+	}
+
+	if record.Deleted.Stamp.IsZero() || record.isDeleted() {
+		record.Deleted = r.stamp(falseValue)
+	}
+}
+
+func (r *Replica) Delete(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	record := r.record(id)
+	if !record.isDeleted() {
+		record.Deleted = r.stamp(trueValue)
+	}
+}
+
+// stamp must be called with r.mutex held.
+func (r *Replica) stamp(value json.RawMessage) Register {
+	register := Register{Value: value, Stamp: r.clock.Now()}
+	r.seen.observe(register.Stamp)
+	return register
+}
+
+// Merge applies a delta from another replica. Merging is commutative,
+// associative and idempotent, so replicas converge regardless of delivery
+// order or duplication. Registers stamped too far in the future are left
+// out and not marked as seen, so they are sent again and accepted once the
+// local clock has caught up; Merge then returns ErrClockDrift.
+func (r *Replica) Merge(delta Delta) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var drift error
+	for _, incoming := range delta.Records {
+		record := r.record(incoming.ID)
+
+		for name, register := range incoming.Fields {
+			if err := r.observe(register.Stamp); err != nil {
+				drift = err
+				continue
+			}
+			if current, ok := record.Fields[name]; !ok || register.wins(current) {
+				record.Fields[name] = register
+			}
+		}
+
+		if !incoming.Deleted.Stamp.IsZero() {
+			if err := r.observe(incoming.Deleted.Stamp); err != nil {
+				drift = err
+				continue
+			}
+			if incoming.Deleted.wins(record.Deleted) {
+				record.Deleted = incoming.Deleted
+			}
+		}
+	}
+
+	return drift
+}
+
+// observe must be called with r.mutex held.
+func (r *Replica) observe(stamp Timestamp) error {
+	if err := r.clock.Observe(stamp); err != nil {
+		return err
+	}
+	r.seen.observe(stamp)
+	return nil
+}
+
+// DeltaSince returns every register newer than what since has seen from the
+// register's origin node.
+func (r *Replica) DeltaSince(since VersionVector) Delta {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	newer := func(stamp Timestamp) bool {
+		return !stamp.IsZero() && stamp.After(since[stamp.Node])
+	}
+
+	delta := Delta{Origin: r.node}
+	for _, id := range r.sortedIDs() {
+		record := r.records[id]
+		out := Record{ID: id, Fields: make(map[string]Register)}
+		for name, register := range record.Fields {
+			if newer(register.Stamp) {
+				out.Fields[name] = register
+			}
+		}
+		if newer(record.Deleted.Stamp) {
+			out.Deleted = record.Deleted
+		}
+		if len(out.Fields) > 0 || !out.Deleted.Stamp.IsZero() {
+			delta.Records = append(delta.Records, out)
+		}
+	}
+
+	return delta
+}
+
+func (r *Replica) Version() VersionVector {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	version := make(VersionVector, len(r.seen))
+	for node, stamp := range r.seen {
+		version[node] = stamp
+	}
+	return version
+}
+
+// Tasks materializes the live tasks ordered by ID.
+func (r *Replica) Tasks() ([]*domain.Task, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasks := make([]*domain.Task, 0, len(r.records))
+	for _, id := range r.sortedIDs() {
+		record := r.records[id]
+		if record.isDeleted() || len(record.Fields) == 0 {
+			continue
+		}
+
+		task, err := record.task()
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// sortedIDs must be called with r.mutex held.
+func (r *Replica) sortedIDs() []string {
+	ids := make([]string, 0, len(r.records))
+	for id := range r.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (r *Record) task() (*domain.Task, error) {
+	fields := make(map[string]json.RawMessage, len(r.Fields))
+	for name, register := range r.Fields {
+		fields[name] = register.Value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var task domain.Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, err
+	}
+	task.ID = r.ID
+	// Peers running older versions still send active and completed.
+	task.Status = domain.UpgradeStatus(task.Status)
+
+	return &task, nil
+}
+
+func taskFields(task *domain.Task) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "id")
+
+	return fields, nil
+}
+
+type replicaState struct {
+	Records []*Record     `json:"records"`
+	Seen    VersionVector `json:"seen"`
+}
+
+func (r *Replica) MarshalJSON() ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	state := replicaState{Seen: r.seen}
+	for _, id := range r.sortedIDs() {
+		state.Records = append(state.Records, r.records[id])
+	}
+	return json.Marshal(state)
+}
+
+func (r *Replica) UnmarshalJSON(data []byte) error {
+	var state replicaState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.records = make(map[string]*Record, len(state.Records))
+	for _, record := range state.Records {
+		if record.Fields == nil {
+			record.Fields = make(map[string]Register)
+		}
+		r.records[record.ID] = record
+	}
+	r.seen = make(VersionVector)
+	for _, stamp := range state.Seen {
+		r.seen.observe(stamp)
+		r.clock.Observe(stamp)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+// editingTransport edits a task in the local repository while a pull is in
+// flight, the way a user does when the network is slow.
+type editingTransport struct {
+	remote *Replica
+	repo   repository.TaskRepository
+	edit   func(ctx context.Context, repo repository.TaskRepository)
+}
+
+func (t *editingTransport) Push(ctx context.Context, delta Delta) error {
+	return t.remote.Merge(delta)
+}
+
+func (t *editingTransport) Pull(ctx context.Context, since VersionVector) ([]Delta, error) {
+	if t.edit != nil {
+		t.edit(ctx, t.repo)
+		t.edit = nil
+	}
+	return []Delta{t.remote.DeltaSince(since)}, nil
+}
+
+func TestSyncKeepsEditsMadeDuringPull(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryTaskRepository()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := &domain.Task{ID: "a", Title: "before", Status: domain.TodoTask, Priority: domain.MediumPriority, CreatedAt: created, UpdatedAt: created}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := NewReplica("remote", NewClock("remote", nil))
+	transport := &editingTransport{remote: remote, repo: repo}
+	syncer, err := NewSyncer(NewReplica("local", NewClock("local", nil)), repo, transport, filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syncer.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The other device changes the priority while this one changes the
+	// title.
+	remoteTasks, err := remote.Tasks()
+	if err != nil || len(remoteTasks) != 1 {
+		t.Fatalf("remote has %v after the first sync (%v)", remoteTasks, err)
+	}
+	remoteTasks[0].Priority = domain.HighPriority
+	if err := remote.Put(remoteTasks[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	transport.edit = func(ctx context.Context, repo repository.TaskRepository) {
+		edited, err := repo.GetByID(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		edited.Title = "during pull"
+		if err := repo.Update(ctx, edited); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := syncer.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "during pull" || got.Priority != domain.HighPriority {
+		t.Fatalf("got %q with %s priority, want the local title and the remote priority", got.Title, got.Priority)
+	}
+
+	if err := syncer.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := remote.Tasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "during pull" {
+		t.Fatalf("remote has %+v, want the edit made during the pull", tasks)
+	}
+}
+
+func simTask(id, title string, created time.Time) *domain.Task {
+	return &domain.Task{ID: id, Title: title, Status: domain.TodoTask, Priority: domain.MediumPriority, CreatedAt: created, UpdatedAt: created}
+}
+
+// TestSimulationConverges edits tasks on every replica while links are cut
+// and healed, with messages reordered and duplicated, and checks that all
+// replicas end up with the same tasks once the network heals.
+func TestSimulationConverges(t *testing.T) {
+	priorities := []domain.Priority{domain.LowPriority, domain.MediumPriority, domain.HighPriority}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		sim := NewSimulation(seed, "a", "b", "c")
+		rng := rand.New(rand.NewSource(seed))
+		nodes := sim.Nodes()
+
+		for step := 0; step < 60; step++ {
+			node := nodes[rng.Intn(len(nodes))]
+			replica := sim.Replica(node)
+			id := fmt.Sprintf("task-%d", rng.Intn(8))
+
+			switch rng.Intn(6) {
+			case 0:
+				replica.Delete(id)
+			case 1:
+				sim.Partition(nodes[rng.Intn(len(nodes))], nodes[rng.Intn(len(nodes))])
+			case 2:
+				sim.Heal()
+			default:
+				tasks, err := replica.Tasks()
+				if err != nil {
+					t.Fatal(err)
+				}
+				task := simTask(id, fmt.Sprintf("%s by %s", id, node), sim.now)
+				for _, existing := range tasks {
+					if existing.ID == id {
+						task = existing
+					}
+				}
+				if rng.Intn(2) == 0 {
+					task.Title = fmt.Sprintf("edit %d by %s", step, node)
+				} else {
+					task.Priority = priorities[rng.Intn(len(priorities))]
+				}
+				if err := replica.Put(task); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// A zero advance now and then exercises the logical clock.
+			sim.Advance(time.Duration(rng.Intn(3)) * time.Millisecond)
+			if rng.Intn(3) == 0 {
+				sim.Gossip(1)
+			}
+		}
+
+		sim.Heal()
+		sim.Gossip(2)
+		if ok, diff := sim.Converged(); !ok {
+			t.Fatalf("seed %d: %s", seed, diff)
+		}
+	}
+}
+
+func TestSimulationKeepsConcurrentFieldEdits(t *testing.T) {
+	sim := NewSimulation(7, "a", "b")
+	task := simTask("x", "first", sim.now)
+	if err := sim.Replica("a").Put(task); err != nil {
+		t.Fatal(err)
+	}
+	sim.Gossip(1)
+
+	sim.Partition("a", "b")
+	sim.Advance(time.Second)
+	onA, onB := *task, *task
+	onA.Title = "renamed on a"
+	onB.Priority = domain.HighPriority
+	if err := sim.Replica("a").Put(&onA); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Replica("b").Put(&onB); err != nil {
+		t.Fatal(err)
+	}
+	sim.Gossip(1)
+
+	sim.Heal()
+	sim.Gossip(1)
+	if ok, diff := sim.Converged(); !ok {
+		t.Fatal(diff)
+	}
+	tasks, err := sim.Replica("b").Tasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tasks[0].Title != "renamed on a" || tasks[0].Priority != domain.HighPriority {
+		t.Fatalf("got %q with %s priority, want both edits", tasks[0].Title, tasks[0].Priority)
+	}
+}
+
+func TestFolderTransportRemembersReadsAndPrunes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, err := NewFolderTransport(dir, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFolderTransport(dir, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replica := NewReplica("a", NewClock("a", nil))
+	if err := replica.Put(simTask("x", "x", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Push(ctx, replica.DeltaSince(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	deltas, err := b.Pull(ctx, nil)
+	if err != nil || len(deltas) != 1 {
+		t.Fatalf("first pull read %d deltas (%v), want 1", len(deltas), err)
+	}
+
+	// Until b acknowledges the delta, say because it crashed before storing
+	// it, a restarted transport reads it again.
+	b, err = NewFolderTransport(dir, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deltas, err := b.Pull(ctx, nil); err != nil || len(deltas) != 1 {
+		t.Fatalf("pull after a crash read %d deltas (%v), want 1", len(deltas), err)
+	}
+	if err := b.Acknowledge(ctx, Delta{}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = NewFolderTransport(dir, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deltas, err := b.Pull(ctx, nil); err != nil || len(deltas) != 0 {
+		t.Fatalf("pull after restart read %d deltas (%v), want 0", len(deltas), err)
+	}
+
+	// Once b has read it, a replaces its delta with a snapshot.
+	if _, err := a.Pull(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Acknowledge(ctx, replica.DeltaSince(nil)); err != nil {
+		t.Fatal(err)
+	}
+	names, err := deltaNames(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || !strings.HasSuffix(names[0], snapshotSuffix) {
+		t.Fatalf("a has %v after every peer read its delta, want a snapshot", names)
+	}
+}
+
+func newFolderSyncer(t *testing.T, dir, node string) (*Syncer, repository.TaskRepository) {
+	t.Helper()
+	transport, err := NewFolderTransport(dir, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewMemoryTaskRepository()
+	syncer, err := NewSyncer(NewReplica(node, NewClock(node, nil)), repo, transport, filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return syncer, repo
+}
+
+// A device joining the shared folder after the others pruned their deltas
+// still gets every task.
+func TestFolderSyncLateJoinerGetsEveryTask(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	a, aRepo := newFolderSyncer(t, dir, "a")
+	b, _ := newFolderSyncer(t, dir, "b")
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := aRepo.Create(ctx, simTask("x", "from a", created)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		for _, syncer := range []*Syncer{a, b} {
+			if err := syncer.SyncOnce(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	names, err := deltaNames(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, snapshotSuffix) {
+			t.Fatalf("a still has delta %s after b read it", name)
+		}
+	}
+
+	c, cRepo := newFolderSyncer(t, dir, "c")
+	if err := c.SyncOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := cRepo.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "from a" {
+		t.Fatalf("late joiner has %+v, want the task from a", tasks)
+	}
+}
@@ -0,0 +1,283 @@
+package replication
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+// LoadNodeID returns the replica id stored in dataDir, creating one on first
+// use.
+func LoadNodeID(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, "node_id")
+
+	data, err := os.ReadFile(path)
+	if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	nodeID := hex.EncodeToString(bytes)
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(nodeID+"\n"), 0644); err != nil {
+		return "", err
+	}
+
+	return nodeID, nil
+}
+
+type syncerState struct {
+	Replica *Replica          `json:"replica"`
+	Written map[string]string `json:"written"`
+	Pushed  VersionVector     `json:"pushed"`
+}
+
+// Syncer keeps a TaskRepository and a Replica in step: local edits made
+// through the repository are recorded in the replica, deltas are exchanged
+// over the transport and the merged state is written back.
+type Syncer struct {
+	replica   *Replica
+	repo      repository.TaskRepository
+	transport Transport
+	statePath string
+
+	mutex   sync.Mutex
+	written map[string]string
+	pushed  VersionVector
+	trigger chan struct{}
+}
+
+func NewSyncer(replica *Replica, repo repository.TaskRepository, transport Transport, statePath string) (*Syncer, error) {
+	s := &Syncer{
+		replica:   replica,
+		repo:      repo,
+		transport: transport,
+		statePath: statePath,
+		written:   make(map[string]string),
+		pushed:    make(VersionVector),
+		trigger:   make(chan struct{}, 1),
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		state := syncerState{Replica: replica}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		if state.Written != nil {
+			s.written = state.Written
+		}
+		if state.Pushed != nil {
+			s.pushed = state.Pushed
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Syncer) Replica() *Replica {
+	return s.replica
+}
+
+// Trigger schedules a sync on the next Run iteration.
+func (s *Syncer) Trigger() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SyncOnce(ctx); err != nil {
+			println("Replication sync failed:", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.trigger:
+		}
+	}
+}
+
+func (s *Syncer) SyncOnce(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.captureLocal(ctx, s.repo); err != nil {
+		return err
+	}
+
+	// A peer refusing part of the delta for clock drift gets all of it again
+	// next time.
+	switch err := s.transport.Push(ctx, s.replica.DeltaSince(s.pushed)); {
+	case errors.Is(err, ErrClockDrift):
+		println("Replication peer refused changes:", err.Error())
+	case err != nil:
+		return err
+	default:
+		s.pushed = s.replica.Version()
+	}
+
+	deltas, err := s.transport.Pull(ctx, s.replica.Version())
+	if err != nil {
+		return err
+	}
+	for _, delta := range deltas {
+		if err := s.replica.Merge(delta); err != nil {
+			println("Replication skipped changes from", delta.Origin+":", err.Error())
+		}
+	}
+
+	// Edits made while the network round trip ran are captured again, in the
+	// same transaction as the merged state is written, so none are lost.
+	err = s.repo.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := s.captureLocal(ctx, tx); err != nil {
+			return err
+		}
+		return s.applyToRepository(ctx, tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.saveState(); err != nil {
+		return err
+	}
+
+	// Only now that the merged deltas are stored may the transport forget
+	// them.
+	if ack, ok := s.transport.(Acknowledger); ok {
+		return ack.Acknowledge(ctx, s.replica.DeltaSince(nil))
+	}
+	return nil
+}
+
+// captureLocal records every change made to the repository since the last
+// sync as a local write.
+func (s *Syncer) captureLocal(ctx context.Context, repo repository.TaskRepository) error {
+	tasks, err := repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		fingerprint, err := fingerprint(task)
+		if err != nil {
+			return err
+		}
+		current[task.ID] = fingerprint
+
+		if s.written[task.ID] == fingerprint {
+			continue
+		}
+		if err := s.replica.PutChanges(task, s.written[task.ID]); err != nil {
+			return err
+		}
+	}
+
+	for id := range s.written {
+		if _, exists := current[id]; !exists {
+			s.replica.Delete(id)
+		}
+	}
+
+	s.written = current
+	return nil
+}
+
+// applyToRepository writes the tasks the merge changed, leaving the rest
+// alone.
+func (s *Syncer) applyToRepository(ctx context.Context, repo repository.TaskRepository) error {
+	tasks, err := s.replica.Tasks()
+	if err != nil {
+		return err
+	}
+
+	live := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		fingerprint, err := fingerprint(task)
+		if err != nil {
+			return err
+		}
+		live[task.ID] = fingerprint
+
+		if s.written[task.ID] == fingerprint {
+			continue
+		}
+
+		err = repo.Update(ctx, task)
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			err = repo.Create(ctx, task)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for id := range s.written {
+		if _, exists := live[id]; exists {
+			continue
+		}
+		if err := repo.Delete(ctx, id); err != nil && !errors.Is(err, repository.ErrTaskNotFound) {
+			return err
+		}
+	}
+
+	s.written = live
+	return nil
+}
+
+func (s *Syncer) saveState() error {
+	data, err := json.Marshal(syncerState{
+		Replica: s.replica,
+		Written: s.written,
+		Pushed:  s.pushed,
+	})
+	if err != nil {
+		return err
+	}
+
+	tempFile := s.statePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile, s.statePath)
+}
+
+func fingerprint(task *domain.Task) (string, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
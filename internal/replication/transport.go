@@ -0,0 +1,319 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport moves deltas between replicas. Push publishes local changes,
+// Pull fetches changes the local replica has not seen yet.
+type Transport interface {
+	Push(ctx context.Context, delta Delta) error
+	Pull(ctx context.Context, since VersionVector) ([]Delta, error)
+}
+
+// Acknowledger is implemented by transports that must hear when pulled
+// deltas are safely stored. state is the replica's full state at that
+// point.
+type Acknowledger interface {
+	Acknowledge(ctx context.Context, state Delta) error
+}
+
+// NopTransport is used by replicas that only serve peers and never initiate
+// an exchange themselves.
+type NopTransport struct{}
+
+func (NopTransport) Push(ctx context.Context, delta Delta) error {
+	return nil
+}
+
+func (NopTransport) Pull(ctx context.Context, since VersionVector) ([]Delta, error) {
+	return nil, nil
+}
+
+// FolderTransport exchanges deltas through a directory shared between
+// devices (Dropbox, Syncthing, a network drive...). Each replica writes its
+// deltas into its own subdirectory and reads everyone else's. The deltas it
+// has stored are listed in a cursor file next to its own, which survives
+// restarts and tells the other replicas which of their deltas it is done
+// with. Shared folders may deliver files out of order, so the cursor lists
+// every delta read rather than the newest.
+//
+// Deltas every replica has read are replaced by a snapshot of the full
+// state, so a replica joining later still gets every task.
+type FolderTransport struct {
+	dir   string
+	node  string
+	mutex sync.Mutex
+	// cursor holds the deltas stored from each node that still exist;
+	// pending is the cursor once the last pull is stored.
+	cursor  map[string][]string
+	pending map[string][]string
+}
+
+const (
+	cursorFile     = "cursor"
+	snapshotSuffix = ".snapshot.json"
+)
+
+func NewFolderTransport(dir, node string) (*FolderTransport, error) {
+	if err := os.MkdirAll(filepath.Join(dir, node), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sync folder: %w", err)
+	}
+
+	cursor, err := readCursor(filepath.Join(dir, node))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FolderTransport{
+		dir:    dir,
+		node:   node,
+		cursor: cursor,
+	}, nil
+}
+
+func readCursor(nodeDir string) (map[string][]string, error) {
+	cursor := make(map[string][]string)
+	data, err := os.ReadFile(filepath.Join(nodeDir, cursorFile))
+	if os.IsNotExist(err) {
+		return cursor, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to read sync cursor in %s: %w", nodeDir, err)
+	}
+	return cursor, nil
+}
+
+func (t *FolderTransport) Push(ctx context.Context, delta Delta) error {
+	if delta.IsEmpty() {
+		return nil
+	}
+
+	data, err := json.Marshal(delta)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	names, err := deltaNames(filepath.Join(t.dir, t.node))
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(t.dir, t.node, nextDeltaName(names))
+
+	return writeFileAtomic(path, data)
+}
+
+// deltaNames lists the deltas in a node's directory, oldest first.
+func deltaNames(nodeDir string) ([]string, error) {
+	files, err := os.ReadDir(nodeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".json") {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// nextDeltaName names a delta after the current time, and after the newest
+// delta already written, so that names sort in the order deltas were written.
+func nextDeltaName(names []string) string {
+	next := time.Now().UnixNano()
+	if len(names) > 0 {
+		newest := names[len(names)-1]
+		if len(newest) >= 20 {
+			if last, err := strconv.ParseInt(newest[:20], 10, 64); err == nil && last >= next {
+				next = last + 1
+			}
+		}
+	}
+	return fmt.Sprintf("%020d.json", next)
+}
+
+func (t *FolderTransport) Pull(ctx context.Context, since VersionVector) ([]Delta, error) {
+	nodes, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var deltas []Delta
+	pending := make(map[string][]string, len(t.cursor))
+	for _, node := range nodes {
+		if !node.IsDir() || node.Name() == t.node {
+			continue
+		}
+
+		names, err := deltaNames(filepath.Join(t.dir, node.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		read := make(map[string]bool, len(t.cursor[node.Name()]))
+		for _, name := range t.cursor[node.Name()] {
+			read[name] = true
+		}
+		kept := make([]string, 0, len(names))
+		for _, name := range names {
+			if read[name] {
+				kept = append(kept, name)
+				continue
+			}
+
+			path := filepath.Join(t.dir, node.Name(), name)
+			data, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var delta Delta
+			if err := json.Unmarshal(data, &delta); err != nil {
+				return nil, fmt.Errorf("failed to read delta %s: %w", path, err)
+			}
+
+			deltas = append(deltas, delta)
+			kept = append(kept, name)
+		}
+
+		pending[node.Name()] = kept
+	}
+	t.pending = pending
+
+	return deltas, nil
+}
+
+// Acknowledge records the deltas of the last pull as read, so they are not
+// read again and their writers may delete them, and then compacts this
+// node's own deltas. Until then a crash only means reading them twice.
+func (t *FolderTransport) Acknowledge(ctx context.Context, state Delta) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.pending != nil && !maps.EqualFunc(t.pending, t.cursor, slices.Equal) {
+		data, err := json.Marshal(t.pending)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(t.dir, t.node, cursorFile), data); err != nil {
+			return err
+		}
+		t.cursor = t.pending
+	}
+	t.pending = nil
+
+	return t.prune(state)
+}
+
+// prune deletes this node's deltas that every other node has read, once a
+// newer snapshot holds what they did. It writes that snapshot from state
+// when there is none yet.
+func (t *FolderTransport) prune(state Delta) error {
+	nodes, err := os.ReadDir(t.dir)
+	if err != nil {
+		return err
+	}
+
+	var peers []map[string]bool
+	for _, node := range nodes {
+		if !node.IsDir() || node.Name() == t.node {
+			continue
+		}
+
+		cursor, err := readCursor(filepath.Join(t.dir, node.Name()))
+		if err != nil {
+			return err
+		}
+		read := make(map[string]bool, len(cursor[t.node]))
+		for _, name := range cursor[t.node] {
+			read[name] = true
+		}
+		peers = append(peers, read)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+
+	names, err := deltaNames(filepath.Join(t.dir, t.node))
+	if err != nil {
+		return err
+	}
+	snapshot := ""
+	for _, name := range names {
+		if strings.HasSuffix(name, snapshotSuffix) {
+			snapshot = name
+		}
+	}
+
+	var acknowledged []string
+	for _, name := range names {
+		read := true
+		for _, peer := range peers {
+			read = read && peer[name]
+		}
+		if read && name != snapshot {
+			acknowledged = append(acknowledged, name)
+		}
+	}
+	if len(acknowledged) == 0 {
+		return nil
+	}
+
+	// Deltas written after the snapshot need a new one first. It holds
+	// them all: they were pushed before this state was taken.
+	if acknowledged[len(acknowledged)-1] > snapshot {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(nextDeltaName(names), ".json") + snapshotSuffix
+		if err := writeFileAtomic(filepath.Join(t.dir, t.node, name), data); err != nil {
+			return err
+		}
+		if snapshot != "" {
+			acknowledged = append(acknowledged, snapshot)
+		}
+	}
+
+	for _, name := range acknowledged {
+		if err := os.Remove(filepath.Join(t.dir, t.node, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempFile, path)
+}
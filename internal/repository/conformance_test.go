@@ -0,0 +1,134 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+	"todo-list/internal/repository/repotest"
+)
+
+// postgresDSNEnv names the database the Postgres backend is tested against.
+// Its tasks are deleted before every subtest, so never point it at real
+// data.
+const postgresDSNEnv = "TODOLIST_TEST_POSTGRES_DSN"
+
+func newFileRepo(t *testing.T) repository.TaskRepository {
+	repo, err := repository.NewFileTaskRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func newHybridRepo(t *testing.T) repository.TaskRepository {
+	remote := repository.NewMemoryTaskRepository()
+	repo, err := repository.NewHybridTaskRepository(repository.NewMemoryTaskRepository(), t.TempDir(), func() (repository.TaskRepository, error) {
+		return remote, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func TestMemoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.TaskRepository {
+		return repository.NewMemoryTaskRepository()
+	})
+}
+
+func TestFileConformance(t *testing.T) {
+	repotest.Run(t, newFileRepo)
+}
+
+func TestHybridConformance(t *testing.T) {
+	repotest.Run(t, newHybridRepo)
+}
+
+// A failing nested transaction must not queue its writes for the remote
+// either.
+func TestHybridNestedTransactionQueuesNothing(t *testing.T) {
+	ctx := context.Background()
+	repo := newHybridRepo(t).(*repository.HybridTaskRepository)
+
+	err := repo.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := tx.Create(ctx, &domain.Task{ID: "kept", Title: "kept", Status: domain.TodoTask, Priority: domain.MediumPriority}); err != nil {
+			return err
+		}
+		tx.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+			if err := tx.Create(ctx, &domain.Task{ID: "dropped", Title: "dropped", Status: domain.TodoTask, Priority: domain.MediumPriority}); err != nil {
+				return err
+			}
+			return errors.New("abort")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := repo.Status().Pending; pending != 1 {
+		t.Fatalf("%d changes queued, want only the one that committed", pending)
+	}
+}
+
+func TestTodoTxtConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.TaskRepository {
+		repo, err := repository.NewTodoTxtTaskRepository(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
+
+func TestMarkdownConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.TaskRepository {
+		repo, err := repository.NewMarkdownTaskRepository(filepath.Join(t.TempDir(), "notes", "tasks.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
+
+func TestPostgresConformance(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("set %s to run against PostgreSQL", postgresDSNEnv)
+	}
+
+	repotest.Run(t, func(t *testing.T) repository.TaskRepository {
+		repo, err := repository.NewPostgresTaskRepository(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { repo.Close() })
+
+		ctx := context.Background()
+		tasks, err := repo.GetAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range tasks {
+			if err := repo.Delete(ctx, task.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return repo
+	})
+}
+
+// The stress tests only prove anything under the race detector:
+// go test -race ./internal/repository/
+func TestMemoryStress(t *testing.T) {
+	repotest.Stress(t, repository.NewMemoryTaskRepository(), 8, 500)
+}
+
+func TestFileStress(t *testing.T) {
+	repotest.Stress(t, newFileRepo(t), 8, 100)
+}
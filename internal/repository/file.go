@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,12 +10,26 @@ import (
 	"time"
 
 	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
 )
 
+// taskStore persists the complete task set of a FileTaskRepository.
+type taskStore interface {
+	load() ([]*domain.Task, error)
+	save(tasks []*domain.Task) error
+}
+
+// idAssigner is implemented by stores whose files can contain tasks written
+// by other tools without an ID. The repository saves right after loading
+// such files so the IDs it assigned stay stable across restarts.
+type idAssigner interface {
+	assignedIDs() bool
+}
+
 type FileTaskRepository struct {
-	filePath string
-	tasks    map[string]*domain.Task
-	mutex    sync.RWMutex
+	store taskStore
+	tasks map[string]*domain.Task
+	mutex sync.RWMutex
 }
 
 func NewFileTaskRepository(dataDir string) (*FileTaskRepository, error) {
@@ -24,39 +37,34 @@ func NewFileTaskRepository(dataDir string) (*FileTaskRepository, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	filePath := filepath.Join(dataDir, "tasks.json")
+	return newFileTaskRepository(jsonStore{filePath: filepath.Join(dataDir, "tasks.json")})
+}
 
+func newFileTaskRepository(store taskStore) (*FileTaskRepository, error) {
 	repo := &FileTaskRepository{
-		filePath: filePath,
-		tasks:    make(map[string]*domain.Task),
+		store: store,
+		tasks: make(map[string]*domain.Task),
 	}
 
 	if err := repo.loadFromFile(); err != nil {
 		return nil, fmt.Errorf("failed to load tasks from file: %w", err)
 	}
 
+	if assigner, ok := store.(idAssigner); ok && assigner.assignedIDs() {
+		if err := repo.saveToFile(); err != nil {
+			return nil, err
+		}
+	}
+
 	return repo, nil
 }
 
 func (r *FileTaskRepository) loadFromFile() error {
-	if _, err := os.Stat(r.filePath); os.IsNotExist(err) {
-		return nil
-	}
-
-	data, err := os.ReadFile(r.filePath)
+	tasks, err := r.store.load()
 	if err != nil {
 		return err
 	}
 
-	if len(data) == 0 {
-		return nil
-	}
-
-	var tasks []*domain.Task
-	if err := json.Unmarshal(data, &tasks); err != nil {
-		return err
-	}
-
 	r.tasks = make(map[string]*domain.Task)
 	for _, task := range tasks {
 		r.tasks[task.ID] = task
@@ -72,28 +80,84 @@ func (r *FileTaskRepository) saveToFile() error {
 	}
 
 	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
 	})
 
-	data, err := json.MarshalIndent(tasks, "", "  ")
+	return r.store.save(tasks)
+}
+
+// jsonStore keeps tasks in a single file as a versioned interchange
+// envelope. Files of older versions, including the bare array written before
+// the envelope existed, are upgraded on load and rewritten on the next save.
+type jsonStore struct {
+	filePath string
+}
+
+func (s jsonStore) load() ([]*domain.Task, error) {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	envelope, err := interchange.UnmarshalEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope.Tasks, nil
+}
+
+func (s jsonStore) save(tasks []*domain.Task) error {
+	data, err := interchange.MarshalEnvelope(tasks, time.Now())
 	if err != nil {
 		return err
 	}
 
-	tempFile := r.filePath + ".tmp"
+	return writeFileAtomic(s.filePath, data)
+}
+
+// writeFileAtomic replaces path via a temporary file so readers never see a
+// half-written file.
+func writeFileAtomic(path string, data []byte) error {
+	tempFile := path + ".tmp"
 	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
 		return err
 	}
 
-	return os.Rename(tempFile, r.filePath)
+	return nil
 }
 
 func (r *FileTaskRepository) Create(ctx context.Context, task *domain.Task) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.tasks[task.ID] = task
-	return r.saveToFile()
+	if _, exists := r.tasks[task.ID]; exists {
+		return fmt.Errorf("task with id %s %w", task.ID, ErrTaskExists)
+	}
+
+	r.tasks[task.ID] = task.Clone()
+	if err := r.saveToFile(); err != nil {
+		delete(r.tasks, task.ID)
+		return err
+	}
+
+	return nil
 }
 
 func (r *FileTaskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
@@ -102,10 +166,10 @@ func (r *FileTaskRepository) GetByID(ctx context.Context, id string) (*domain.Ta
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("task with id %s not found", id)
+		return nil, fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
 	}
 
-	return task, nil
+	return task.Clone(), nil
 }
 
 func (r *FileTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, error) {
@@ -114,12 +178,10 @@ func (r *FileTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, error)
 
 	tasks := make([]*domain.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		tasks = append(tasks, task)
+		tasks = append(tasks, task.Clone())
 	}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -131,13 +193,11 @@ func (r *FileTaskRepository) GetByStatus(ctx context.Context, status domain.Task
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
 		if task.Status == status {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -149,13 +209,11 @@ func (r *FileTaskRepository) GetByPriority(ctx context.Context, priority domain.
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
 		if task.Priority == priority {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -166,14 +224,12 @@ func (r *FileTaskRepository) GetByDateRange(ctx context.Context, from, to time.T
 
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
-		if task.CreatedAt.After(from) && task.CreatedAt.Before(to) {
-			tasks = append(tasks, task)
+		if !task.CreatedAt.Before(from) && task.CreatedAt.Before(to) {
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -182,22 +238,55 @@ func (r *FileTaskRepository) Update(ctx context.Context, task *domain.Task) erro
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.tasks[task.ID]; !exists {
-		return fmt.Errorf("task with id %s not found", task.ID)
+	previous, exists := r.tasks[task.ID]
+	if !exists {
+		return fmt.Errorf("task with id %s %w", task.ID, ErrTaskNotFound)
 	}
 
-	r.tasks[task.ID] = task
-	return r.saveToFile()
+	r.tasks[task.ID] = task.Clone()
+	if err := r.saveToFile(); err != nil {
+		r.tasks[task.ID] = previous
+		return err
+	}
+
+	return nil
 }
 
 func (r *FileTaskRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.tasks[id]; !exists {
-		return fmt.Errorf("task with id %s not found", id)
+	previous, exists := r.tasks[id]
+	if !exists {
+		return fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
 	}
 
 	delete(r.tasks, id)
-	return r.saveToFile()
+	if err := r.saveToFile(); err != nil {
+		r.tasks[id] = previous
+		return err
+	}
+
+	return nil
+}
+
+// WithTx stages every write in memory and saves the file once when fn
+// succeeds.
+func (r *FileTaskRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TaskRepository) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	staged := stage(r.tasks)
+	if err := fn(ctx, staged); err != nil {
+		return err
+	}
+
+	previous := r.tasks
+	r.tasks = staged.tasks
+	if err := r.saveToFile(); err != nil {
+		r.tasks = previous
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+type SyncState string
+
+const (
+	SyncDisabled SyncState = "disabled"
+	SyncOnline   SyncState = "online"
+	SyncOffline  SyncState = "offline"
+	SyncSyncing  SyncState = "syncing"
+)
+
+type SyncStatus struct {
+	State        SyncState  `json:"state"`
+	Pending      int        `json:"pending"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+type outboxOp string
+
+const (
+	outboxUpsert outboxOp = "upsert"
+	outboxDelete outboxOp = "delete"
+)
+
+type outboxEntry struct {
+	Op       outboxOp     `json:"op"`
+	TaskID   string       `json:"task_id"`
+	Task     *domain.Task `json:"task,omitempty"`
+	QueuedAt time.Time    `json:"queued_at"`
+}
+
+type hybridState struct {
+	Outbox       []outboxEntry `json:"outbox"`
+	RemoteIDs    []string      `json:"remote_ids"`
+	LastSyncedAt *time.Time    `json:"last_synced_at,omitempty"`
+}
+
+// HybridTaskRepository serves every read from a local repository and queues
+// mutations in a persistent outbox that is replayed against the remote
+// repository whenever it is reachable. Conflicts are resolved last-writer-wins
+// on UpdatedAt.
+type HybridTaskRepository struct {
+	local     TaskRepository
+	connect   func() (TaskRepository, error)
+	statePath string
+
+	mutex        sync.Mutex
+	remote       TaskRepository
+	outbox       []outboxEntry
+	remoteIDs    map[string]bool
+	lastSyncedAt *time.Time
+	lastError    string
+	syncing      bool
+
+	syncMutex sync.Mutex
+	trigger   chan struct{}
+
+	// recorded is set on the transaction-scoped view handed to WithTx
+	// callbacks; entries are collected there and enqueued on commit.
+	recorded *[]outboxEntry
+}
+
+func NewHybridTaskRepository(local TaskRepository, dataDir string, connect func() (TaskRepository, error)) (*HybridTaskRepository, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &HybridTaskRepository{
+		local:     local,
+		connect:   connect,
+		statePath: filepath.Join(dataDir, "sync.json"),
+		remoteIDs: make(map[string]bool),
+		trigger:   make(chan struct{}, 1),
+	}
+
+	if err := repo.loadState(); err != nil {
+		return nil, fmt.Errorf("failed to load sync state: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *HybridTaskRepository) loadState() error {
+	data, err := os.ReadFile(r.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	var state hybridState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	r.outbox = state.Outbox
+	for _, entry := range r.outbox {
+		if entry.Task != nil {
+			entry.Task.Status = domain.UpgradeStatus(entry.Task.Status)
+		}
+	}
+	r.lastSyncedAt = state.LastSyncedAt
+	for _, id := range state.RemoteIDs {
+		r.remoteIDs[id] = true
+	}
+
+	return nil
+}
+
+// saveState must be called with r.mutex held.
+func (r *HybridTaskRepository) saveState() error {
+	state := hybridState{
+		Outbox:       r.outbox,
+		RemoteIDs:    make([]string, 0, len(r.remoteIDs)),
+		LastSyncedAt: r.lastSyncedAt,
+	}
+	for id := range r.remoteIDs {
+		state.RemoteIDs = append(state.RemoteIDs, id)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile := r.statePath + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile, r.statePath)
+}
+
+func (r *HybridTaskRepository) enqueue(entries ...outboxEntry) error {
+	if r.recorded != nil {
+		*r.recorded = append(*r.recorded, entries...)
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, entry := range entries {
+		// A newer snapshot or a delete supersedes any pending upsert of the same task.
+		pending := r.outbox[:0]
+		for _, queued := range r.outbox {
+			if queued.TaskID == entry.TaskID && queued.Op == outboxUpsert {
+				continue
+			}
+			pending = append(pending, queued)
+		}
+		r.outbox = append(pending, entry)
+	}
+
+	if err := r.saveState(); err != nil {
+		return err
+	}
+
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (r *HybridTaskRepository) Create(ctx context.Context, task *domain.Task) error {
+	if err := r.local.Create(ctx, task); err != nil {
+		return err
+	}
+
+	return r.enqueue(outboxEntry{Op: outboxUpsert, TaskID: task.ID, Task: task.Clone(), QueuedAt: time.Now()})
+}
+
+func (r *HybridTaskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	return r.local.GetByID(ctx, id)
+}
+
+func (r *HybridTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, error) {
+	return r.local.GetAll(ctx)
+}
+
+// GetAllSorted lets the remote sort while it is online and holds the same
+// tasks as the local repository, and sorts the local tasks otherwise.
+func (r *HybridTaskRepository) GetAllSorted(ctx context.Context, spec domain.SortSpec, locale domain.SortLocale) ([]*domain.Task, error) {
+	tasks, err := r.local.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	lister, online := r.remote.(SortedLister)
+	online = online && len(r.outbox) == 0 && !r.syncing
+	r.mutex.Unlock()
+
+	if online && r.recorded == nil {
+		sorted, err := lister.GetAllSorted(ctx, spec, locale)
+		if err == nil && sameTasks(tasks, sorted) {
+			return sorted, nil
+		}
+	}
+
+	domain.SortTasks(tasks, spec, locale)
+	return tasks, nil
+}
+
+// sameTasks reports whether both lists hold the same versions of the same
+// tasks, in any order.
+func sameTasks(a, b []*domain.Task) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	updated := make(map[string]time.Time, len(a))
+	for _, task := range a {
+		updated[task.ID] = task.UpdatedAt
+	}
+	for _, task := range b {
+		at, ok := updated[task.ID]
+		if !ok || !at.Equal(task.UpdatedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *HybridTaskRepository) GetByStatus(ctx context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
+	return r.local.GetByStatus(ctx, status)
+}
+
+func (r *HybridTaskRepository) GetByPriority(ctx context.Context, priority domain.Priority) ([]*domain.Task, error) {
+	return r.local.GetByPriority(ctx, priority)
+}
+
+func (r *HybridTaskRepository) GetByDateRange(ctx context.Context, from, to time.Time) ([]*domain.Task, error) {
+	return r.local.GetByDateRange(ctx, from, to)
+}
+
+func (r *HybridTaskRepository) Update(ctx context.Context, task *domain.Task) error {
+	if err := r.local.Update(ctx, task); err != nil {
+		return err
+	}
+
+	return r.enqueue(outboxEntry{Op: outboxUpsert, TaskID: task.ID, Task: task.Clone(), QueuedAt: time.Now()})
+}
+
+func (r *HybridTaskRepository) Delete(ctx context.Context, id string) error {
+	if err := r.local.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return r.enqueue(outboxEntry{Op: outboxDelete, TaskID: id, QueuedAt: time.Now()})
+}
+
+// WithTx runs fn inside a transaction of the local repository and queues the
+// resulting mutations only once it has committed. A nested call stages its
+// own writes and hands them to the surrounding transaction on success, so a
+// failing inner call leaves nothing behind.
+func (r *HybridTaskRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TaskRepository) error) error {
+	var entries []outboxEntry
+	err := r.local.WithTx(ctx, func(ctx context.Context, tx TaskRepository) error {
+		return fn(ctx, &HybridTaskRepository{local: tx, recorded: &entries})
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.enqueue(entries...)
+}
+
+func (r *HybridTaskRepository) Status() SyncStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := SyncStatus{
+		State:        SyncOffline,
+		Pending:      len(r.outbox),
+		LastSyncedAt: r.lastSyncedAt,
+		LastError:    r.lastError,
+	}
+
+	switch {
+	case r.syncing:
+		status.State = SyncSyncing
+	case r.remote != nil:
+		status.State = SyncOnline
+	}
+
+	return status
+}
+
+// Run syncs immediately and then every interval, or sooner when a local
+// mutation is queued, until ctx is cancelled.
+func (r *HybridTaskRepository) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = r.Sync(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+func (r *HybridTaskRepository) Sync(ctx context.Context) error {
+	r.syncMutex.Lock()
+	defer r.syncMutex.Unlock()
+
+	r.mutex.Lock()
+	remote := r.remote
+	r.mutex.Unlock()
+
+	if remote == nil {
+		connected, err := r.connect()
+		if err != nil {
+			r.finishSync(nil, err)
+			return err
+		}
+		remote = connected
+	}
+
+	r.mutex.Lock()
+	r.remote = remote
+	r.syncing = true
+	r.mutex.Unlock()
+
+	err := r.flushOutbox(ctx, remote)
+	if err == nil {
+		err = r.pullRemote(ctx, remote)
+	}
+
+	r.finishSync(remote, err)
+	return err
+}
+
+func (r *HybridTaskRepository) finishSync(remote TaskRepository, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.syncing = false
+
+	if err != nil {
+		// Drop the connection so the next run reconnects from scratch.
+		if closer, ok := remote.(io.Closer); ok {
+			closer.Close()
+		}
+		r.remote = nil
+		r.lastError = err.Error()
+		return
+	}
+
+	now := time.Now()
+	r.lastSyncedAt = &now
+	r.lastError = ""
+	r.saveState()
+}
+
+func (r *HybridTaskRepository) flushOutbox(ctx context.Context, remote TaskRepository) error {
+	for {
+		r.mutex.Lock()
+		if len(r.outbox) == 0 {
+			r.mutex.Unlock()
+			return nil
+		}
+		entry := r.outbox[0]
+		r.mutex.Unlock()
+
+		if err := r.replay(ctx, remote, entry); err != nil {
+			return err
+		}
+
+		r.mutex.Lock()
+		// The head may have been superseded while we were replaying it.
+		if len(r.outbox) > 0 && r.outbox[0].TaskID == entry.TaskID && r.outbox[0].QueuedAt.Equal(entry.QueuedAt) {
+			r.outbox = r.outbox[1:]
+		}
+		if entry.Op == outboxDelete {
+			delete(r.remoteIDs, entry.TaskID)
+		} else {
+			r.remoteIDs[entry.TaskID] = true
+		}
+		err := r.saveState()
+		r.mutex.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *HybridTaskRepository) replay(ctx context.Context, remote TaskRepository, entry outboxEntry) error {
+	if entry.Op == outboxDelete {
+		err := remote.Delete(ctx, entry.TaskID)
+		if err != nil && !errors.Is(err, ErrTaskNotFound) {
+			return err
+		}
+		return nil
+	}
+
+	current, err := remote.GetByID(ctx, entry.TaskID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return remote.Create(ctx, entry.Task)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Last writer wins: a newer remote version is pulled back afterwards.
+	if current.UpdatedAt.After(entry.Task.UpdatedAt) {
+		return nil
+	}
+
+	return remote.Update(ctx, entry.Task)
+}
+
+func (r *HybridTaskRepository) pullRemote(ctx context.Context, remote TaskRepository) error {
+	remoteTasks, err := remote.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	pending := make(map[string]bool, len(r.outbox))
+	for _, entry := range r.outbox {
+		pending[entry.TaskID] = true
+	}
+	previous := r.remoteIDs
+	r.mutex.Unlock()
+
+	seen := make(map[string]bool, len(remoteTasks))
+	for _, task := range remoteTasks {
+		seen[task.ID] = true
+		if pending[task.ID] {
+			continue
+		}
+
+		localTask, err := r.local.GetByID(ctx, task.ID)
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			err = r.local.Create(ctx, task)
+		case err == nil && task.UpdatedAt.After(localTask.UpdatedAt):
+			err = r.local.Update(ctx, task)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Tasks we have seen on the remote before but that are gone now were
+	// deleted by another client.
+	for id := range previous {
+		if seen[id] || pending[id] {
+			continue
+		}
+		if err := r.local.Delete(ctx, id); err != nil && !errors.Is(err, ErrTaskNotFound) {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	r.remoteIDs = seen
+	r.mutex.Unlock()
+
+	return nil
+}
+
+func (r *HybridTaskRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if closer, ok := r.remote.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -2,11 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"todo-list/internal/domain"
 )
 
+// ErrTaskNotFound is wrapped by every backend when a task id does not exist,
+// so callers can tell a missing task apart from a storage failure.
+var ErrTaskNotFound = errors.New("not found")
+
+// ErrTaskExists is wrapped by Create when a task with the same id is already
+// stored.
+var ErrTaskExists = errors.New("already exists")
+
 type TaskRepository interface {
 	Create(ctx context.Context, task *domain.Task) error
 	GetByID(ctx context.Context, id string) (*domain.Task, error)
@@ -16,4 +25,15 @@ type TaskRepository interface {
 	GetByDateRange(ctx context.Context, from, to time.Time) ([]*domain.Task, error)
 	Update(ctx context.Context, task *domain.Task) error
 	Delete(ctx context.Context, id string) error
-}
\ No newline at end of file
+
+	// WithTx runs fn against a repository whose writes are applied
+	// atomically: all of them if fn returns nil, none of them otherwise. fn
+	// must only use the repository it is given.
+	WithTx(ctx context.Context, fn func(ctx context.Context, tx TaskRepository) error) error
+}
+
+// SortedLister is implemented by backends that sort tasks themselves, such
+// as PostgreSQL with ORDER BY. Their order must match domain.SortTasks.
+type SortedLister interface {
+	GetAllSorted(ctx context.Context, spec domain.SortSpec, locale domain.SortLocale) ([]*domain.Task, error)
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+// MarkdownTaskRepository keeps tasks as an Obsidian-style checklist in a
+// single Markdown file, which diffs well under git. Each item carries its ID
+// in a trailing HTML comment. The file is rewritten on every change, so
+// text outside the checklist is not preserved.
+type MarkdownTaskRepository struct {
+	*FileTaskRepository
+}
+
+func NewMarkdownTaskRepository(filePath string) (*MarkdownTaskRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo, err := newFileTaskRepository(&markdownStore{filePath: filePath})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkdownTaskRepository{FileTaskRepository: repo}, nil
+}
+
+type markdownStore struct {
+	filePath   string
+	missingIDs bool
+}
+
+func (s *markdownStore) load() ([]*domain.Task, error) {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := interchange.ReadMarkdown(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	tasks := make([]*domain.Task, 0, len(records))
+	seen := make(map[string]bool, len(records))
+	for _, record := range records {
+		// Dropping an item here would delete it on the next save.
+		if !record.Valid() {
+			return nil, fmt.Errorf("%s line %d: %s", s.filePath, record.Line, strings.Join(record.Errors, "; "))
+		}
+		if seen[record.Task.ID] {
+			return nil, fmt.Errorf("%s line %d: duplicate id %s", s.filePath, record.Line, record.Task.ID)
+		}
+		seen[record.Task.ID] = true
+
+		if !strings.Contains(lines[record.Line-1], "<!-- id:") {
+			s.missingIDs = true
+		}
+		tasks = append(tasks, record.Task)
+	}
+
+	return tasks, nil
+}
+
+func (s *markdownStore) assignedIDs() bool {
+	return s.missingIDs
+}
+
+func (s *markdownStore) save(tasks []*domain.Task) error {
+	var data bytes.Buffer
+	if err := interchange.WriteMarkdown(&data, tasks, interchange.MarkdownOptions{Title: "Tasks"}); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.filePath, data.Bytes()); err != nil {
+		return err
+	}
+
+	s.missingIDs = false
+	return nil
+}
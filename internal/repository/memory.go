@@ -25,7 +25,11 @@ func (r *MemoryTaskRepository) Create(ctx context.Context, task *domain.Task) er
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.tasks[task.ID] = task
+	if _, exists := r.tasks[task.ID]; exists {
+		return fmt.Errorf("task with id %s %w", task.ID, ErrTaskExists)
+	}
+
+	r.tasks[task.ID] = task.Clone()
 	return nil
 }
 
@@ -35,10 +39,10 @@ func (r *MemoryTaskRepository) GetByID(ctx context.Context, id string) (*domain.
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("task with id %s not found", id)
+		return nil, fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
 	}
 
-	return task, nil
+	return task.Clone(), nil
 }
 
 func (r *MemoryTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, error) {
@@ -47,13 +51,10 @@ func (r *MemoryTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, erro
 
 	tasks := make([]*domain.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		tasks = append(tasks, task)
+		tasks = append(tasks, task.Clone())
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -65,14 +66,11 @@ func (r *MemoryTaskRepository) GetByStatus(ctx context.Context, status domain.Ta
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
 		if task.Status == status {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -84,14 +82,11 @@ func (r *MemoryTaskRepository) GetByPriority(ctx context.Context, priority domai
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
 		if task.Priority == priority {
-			tasks = append(tasks, task)
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -102,15 +97,12 @@ func (r *MemoryTaskRepository) GetByDateRange(ctx context.Context, from, to time
 
 	tasks := make([]*domain.Task, 0)
 	for _, task := range r.tasks {
-		if task.CreatedAt.After(from) && task.CreatedAt.Before(to) {
-			tasks = append(tasks, task)
+		if !task.CreatedAt.Before(from) && task.CreatedAt.Before(to) {
+			tasks = append(tasks, task.Clone())
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(tasks, func(i, j int) bool {
-		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-	})
+	sortNewestFirst(tasks)
 
 	return tasks, nil
 }
@@ -120,10 +112,10 @@ func (r *MemoryTaskRepository) Update(ctx context.Context, task *domain.Task) er
 	defer r.mutex.Unlock()
 
 	if _, exists := r.tasks[task.ID]; !exists {
-		return fmt.Errorf("task with id %s not found", task.ID)
+		return fmt.Errorf("task with id %s %w", task.ID, ErrTaskNotFound)
 	}
 
-	r.tasks[task.ID] = task
+	r.tasks[task.ID] = task.Clone()
 	return nil
 }
 
@@ -132,9 +124,43 @@ func (r *MemoryTaskRepository) Delete(ctx context.Context, id string) error {
 	defer r.mutex.Unlock()
 
 	if _, exists := r.tasks[id]; !exists {
-		return fmt.Errorf("task with id %s not found", id)
+		return fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
 	}
 
 	delete(r.tasks, id)
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *MemoryTaskRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TaskRepository) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	staged := stage(r.tasks)
+	if err := fn(ctx, staged); err != nil {
+		return err
+	}
+
+	r.tasks = staged.tasks
+	return nil
+}
+
+// stage returns a scratch repository over a copy of tasks. Stored tasks are
+// never mutated in place, so sharing the pointers is safe.
+func stage(tasks map[string]*domain.Task) *MemoryTaskRepository {
+	staged := NewMemoryTaskRepository()
+	for id, task := range tasks {
+		staged.tasks[id] = task
+	}
+	return staged
+}
+
+// sortNewestFirst orders tasks by creation date, newest first, breaking ties
+// by ID so every backend returns the same order.
+func sortNewestFirst(tasks []*domain.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
@@ -3,16 +3,40 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
 	"time"
 
 	"todo-list/internal/domain"
+	"todo-list/migrations"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"golang.org/x/text/language"
 )
 
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// taskColumns is the column list shared by every query; scanTask reads rows
+// in this order.
+const taskColumns = "id, title, description, status, priority, due_date, created_at, updated_at, project, tags, parent_id, due_all_day, start_date, started_at, completed_at, rank, estimate_minutes, estimate_points"
+
+// taskSelect is taskColumns plus the task's dependencies, which are kept as
+// edges in their own table.
+const taskSelect = taskColumns + ", ARRAY(SELECT blocker_id FROM task_dependencies WHERE task_id = tasks.id ORDER BY position) AS blocked_by"
+
 type PostgresTaskRepository struct {
 	db *sql.DB
+	q  queryer
+	// collations holds the ICU collations the server has, by name.
+	collations map[string]bool
 }
 
 func NewPostgresTaskRepository(connectionString string) (*PostgresTaskRepository, error) {
@@ -29,6 +53,7 @@ func NewPostgresTaskRepository(connectionString string) (*PostgresTaskRepository
 
 	repo := &PostgresTaskRepository{
 		db: db,
+		q:  db,
 	}
 
 	// Create tables if they don't exist
@@ -37,30 +62,116 @@ func NewPostgresTaskRepository(connectionString string) (*PostgresTaskRepository
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	collations, err := loadICUCollations(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to list collations: %w", err)
+	}
+	repo.collations = collations
+
 	return repo, nil
 }
 
+// loadICUCollations lists the ICU collations of the database, which has
+// none when PostgreSQL is built without ICU.
+func loadICUCollations(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT collname FROM pg_collation WHERE collname LIKE '%-x-icu'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collations := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		collations[name] = true
+	}
+	return collations, rows.Err()
+}
+
+// titleCollation picks the ICU collation for the language, or for its base
+// language, and returns "" when the server has neither.
+func (r *PostgresTaskRepository) titleCollation(tag language.Tag) string {
+	base, _ := tag.Base()
+	for _, name := range []string{tag.String() + "-x-icu", base.String() + "-x-icu"} {
+		if r.collations[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// createTables applies the schema migrations the database has not had yet,
+// in name order, and records each in schema_migrations so it runs once.
+// Databases from before the table existed rerun them all once, which they
+// are written to allow.
 func (r *PostgresTaskRepository) createTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS tasks (
-		id VARCHAR(255) PRIMARY KEY,
-		title TEXT NOT NULL,
-		description TEXT DEFAULT '',
-		status VARCHAR(50) NOT NULL CHECK (status IN ('active', 'completed')),
-		priority VARCHAR(50) NOT NULL CHECK (priority IN ('low', 'medium', 'high')),
-		due_date TIMESTAMP WITH TIME ZONE,
-		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
-		updated_at TIMESTAMP WITH TIME ZONE NOT NULL
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
-	CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
-	CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
-	CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at);
-	`
+	ctx := context.Background()
+	if _, err := r.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return err
+	}
 
-	_, err := r.db.Exec(query)
-	return err
+	names, err := fs.Glob(migrations.Files, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	return r.atomically(ctx, func(q queryer) error {
+		// Another instance connecting at the same time waits here instead
+		// of applying the same migrations.
+		if _, err := q.ExecContext(ctx, `LOCK TABLE schema_migrations IN EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			version := strings.TrimSuffix(name, ".sql")
+			if applied[version] {
+				continue
+			}
+			query, err := fs.ReadFile(migrations.Files, name)
+			if err != nil {
+				return err
+			}
+			if _, err := q.ExecContext(ctx, string(query)); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			if _, err := q.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func appliedMigrations(ctx context.Context, q queryer) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
 }
 
 func (r *PostgresTaskRepository) Close() error {
@@ -72,76 +183,178 @@ func (r *PostgresTaskRepository) Close() error {
 
 func (r *PostgresTaskRepository) Create(ctx context.Context, task *domain.Task) error {
 	query := `
-		INSERT INTO tasks (id, title, description, status, priority, due_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO tasks (` + taskColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
 
-	_, err := r.db.ExecContext(
-		ctx, query,
-		task.ID,
-		task.Title,
-		task.Description,
-		string(task.Status),
-		string(task.Priority),
-		task.DueDate,
-		task.CreatedAt,
-		task.UpdatedAt,
-	)
+	minutes, points := estimateValues(task.Estimate)
+	return r.atomically(ctx, func(q queryer) error {
+		_, err := q.ExecContext(
+			ctx, query,
+			task.ID,
+			task.Title,
+			task.Description,
+			string(task.Status),
+			string(task.Priority),
+			task.DueDate,
+			task.CreatedAt,
+			task.UpdatedAt,
+			task.Project,
+			pq.Array(task.Tags),
+			task.ParentID,
+			task.DueAllDay,
+			dateValue(task.StartDate),
+			task.StartedAt,
+			task.CompletedAt,
+			task.Rank,
+			minutes,
+			points,
+		)
 
-	return err
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("task with id %s %w", task.ID, ErrTaskExists)
+		}
+		if err != nil {
+			return err
+		}
+
+		return saveDependencies(ctx, q, task)
+	})
 }
 
 func (r *PostgresTaskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, created_at, updated_at
+		SELECT ` + taskSelect + `
 		FROM tasks
 		WHERE id = $1
 	`
 
-	row := r.db.QueryRowContext(ctx, query, id)
-
-	var task domain.Task
-	var status, priority string
-	err := row.Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&status,
-		&priority,
-		&task.DueDate,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
-
+	task, err := scanTask(r.q.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("task with id %s not found", id)
+			return nil, fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
 		}
 		return nil, err
 	}
 
-	task.Status = domain.TaskStatus(status)
-	task.Priority = domain.Priority(priority)
-
-	return &task, nil
+	return task, nil
 }
 
 func (r *PostgresTaskRepository) GetAll(ctx context.Context) ([]*domain.Task, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, created_at, updated_at
+		SELECT ` + taskSelect + `
 		FROM tasks
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id ASC
 	`
 
 	return r.queryTasks(ctx, query)
 }
 
+// GetAllSorted sorts in the database. Titles use the ICU collation for the
+// locale's language, which PostgreSQL creates when it is built with ICU.
+// Without one the database cannot order titles like domain.SortTasks, so
+// specs that sort by title are sorted here instead.
+func (r *PostgresTaskRepository) GetAllSorted(ctx context.Context, spec domain.SortSpec, locale domain.SortLocale) ([]*domain.Task, error) {
+	collation := r.titleCollation(locale.Language)
+	if collation == "" && sortsTitles(spec) {
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		tasks, err := r.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		domain.SortTasks(tasks, spec, locale)
+		return tasks, nil
+	}
+
+	orderBy, err := sortOrderBy(spec, locale, collation)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + taskSelect + `
+		FROM tasks
+		ORDER BY ` + orderBy
+
+	return r.queryTasks(ctx, query)
+}
+
+func sortsTitles(spec domain.SortSpec) bool {
+	for _, key := range spec {
+		if key.Field == domain.SortTitle {
+			return true
+		}
+	}
+	return false
+}
+
+// sortOrderBy translates spec into an ORDER BY list giving the order of
+// domain.SortTasks. Titles are compared in titleCollation, which must be
+// set when spec sorts by title.
+func sortOrderBy(spec domain.SortSpec, locale domain.SortLocale, titleCollation string) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+
+	var terms []string
+	for _, key := range spec {
+		asc, desc := "ASC", "DESC"
+		if key.Desc {
+			asc, desc = desc, asc
+		}
+
+		switch key.Field {
+		case domain.SortCreated:
+			terms = append(terms, "created_at "+asc)
+		case domain.SortUpdated:
+			terms = append(terms, "updated_at "+asc)
+		case domain.SortPriority:
+			terms = append(terms, "CASE priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END "+asc)
+		case domain.SortDueDate:
+			// All-day due dates start their day in the locale's time zone
+			// and come before timed ones on the same day.
+			terms = append(terms,
+				"CASE WHEN due_all_day THEN (due_date AT TIME ZONE 'UTC')::date::timestamp AT TIME ZONE "+
+					sortTimeZone(locale.Location)+" ELSE due_date END "+asc+" NULLS LAST",
+				"due_all_day "+desc)
+		case domain.SortTitle:
+			if titleCollation == "" {
+				return "", errors.New("sorting titles needs an ICU collation")
+			}
+			terms = append(terms, "title COLLATE "+pq.QuoteIdentifier(titleCollation)+" "+asc)
+		case domain.SortCompletedAt:
+			terms = append(terms, "completed_at "+asc+" NULLS LAST")
+		case domain.SortEstimate:
+			terms = append(terms,
+				"(estimate_minutes IS NULL AND estimate_points IS NULL) ASC",
+				"estimate_points IS NOT NULL "+asc, "estimate_minutes "+asc, "estimate_points "+asc)
+		case domain.SortManual:
+			terms = append(terms, "rank = '' ASC", "rank "+asc)
+		}
+	}
+	terms = append(terms, `id COLLATE "C" ASC`)
+
+	return strings.Join(terms, ", "), nil
+}
+
+// sortTimeZone names loc for AT TIME ZONE. The database does not know
+// time.Local by name, so it stands for the session's time zone.
+func sortTimeZone(loc *time.Location) string {
+	if loc == nil || loc == time.Local {
+		return "current_setting('TimeZone')"
+	}
+	return pq.QuoteLiteral(loc.String())
+}
+
 func (r *PostgresTaskRepository) GetByStatus(ctx context.Context, status domain.TaskStatus) ([]*domain.Task, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, created_at, updated_at
+		SELECT ` + taskSelect + `
 		FROM tasks
 		WHERE status = $1
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id ASC
 	`
 
 	return r.queryTasks(ctx, query, string(status))
@@ -149,10 +362,10 @@ func (r *PostgresTaskRepository) GetByStatus(ctx context.Context, status domain.
 
 func (r *PostgresTaskRepository) GetByPriority(ctx context.Context, priority domain.Priority) ([]*domain.Task, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, created_at, updated_at
+		SELECT ` + taskSelect + `
 		FROM tasks
 		WHERE priority = $1
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id ASC
 	`
 
 	return r.queryTasks(ctx, query, string(priority))
@@ -160,10 +373,10 @@ func (r *PostgresTaskRepository) GetByPriority(ctx context.Context, priority dom
 
 func (r *PostgresTaskRepository) GetByDateRange(ctx context.Context, from, to time.Time) ([]*domain.Task, error) {
 	query := `
-		SELECT id, title, description, status, priority, due_date, created_at, updated_at
+		SELECT ` + taskSelect + `
 		FROM tasks
-		WHERE created_at BETWEEN $1 AND $2
-		ORDER BY created_at DESC
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at DESC, id ASC
 	`
 
 	return r.queryTasks(ctx, query, from, to)
@@ -172,88 +385,113 @@ func (r *PostgresTaskRepository) GetByDateRange(ctx context.Context, from, to ti
 func (r *PostgresTaskRepository) Update(ctx context.Context, task *domain.Task) error {
 	query := `
 		UPDATE tasks
-		SET title = $2, description = $3, status = $4, priority = $5, due_date = $6, updated_at = $7
+		SET title = $2, description = $3, status = $4, priority = $5, due_date = $6, updated_at = $7,
+			project = $8, tags = $9, parent_id = $10, due_all_day = $11, start_date = $12,
+			started_at = $13, completed_at = $14, rank = $15, estimate_minutes = $16, estimate_points = $17
 		WHERE id = $1
 	`
 
-	result, err := r.db.ExecContext(
-		ctx, query,
-		task.ID,
-		task.Title,
-		task.Description,
-		string(task.Status),
-		string(task.Priority),
-		task.DueDate,
-		task.UpdatedAt,
-	)
+	minutes, points := estimateValues(task.Estimate)
+	return r.atomically(ctx, func(q queryer) error {
+		result, err := q.ExecContext(
+			ctx, query,
+			task.ID,
+			task.Title,
+			task.Description,
+			string(task.Status),
+			string(task.Priority),
+			task.DueDate,
+			task.UpdatedAt,
+			task.Project,
+			pq.Array(task.Tags),
+			task.ParentID,
+			task.DueAllDay,
+			dateValue(task.StartDate),
+			task.StartedAt,
+			task.CompletedAt,
+			task.Rank,
+			minutes,
+			points,
+		)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("task with id %s %w", task.ID, ErrTaskNotFound)
+		}
+
+		return saveDependencies(ctx, q, task)
+	})
+}
+
+// saveDependencies replaces the stored edges of task with its BlockedBy
+// list. Blockers have no foreign key, so a task can be stored before the
+// tasks it waits for, as imports and sync may do.
+func saveDependencies(ctx context.Context, q queryer, task *domain.Task) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM task_dependencies WHERE task_id = $1`, task.ID); err != nil {
 		return err
 	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("task with id %s not found", task.ID)
+	if len(task.BlockedBy) == 0 {
+		return nil
 	}
 
-	return nil
+	query := `
+		INSERT INTO task_dependencies (task_id, blocker_id, position)
+		SELECT $1, blocker_id, position FROM unnest($2::text[]) WITH ORDINALITY AS b(blocker_id, position)
+		ON CONFLICT DO NOTHING
+	`
+	_, err := q.ExecContext(ctx, query, task.ID, pq.Array(task.BlockedBy))
+	return err
 }
 
+// Delete removes the task and, by cascade, what it waits for; edges of tasks
+// waiting for it are removed too.
 func (r *PostgresTaskRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM tasks WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
+	return r.atomically(ctx, func(q queryer) error {
+		result, err := q.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("task with id %s not found", id)
-	}
+		if rowsAffected == 0 {
+			return fmt.Errorf("task with id %s %w", id, ErrTaskNotFound)
+		}
 
-	return nil
+		_, err = q.ExecContext(ctx, `DELETE FROM task_dependencies WHERE blocker_id = $1`, id)
+		return err
+	})
 }
 
 func (r *PostgresTaskRepository) queryTasks(ctx context.Context, query string, args ...interface{}) ([]*domain.Task, error) {
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := r.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tasks []*domain.Task
+	tasks := make([]*domain.Task, 0)
 
 	for rows.Next() {
-		var task domain.Task
-		var status, priority string
-
-		err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&status,
-			&priority,
-			&task.DueDate,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		task.Status = domain.TaskStatus(status)
-		task.Priority = domain.Priority(priority)
-
-		tasks = append(tasks, &task)
+		tasks = append(tasks, task)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -261,4 +499,148 @@ func (r *PostgresTaskRepository) queryTasks(ctx context.Context, query string, a
 	}
 
 	return tasks, nil
-}
\ No newline at end of file
+}
+
+// dateValue writes a date as YYYY-MM-DD so a DATE column does not shift it
+// by the session time zone.
+func dateValue(date *time.Time) interface{} {
+	if date == nil {
+		return nil
+	}
+	return date.UTC().Format("2006-01-02")
+}
+
+// estimateValues splits an estimate into its two columns, both NULL
+// without one.
+func estimateValues(estimate *domain.Estimate) (minutes, points interface{}) {
+	if estimate == nil {
+		return nil, nil
+	}
+	if estimate.IsPoints() {
+		return nil, estimate.Points
+	}
+	return estimate.Minutes, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (*domain.Task, error) {
+	var task domain.Task
+	var status, priority string
+	var tags []string
+	var startDate sql.NullTime
+	var blockedBy []string
+	var estimateMinutes sql.NullInt64
+	var estimatePoints sql.NullFloat64
+
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&status,
+		&priority,
+		&task.DueDate,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.Project,
+		pq.Array(&tags),
+		&task.ParentID,
+		&task.DueAllDay,
+		&startDate,
+		&task.StartedAt,
+		&task.CompletedAt,
+		&task.Rank,
+		&estimateMinutes,
+		&estimatePoints,
+		pq.Array(&blockedBy),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dates come back in the session time zone; all-day due dates and
+	// start dates are kept as midnight UTC.
+	if task.DueAllDay && task.DueDate != nil {
+		due := task.DueDate.UTC()
+		task.DueDate = &due
+	}
+	if startDate.Valid {
+		start := domain.DateOnly(startDate.Time)
+		task.StartDate = &start
+	}
+
+	task.Status = domain.TaskStatus(status)
+	task.Priority = domain.Priority(priority)
+	if len(tags) > 0 {
+		task.Tags = tags
+	}
+	if len(blockedBy) > 0 {
+		task.BlockedBy = blockedBy
+	}
+	switch {
+	case estimatePoints.Valid:
+		task.Estimate = &domain.Estimate{Points: estimatePoints.Float64}
+	case estimateMinutes.Valid:
+		task.Estimate = &domain.Estimate{Minutes: int(estimateMinutes.Int64)}
+	}
+
+	return &task, nil
+}
+
+// atomically runs fn in a transaction of its own, or in a savepoint of the
+// surrounding one inside WithTx.
+func (r *PostgresTaskRepository) atomically(ctx context.Context, fn func(q queryer) error) error {
+	if r.db == nil {
+		return savepoint(ctx, r.q, func() error { return fn(r.q) })
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresTaskRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TaskRepository) error) error {
+	// Nested calls run in a savepoint of the surrounding transaction.
+	if r.db == nil {
+		return savepoint(ctx, r.q, func() error { return fn(ctx, r) })
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(ctx, &PostgresTaskRepository{q: tx, collations: r.collations}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// savepoint undoes the writes of fn alone when it fails, leaving the
+// surrounding transaction usable. PostgreSQL otherwise refuses every later
+// statement of a transaction that had an error.
+func savepoint(ctx context.Context, q queryer, fn func() error) error {
+	if _, err := q.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		// Release it too, or the savepoint of an enclosing call would be
+		// shadowed by this one of the same name.
+		q.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested; RELEASE SAVEPOINT nested")
+		return err
+	}
+	_, err := q.ExecContext(ctx, "RELEASE SAVEPOINT nested")
+	return err
+}
@@ -0,0 +1,524 @@
+// Package repotest is a conformance suite for repository.TaskRepository
+// implementations. Call Run from a _test.go file next to the backend:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repository.TaskRepository {
+//			return repository.NewMemoryTaskRepository()
+//		})
+//	}
+//
+//	func TestStress(t *testing.T) {
+//		repotest.Stress(t, repository.NewMemoryTaskRepository(), 8, 500)
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+
+	"golang.org/x/text/language"
+)
+
+// Factory returns a new, empty repository for a single subtest.
+type Factory func(t *testing.T) repository.TaskRepository
+
+// base is truncated to microseconds because that is what Postgres stores.
+var base = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func newTask(id string, createdAt time.Time) *domain.Task {
+	return &domain.Task{
+		ID:          id,
+		Title:       "task " + id,
+		Description: "description " + id,
+		Status:      domain.TodoTask,
+		Priority:    domain.MediumPriority,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+}
+
+func mustCreate(t *testing.T, repo repository.TaskRepository, tasks ...*domain.Task) {
+	t.Helper()
+	for _, task := range tasks {
+		if err := repo.Create(context.Background(), task); err != nil {
+			t.Fatalf("Create(%s): %v", task.ID, err)
+		}
+	}
+}
+
+func ids(tasks []*domain.Task) []string {
+	result := make([]string, len(tasks))
+	for i, task := range tasks {
+		result[i] = task.ID
+	}
+	return result
+}
+
+func assertIDs(t *testing.T, name string, got []*domain.Task, want ...string) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s returned a nil slice, want an empty one", name)
+	}
+	if fmt.Sprint(ids(got)) != fmt.Sprint(want) {
+		t.Fatalf("%s = %v, want %v", name, ids(got), want)
+	}
+}
+
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.TaskRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"NotFound", testNotFound},
+		{"EmptyLists", testEmptyLists},
+		{"Ordering", testOrdering},
+		{"Filters", testFilters},
+		{"DateRangeBoundaries", testDateRangeBoundaries},
+		{"UpdateAndDelete", testUpdateAndDelete},
+		{"Isolation", testIsolation},
+		{"ConcurrentAccess", testConcurrentAccess},
+		{"Transactions", testTransactions},
+		{"NestedTransactions", testNestedTransactions},
+		{"SortedListing", testSortedListing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	due := base.Add(48 * time.Hour)
+	task := newTask("a", base)
+	task.Priority = domain.HighPriority
+	task.DueDate = &due
+	mustCreate(t, repo, task)
+
+	got, err := repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	if got.Title != task.Title || got.Description != task.Description || got.Status != task.Status || got.Priority != task.Priority {
+		t.Fatalf("GetByID = %+v, want %+v", got, task)
+	}
+	if !got.CreatedAt.Equal(task.CreatedAt) || !got.UpdatedAt.Equal(task.UpdatedAt) {
+		t.Fatalf("timestamps = %v/%v, want %v/%v", got.CreatedAt, got.UpdatedAt, task.CreatedAt, task.UpdatedAt)
+	}
+	if got.DueDate == nil || !got.DueDate.Equal(due) {
+		t.Fatalf("DueDate = %v, want %v", got.DueDate, due)
+	}
+
+	day := domain.DateOnly(due)
+	start := domain.DateOnly(base)
+	allDay := newTask("b", base)
+	allDay.DueDate, allDay.DueAllDay, allDay.StartDate = &day, true, &start
+	mustCreate(t, repo, allDay)
+
+	got, err = repo.GetByID(ctx, "b")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.DueAllDay || got.DueDate == nil || !got.DueDate.Equal(day) {
+		t.Fatalf("all-day DueDate = %v (all day %v), want %v", got.DueDate, got.DueAllDay, day)
+	}
+	if got.StartDate == nil || !got.StartDate.Equal(start) {
+		t.Fatalf("StartDate = %v, want %v", got.StartDate, start)
+	}
+}
+
+func testCreateDuplicate(t *testing.T, repo repository.TaskRepository) {
+	mustCreate(t, repo, newTask("a", base))
+
+	err := repo.Create(context.Background(), newTask("a", base))
+	if !errors.Is(err, repository.ErrTaskExists) {
+		t.Fatalf("duplicate Create error = %v, want ErrTaskExists", err)
+	}
+}
+
+func testNotFound(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		call func() error
+	}{
+		{"GetByID", func() error { _, err := repo.GetByID(ctx, "missing"); return err }},
+		{"Update", func() error { return repo.Update(ctx, newTask("missing", base)) }},
+		{"Delete", func() error { return repo.Delete(ctx, "missing") }},
+	}
+
+	for _, tc := range cases {
+		if err := tc.call(); !errors.Is(err, repository.ErrTaskNotFound) {
+			t.Errorf("%s error = %v, want ErrTaskNotFound", tc.name, err)
+		}
+	}
+}
+
+func testEmptyLists(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll", all)
+
+	byStatus, err := repo.GetByStatus(ctx, domain.TodoTask)
+	if err != nil {
+		t.Fatalf("GetByStatus: %v", err)
+	}
+	assertIDs(t, "GetByStatus", byStatus)
+}
+
+func testOrdering(t *testing.T, repo repository.TaskRepository) {
+	// "b" and "c" share a creation time, so ID decides between them.
+	mustCreate(t, repo,
+		newTask("a", base),
+		newTask("c", base.Add(time.Hour)),
+		newTask("b", base.Add(time.Hour)),
+		newTask("d", base.Add(2*time.Hour)),
+	)
+
+	all, err := repo.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll", all, "d", "b", "c", "a")
+}
+
+func testFilters(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+
+	done := newTask("done", base)
+	done.Status = domain.DoneTask
+	high := newTask("high", base.Add(time.Hour))
+	high.Priority = domain.HighPriority
+	mustCreate(t, repo, done, high, newTask("plain", base.Add(2*time.Hour)))
+
+	tests := []struct {
+		name string
+		call func() ([]*domain.Task, error)
+		want []string
+	}{
+		{"active", func() ([]*domain.Task, error) { return repo.GetByStatus(ctx, domain.TodoTask) }, []string{"plain", "high"}},
+		{"completed", func() ([]*domain.Task, error) { return repo.GetByStatus(ctx, domain.DoneTask) }, []string{"done"}},
+		{"high", func() ([]*domain.Task, error) { return repo.GetByPriority(ctx, domain.HighPriority) }, []string{"high"}},
+		{"medium", func() ([]*domain.Task, error) { return repo.GetByPriority(ctx, domain.MediumPriority) }, []string{"plain", "done"}},
+		{"low", func() ([]*domain.Task, error) { return repo.GetByPriority(ctx, domain.LowPriority) }, []string{}},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.call()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		assertIDs(t, tt.name, got, tt.want...)
+	}
+}
+
+// testDateRangeBoundaries pins GetByDateRange to the half-open interval
+// [from, to).
+func testDateRangeBoundaries(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	mustCreate(t, repo,
+		newTask("before", base.Add(-time.Microsecond)),
+		newTask("from", base),
+		newTask("inside", base.Add(time.Hour)),
+		newTask("to", base.Add(2*time.Hour)),
+	)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"half-open", base, base.Add(2 * time.Hour), []string{"inside", "from"}},
+		{"inclusive start", base, base.Add(time.Microsecond), []string{"from"}},
+		{"exclusive end", base.Add(time.Hour + time.Microsecond), base.Add(2 * time.Hour), []string{}},
+		{"empty interval", base, base, []string{}},
+		{"everything", base.Add(-time.Hour), base.Add(3 * time.Hour), []string{"to", "inside", "from", "before"}},
+	}
+
+	for _, tt := range tests {
+		got, err := repo.GetByDateRange(ctx, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		assertIDs(t, tt.name, got, tt.want...)
+	}
+}
+
+func testUpdateAndDelete(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	mustCreate(t, repo, newTask("a", base), newTask("b", base.Add(time.Hour)))
+
+	updated := newTask("a", base)
+	updated.Title = "renamed"
+	updated.Status = domain.DoneTask
+	updated.Rank = "i"
+	updated.BlockedBy = []string{"b"}
+	updated.Estimate = &domain.Estimate{Minutes: 90}
+	updated.UpdatedAt = base.Add(time.Minute)
+	if err := repo.Update(ctx, updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Title != "renamed" || got.Status != domain.DoneTask || got.Rank != "i" || !got.DependsOn("b") || len(got.BlockedBy) != 1 ||
+		got.Estimate == nil || *got.Estimate != *updated.Estimate || !got.UpdatedAt.Equal(updated.UpdatedAt) {
+		t.Fatalf("after Update = %+v", got)
+	}
+
+	if err := repo.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "a"); !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Fatalf("GetByID after Delete error = %v, want ErrTaskNotFound", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll", all, "b")
+}
+
+// testIsolation checks that callers never share memory with stored tasks.
+func testIsolation(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	due := base.Add(time.Hour)
+	callerDue := due
+	task := newTask("a", base)
+	task.DueDate = &callerDue
+	mustCreate(t, repo, task)
+
+	task.Title = "mutated after create"
+	*task.DueDate = base.Add(99 * time.Hour)
+
+	got, err := repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Title != "task a" || !got.DueDate.Equal(due) {
+		t.Fatalf("Create kept a reference to the caller's task: %+v", got)
+	}
+
+	got.Title = "mutated after get"
+	*got.DueDate = base.Add(99 * time.Hour)
+
+	listed, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if listed[0].Title != "task a" || !listed[0].DueDate.Equal(due) {
+		t.Fatalf("GetByID returned a stored task: %+v", listed[0])
+	}
+
+	listed[0].Title = "mutated after list"
+	update := newTask("a", base)
+	update.Title = "updated"
+	if err := repo.Update(ctx, update); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	update.Title = "mutated after update"
+
+	got, err = repo.GetByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Title != "updated" {
+		t.Fatalf("Title = %q, want %q", got.Title, "updated")
+	}
+}
+
+func testConcurrentAccess(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	const workers, perWorker = 8, 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker*3)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				task := newTask(fmt.Sprintf("w%d-%d", w, i), base.Add(time.Duration(w*perWorker+i)*time.Second))
+				if err := repo.Create(ctx, task); err != nil {
+					errs <- err
+					continue
+				}
+
+				task.Title = "updated"
+				if err := repo.Update(ctx, task); err != nil {
+					errs <- err
+				}
+
+				if _, err := repo.GetAll(ctx); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent operation failed: %v", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != workers*perWorker {
+		t.Fatalf("GetAll returned %d tasks, want %d", len(all), workers*perWorker)
+	}
+	for _, task := range all {
+		if task.Title != "updated" {
+			t.Fatalf("task %s lost its update", task.ID)
+		}
+	}
+}
+
+func testTransactions(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	mustCreate(t, repo, newTask("existing", base))
+
+	errAbort := errors.New("abort")
+	err := repo.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := tx.Create(ctx, newTask("rolled-back", base.Add(time.Hour))); err != nil {
+			return err
+		}
+		if err := tx.Delete(ctx, "existing"); err != nil {
+			return err
+		}
+		if _, err := tx.GetByID(ctx, "rolled-back"); err != nil {
+			t.Errorf("write not visible inside the transaction: %v", err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx error = %v, want the callback's error", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll after rollback", all, "existing")
+
+	err = repo.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := tx.Create(ctx, newTask("committed", base.Add(time.Hour))); err != nil {
+			return err
+		}
+		renamed := newTask("existing", base)
+		renamed.Title = "renamed"
+		return tx.Update(ctx, renamed)
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	all, err = repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll after commit", all, "committed", "existing")
+	if all[1].Title != "renamed" {
+		t.Fatalf("Title = %q, want %q", all[1].Title, "renamed")
+	}
+}
+
+// testNestedTransactions checks that a failing nested WithTx undoes only its
+// own writes, the way a bulk operation skips a failing item.
+func testNestedTransactions(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	mustCreate(t, repo, newTask("existing", base))
+
+	errAbort := errors.New("abort")
+	err := repo.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := tx.Create(ctx, newTask("outer", base.Add(time.Hour))); err != nil {
+			return err
+		}
+		err := tx.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+			if err := tx.Delete(ctx, "existing"); err != nil {
+				return err
+			}
+			if err := tx.Create(ctx, newTask("inner", base.Add(2*time.Hour))); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Errorf("nested WithTx error = %v, want the callback's error", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, "GetAll after a failed nested transaction", all, "outer", "existing")
+}
+
+// testSortedListing checks that backends sorting tasks themselves agree with
+// domain.SortTasks, titles in other languages included.
+func testSortedListing(t *testing.T, repo repository.TaskRepository) {
+	lister, ok := repo.(repository.SortedLister)
+	if !ok {
+		t.Skip("the repository does not sort")
+	}
+
+	titles := []string{"zebra", "Éclair", "apple", "eclair", "Zoo", "Ärger", "b2", "b10"}
+	var tasks []*domain.Task
+	for i, title := range titles {
+		task := newTask(fmt.Sprintf("%02d", i), base.Add(time.Duration(i)*time.Minute))
+		task.Title = title
+		task.Priority = []domain.Priority{domain.LowPriority, domain.MediumPriority, domain.HighPriority}[i%3]
+		tasks = append(tasks, task)
+	}
+	mustCreate(t, repo, tasks...)
+
+	for _, spec := range []string{"title", "title desc", "priority desc,title", "created desc"} {
+		for _, locale := range []domain.SortLocale{
+			{Location: time.UTC},
+			{Location: time.UTC, Language: language.German},
+			{Location: time.UTC, Language: language.Swedish},
+		} {
+			parsed, err := domain.ParseSortSpec(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := lister.GetAllSorted(context.Background(), parsed, locale)
+			if err != nil {
+				t.Fatalf("GetAllSorted(%s, %s): %v", spec, locale.Language, err)
+			}
+			want := append([]*domain.Task(nil), tasks...)
+			domain.SortTasks(want, parsed, locale)
+			if fmt.Sprint(ids(got)) != fmt.Sprint(ids(want)) {
+				t.Errorf("GetAllSorted(%s, %s) = %v, want %v", spec, locale.Language, ids(got), ids(want))
+			}
+		}
+	}
+}
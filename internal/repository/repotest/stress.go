@@ -0,0 +1,115 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+// Stress hammers a repository the way concurrent Wails bindings do: every
+// worker creates, edits, deletes and lists tasks while scribbling over
+// whatever the repository hands back. Run it with -race; a backend that
+// shares stored pointers with callers fails there or on the final check.
+func Stress(t *testing.T, repo repository.TaskRepository, workers, opsPerWorker int) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	expected := make([]map[string]string, workers)
+
+	for w := 0; w < workers; w++ {
+		expected[w] = make(map[string]string)
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(int64(w)))
+			mine := expected[w]
+			owned := make([]string, 0)
+
+			for i := 0; i < opsPerWorker; i++ {
+				var err error
+
+				switch op := rng.Intn(5); {
+				case op == 0 || len(owned) == 0:
+					task := newTask(fmt.Sprintf("stress-%d-%d", w, i), base.Add(time.Duration(i)*time.Millisecond))
+					if err = repo.Create(ctx, task); err == nil {
+						owned = append(owned, task.ID)
+						mine[task.ID] = task.Title
+						task.Title = "caller scribble"
+					}
+				case op == 1:
+					id := owned[rng.Intn(len(owned))]
+					var task *domain.Task
+					if task, err = repo.GetByID(ctx, id); err == nil {
+						task.Title = fmt.Sprintf("edit %d", i)
+						if err = repo.Update(ctx, task); err == nil {
+							mine[id] = task.Title
+						}
+						task.Title = "caller scribble"
+					}
+				case op == 2:
+					j := rng.Intn(len(owned))
+					if err = repo.Delete(ctx, owned[j]); err == nil {
+						delete(mine, owned[j])
+						owned = append(owned[:j], owned[j+1:]...)
+					}
+				default:
+					var tasks []*domain.Task
+					if tasks, err = repo.GetAll(ctx); err == nil {
+						for _, task := range tasks {
+							task.Title = "reader scribble"
+						}
+					}
+				}
+
+				if err != nil {
+					errs <- fmt.Errorf("worker %d op %d: %w", w, i, err)
+					return
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if t.Failed() {
+		return
+	}
+
+	total := 0
+	for _, mine := range expected {
+		total += len(mine)
+		for id, title := range mine {
+			task, err := repo.GetByID(ctx, id)
+			if errors.Is(err, repository.ErrTaskNotFound) {
+				t.Fatalf("task %s disappeared", id)
+			}
+			if err != nil {
+				t.Fatalf("GetByID(%s): %v", id, err)
+			}
+			if task.Title != title {
+				t.Fatalf("task %s title = %q, want %q", id, task.Title, title)
+			}
+		}
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != total {
+		t.Fatalf("GetAll returned %d tasks, want %d", len(all), total)
+	}
+}
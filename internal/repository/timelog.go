@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"todo-list/internal/domain"
+)
+
+var (
+	// ErrTimeEntryNotFound is wrapped when a time entry id does not exist.
+	ErrTimeEntryNotFound = errors.New("not found")
+	// ErrTimeEntryExists is wrapped by Create when the id is already taken.
+	ErrTimeEntryExists = errors.New("already exists")
+)
+
+// TimeEntryRepository stores the time log. It is kept apart from tasks, so
+// every task backend shares the same time log in the data directory.
+type TimeEntryRepository interface {
+	Create(ctx context.Context, entry *domain.TimeEntry) error
+	GetByID(ctx context.Context, id string) (*domain.TimeEntry, error)
+	// GetAll returns the entries oldest first.
+	GetAll(ctx context.Context) ([]*domain.TimeEntry, error)
+	Update(ctx context.Context, entry *domain.TimeEntry) error
+	Delete(ctx context.Context, id string) error
+
+	// WithTx works like TaskRepository.WithTx.
+	WithTx(ctx context.Context, fn func(ctx context.Context, tx TimeEntryRepository) error) error
+}
+
+type MemoryTimeEntryRepository struct {
+	entries map[string]*domain.TimeEntry
+	mutex   sync.RWMutex
+}
+
+func NewMemoryTimeEntryRepository() *MemoryTimeEntryRepository {
+	return &MemoryTimeEntryRepository{
+		entries: make(map[string]*domain.TimeEntry),
+	}
+}
+
+func (r *MemoryTimeEntryRepository) Create(ctx context.Context, entry *domain.TimeEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[entry.ID]; exists {
+		return fmt.Errorf("time entry with id %s %w", entry.ID, ErrTimeEntryExists)
+	}
+
+	r.entries[entry.ID] = entry.Clone()
+	return nil
+}
+
+func (r *MemoryTimeEntryRepository) GetByID(ctx context.Context, id string) (*domain.TimeEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry, exists := r.entries[id]
+	if !exists {
+		return nil, fmt.Errorf("time entry with id %s %w", id, ErrTimeEntryNotFound)
+	}
+
+	return entry.Clone(), nil
+}
+
+func (r *MemoryTimeEntryRepository) GetAll(ctx context.Context) ([]*domain.TimeEntry, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := make([]*domain.TimeEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry.Clone())
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].Start.Before(entries[j].Start)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+
+	return entries, nil
+}
+
+func (r *MemoryTimeEntryRepository) Update(ctx context.Context, entry *domain.TimeEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[entry.ID]; !exists {
+		return fmt.Errorf("time entry with id %s %w", entry.ID, ErrTimeEntryNotFound)
+	}
+
+	r.entries[entry.ID] = entry.Clone()
+	return nil
+}
+
+func (r *MemoryTimeEntryRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.entries[id]; !exists {
+		return fmt.Errorf("time entry with id %s %w", id, ErrTimeEntryNotFound)
+	}
+
+	delete(r.entries, id)
+	return nil
+}
+
+// WithTx runs fn against a copy of the entries and keeps the copy when fn
+// succeeds.
+func (r *MemoryTimeEntryRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TimeEntryRepository) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	staged := &MemoryTimeEntryRepository{entries: make(map[string]*domain.TimeEntry, len(r.entries))}
+	for id, entry := range r.entries {
+		staged.entries[id] = entry
+	}
+	if err := fn(ctx, staged); err != nil {
+		return err
+	}
+
+	r.entries = staged.entries
+	return nil
+}
+
+// FileTimeEntryRepository keeps the time log in a JSON file, so a running
+// timer survives restarts.
+type FileTimeEntryRepository struct {
+	*MemoryTimeEntryRepository
+	filePath string
+}
+
+func NewFileTimeEntryRepository(filePath string) (*FileTimeEntryRepository, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	repo := &FileTimeEntryRepository{
+		MemoryTimeEntryRepository: NewMemoryTimeEntryRepository(),
+		filePath:                  filePath,
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		var entries []*domain.TimeEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to load time log: %w", err)
+		}
+		for _, entry := range entries {
+			repo.entries[entry.ID] = entry
+		}
+	}
+
+	return repo, nil
+}
+
+func (r *FileTimeEntryRepository) Create(ctx context.Context, entry *domain.TimeEntry) error {
+	return r.WithTx(ctx, func(ctx context.Context, tx TimeEntryRepository) error {
+		return tx.Create(ctx, entry)
+	})
+}
+
+func (r *FileTimeEntryRepository) Update(ctx context.Context, entry *domain.TimeEntry) error {
+	return r.WithTx(ctx, func(ctx context.Context, tx TimeEntryRepository) error {
+		return tx.Update(ctx, entry)
+	})
+}
+
+func (r *FileTimeEntryRepository) Delete(ctx context.Context, id string) error {
+	return r.WithTx(ctx, func(ctx context.Context, tx TimeEntryRepository) error {
+		return tx.Delete(ctx, id)
+	})
+}
+
+// WithTx saves the file once when fn succeeds.
+func (r *FileTimeEntryRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx TimeEntryRepository) error) error {
+	return r.MemoryTimeEntryRepository.WithTx(ctx, func(ctx context.Context, tx TimeEntryRepository) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+
+		entries, err := tx.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(r.filePath, data)
+	})
+}
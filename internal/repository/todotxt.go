@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+// TodoTxtTaskRepository stores open tasks in todo.txt and completed ones in
+// done.txt, so the files stay usable with other todo.txt tools. Task IDs are
+// kept in an id: pair on every line.
+type TodoTxtTaskRepository struct {
+	*FileTaskRepository
+}
+
+func NewTodoTxtTaskRepository(dir string) (*TodoTxtTaskRepository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	store := &todoTxtStore{
+		todoPath: filepath.Join(dir, "todo.txt"),
+		donePath: filepath.Join(dir, "done.txt"),
+	}
+	repo, err := newFileTaskRepository(store)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TodoTxtTaskRepository{FileTaskRepository: repo}, nil
+}
+
+type todoTxtStore struct {
+	todoPath   string
+	donePath   string
+	missingIDs bool
+}
+
+func (s *todoTxtStore) load() ([]*domain.Task, error) {
+	var tasks []*domain.Task
+	seen := make(map[string]int)
+
+	// done.txt is read last so a task found in both files, e.g. after an
+	// interrupted save, ends up completed.
+	for _, path := range []string{s.todoPath, s.donePath} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := interchange.ReadTodoTxt(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		lines := strings.Split(string(data), "\n")
+		for _, record := range records {
+			if !hasTodoTxtID(lines[record.Line-1]) {
+				s.missingIDs = true
+			}
+
+			if i, ok := seen[record.Task.ID]; ok {
+				tasks[i] = record.Task
+				continue
+			}
+			seen[record.Task.ID] = len(tasks)
+			tasks = append(tasks, record.Task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (s *todoTxtStore) assignedIDs() bool {
+	return s.missingIDs
+}
+
+func hasTodoTxtID(line string) bool {
+	for _, field := range strings.Fields(line) {
+		if strings.HasPrefix(field, "id:") && len(field) > len("id:") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *todoTxtStore) save(tasks []*domain.Task) error {
+	var open, done []*domain.Task
+	for _, task := range tasks {
+		if task.Status.IsClosed() {
+			done = append(done, task)
+		} else {
+			open = append(open, task)
+		}
+	}
+
+	// done.txt goes first; see load for why.
+	var doneData, todoData bytes.Buffer
+	if err := interchange.WriteTodoTxt(&doneData, done); err != nil {
+		return err
+	}
+	if err := interchange.WriteTodoTxt(&todoData, open); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(s.donePath, doneData.Bytes()); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.todoPath, todoData.Bytes()); err != nil {
+		return err
+	}
+
+	s.missingIDs = false
+	return nil
+}
@@ -3,21 +3,91 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"todo-list/internal/domain"
 	"todo-list/internal/repository"
+
+	"golang.org/x/text/language"
 )
 
 type TaskService struct {
-	repo repository.TaskRepository
+	repo      repository.TaskRepository
+	clock     domain.Clock
+	workflows domain.Workflows
+	language  func() language.Tag
+	timeLog   repository.TimeEntryRepository
+}
+
+// Option configures a TaskService.
+type Option func(*TaskService)
+
+// WithClock sets where the service gets the current time, time zone and
+// week start from. The default is domain.SystemClock.
+func WithClock(clock domain.Clock) Option {
+	return func(s *TaskService) {
+		s.clock = clock
+	}
+}
+
+// WithWorkflows sets the workflow of each project. Projects without one
+// use domain.DefaultWorkflow.
+func WithWorkflows(workflows domain.Workflows) Option {
+	return func(s *TaskService) {
+		s.workflows = workflows
+	}
+}
+
+// WithLanguage sets where the service gets the language task titles are
+// sorted for, so it can follow the settings. The default is no language in
+// particular.
+func WithLanguage(language func() language.Tag) Option {
+	return func(s *TaskService) {
+		s.language = language
+	}
+}
+
+// WithTimeLog sets where time spent on tasks is logged. The default keeps
+// it in memory.
+func WithTimeLog(timeLog repository.TimeEntryRepository) Option {
+	return func(s *TaskService) {
+		s.timeLog = timeLog
+	}
 }
 
-func NewTaskService(repo repository.TaskRepository) *TaskService {
-	return &TaskService{
-		repo: repo,
+func NewTaskService(repo repository.TaskRepository, opts ...Option) *TaskService {
+	s := &TaskService{
+		repo:    repo,
+		clock:   domain.SystemClock,
+		timeLog: repository.NewMemoryTimeEntryRepository(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// Clock returns the clock the service stamps and filters tasks with.
+func (s *TaskService) Clock() domain.Clock {
+	return s.clock
+}
+
+// Workflow returns the workflow tasks in project follow.
+func (s *TaskService) Workflow(project string) domain.Workflow {
+	return s.workflows.For(project)
+}
+
+// WithTx runs fn with a TaskService whose writes are committed atomically
+// when fn returns nil and discarded otherwise.
+func (s *TaskService) WithTx(ctx context.Context, fn func(ctx context.Context, tx *TaskService) error) error {
+	return s.repo.WithTx(ctx, func(ctx context.Context, repo repository.TaskRepository) error {
+		txService := *s
+		txService.repo = repo
+		return fn(ctx, &txService)
+	})
 }
 
 func (s *TaskService) CreateTask(ctx context.Context, title, description string) (*domain.Task, error) {
@@ -25,7 +95,23 @@ func (s *TaskService) CreateTask(ctx context.Context, title, description string)
 		return nil, errors.New("task title cannot be empty")
 	}
 
-	task := domain.NewTask(title, description)
+	task := domain.NewTaskAt(title, description, s.clock.Now())
+
+	// New tasks go to the end of the manual order.
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last := ""
+	for _, other := range tasks {
+		if other.Rank > last {
+			last = other.Rank
+		}
+	}
+	task.Rank, err = domain.RankBetween(last, "")
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.Create(ctx, task); err != nil {
 		return nil, err
@@ -34,10 +120,87 @@ func (s *TaskService) CreateTask(ctx context.Context, title, description string)
 	return task, nil
 }
 
+// ImportTask stores a fully populated task coming from an import, keeping
+// its ID and timestamps.
+func (s *TaskService) ImportTask(ctx context.Context, task *domain.Task) error {
+	if err := validateTask(task); err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, task)
+}
+
+// ReplaceTask overwrites the stored task with the given one as a whole, for
+// clients that always send the full task.
+func (s *TaskService) ReplaceTask(ctx context.Context, task *domain.Task) error {
+	if err := validateTask(task); err != nil {
+		return err
+	}
+
+	return s.repo.Update(ctx, task)
+}
+
+func validateTask(task *domain.Task) error {
+	if strings.TrimSpace(task.Title) == "" {
+		return errors.New("task title cannot be empty")
+	}
+	if !task.Priority.IsValid() {
+		return fmt.Errorf("invalid priority %q", task.Priority)
+	}
+	if !task.Status.IsValid() {
+		return fmt.Errorf("invalid status %q", task.Status)
+	}
+	if task.ParentID == task.ID {
+		return errors.New("task cannot be its own parent")
+	}
+	if task.DependsOn(task.ID) {
+		return errors.New("task cannot depend on itself")
+	}
+	if !domain.ValidRank(task.Rank) {
+		return fmt.Errorf("invalid rank %q", task.Rank)
+	}
+	if task.DueAllDay && (task.DueDate == nil || !domain.IsDateOnly(*task.DueDate)) {
+		return errors.New("all-day due date must be a date at midnight UTC")
+	}
+	if task.StartDate != nil && !domain.IsDateOnly(*task.StartDate) {
+		return errors.New("start date must be a date at midnight UTC")
+	}
+	if task.Estimate != nil {
+		if err := task.Estimate.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *TaskService) GetAllTasks(ctx context.Context) ([]*domain.Task, error) {
 	return s.repo.GetAll(ctx)
 }
 
+// GetAllTasksSorted returns every task in the order of spec, sorted by the
+// repository when it can.
+func (s *TaskService) GetAllTasksSorted(ctx context.Context, spec domain.SortSpec) ([]*domain.Task, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	locale := domain.SortLocale{Location: s.clock.Now().Location()}
+	if s.language != nil {
+		locale.Language = s.language()
+	}
+
+	if lister, ok := s.repo.(repository.SortedLister); ok {
+		return lister.GetAllSorted(ctx, spec, locale)
+	}
+
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	domain.SortTasks(tasks, spec, locale)
+
+	return tasks, nil
+}
+
 func (s *TaskService) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
 	return s.repo.GetByID(ctx, id)
 }
@@ -51,73 +214,166 @@ func (s *TaskService) GetTasksByPriority(ctx context.Context, priority domain.Pr
 }
 
 func (s *TaskService) GetOverdueTasks(ctx context.Context) ([]*domain.Task, error) {
+	return s.GetTasksByDate(ctx, domain.DateOverdue)
+}
+
+func (s *TaskService) GetTodayTasks(ctx context.Context) ([]*domain.Task, error) {
+	return s.GetTasksByDate(ctx, domain.DateToday)
+}
+
+// GetWeekTasks returns the tasks due in the current calendar week.
+func (s *TaskService) GetWeekTasks(ctx context.Context) ([]*domain.Task, error) {
+	return s.GetTasksByDate(ctx, domain.DateThisWeek)
+}
+
+// GetTasksByDate returns the tasks matching a date preset such as
+// domain.DateTomorrow, evaluated in the clock's time zone.
+func (s *TaskService) GetTasksByDate(ctx context.Context, preset string) ([]*domain.Task, error) {
+	if _, _, err := domain.DatePresetRange(preset, s.clock); err != nil {
+		return nil, err
+	}
+
 	allTasks, err := s.repo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	overdueTasks := make([]*domain.Task, 0)
+	tasks := make([]*domain.Task, 0)
 	for _, task := range allTasks {
-		if task.IsOverdue() {
-			overdueTasks = append(overdueTasks, task)
+		if ok, _ := task.MatchesDatePreset(preset, s.clock); ok {
+			tasks = append(tasks, task)
 		}
 	}
 
-	return overdueTasks, nil
+	return tasks, nil
 }
 
-func (s *TaskService) GetTodayTasks(ctx context.Context) ([]*domain.Task, error) {
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+func (s *TaskService) UpdateTask(ctx context.Context, id, title, description string) (*domain.Task, error) {
+	task, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	allTasks, err := s.repo.GetAll(ctx)
+	if strings.TrimSpace(title) == "" {
+		return nil, errors.New("task title cannot be empty")
+	}
+
+	task.Title = title
+	task.Description = description
+	task.UpdatedAt = s.clock.Now()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// TransitionTask moves a task to status if its project's workflow allows
+// it. Moving a task to the state it is in changes nothing. A task cannot be
+// started or done while tasks it depends on are open.
+func (s *TaskService) TransitionTask(ctx context.Context, id string, status domain.TaskStatus) (*domain.Task, error) {
+	return s.transitionTask(ctx, id, status, false)
+}
+
+// ForceTransitionTask is TransitionTask for when the user chose to go ahead
+// although tasks this one depends on are open. The workflow still applies.
+func (s *TaskService) ForceTransitionTask(ctx context.Context, id string, status domain.TaskStatus) (*domain.Task, error) {
+	return s.transitionTask(ctx, id, status, true)
+}
+
+func (s *TaskService) transitionTask(ctx context.Context, id string, status domain.TaskStatus, force bool) (*domain.Task, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("invalid status %q", status)
+	}
+
+	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if task.Status == status {
+		return task, nil
+	}
 
-	todayTasks := make([]*domain.Task, 0)
-	for _, task := range allTasks {
-		if task.DueDate != nil && task.DueDate.After(startOfDay) && task.DueDate.Before(endOfDay) {
-			todayTasks = append(todayTasks, task)
+	if !s.workflows.For(task.Project).Allows(task.Status, status) {
+		return nil, fmt.Errorf("cannot move task from %s to %s: %w", task.Status, status, domain.ErrTransitionNotAllowed)
+	}
+
+	if !force && (status == domain.InProgressTask || status == domain.DoneTask) {
+		blockers, err := s.openBlockers(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 {
+			titles := make([]string, len(blockers))
+			for i, blocker := range blockers {
+				titles[i] = fmt.Sprintf("%q", blocker.Title)
+			}
+			return nil, fmt.Errorf("%q is waiting for %s: %w", task.Title, strings.Join(titles, ", "), domain.ErrBlocked)
 		}
 	}
 
-	return todayTasks, nil
+	task.Transition(s.clock, status)
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
 }
 
-func (s *TaskService) GetWeekTasks(ctx context.Context) ([]*domain.Task, error) {
-	now := time.Now()
-	weekFromNow := now.Add(7 * 24 * time.Hour)
+func (s *TaskService) SetTaskPriority(ctx context.Context, id string, priority domain.Priority) (*domain.Task, error) {
+	task, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-	allTasks, err := s.repo.GetAll(ctx)
+	task.SetPriority(s.clock, priority)
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *TaskService) SetTaskDueDate(ctx context.Context, id string, dueDate *time.Time, allDay bool) (*domain.Task, error) {
+	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	weekTasks := make([]*domain.Task, 0)
-	for _, task := range allTasks {
-		if task.DueDate != nil && task.DueDate.After(now) && task.DueDate.Before(weekFromNow) {
-			weekTasks = append(weekTasks, task)
-		}
+	task.SetDueDate(s.clock, dueDate, allDay)
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
 	}
 
-	return weekTasks, nil
+	return task, nil
 }
 
-func (s *TaskService) UpdateTask(ctx context.Context, id, title, description string) (*domain.Task, error) {
+func (s *TaskService) SetTaskStartDate(ctx context.Context, id string, startDate *time.Time) (*domain.Task, error) {
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.TrimSpace(title) == "" {
-		return nil, errors.New("task title cannot be empty")
+	task.SetStartDate(s.clock, startDate)
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
 	}
 
-	task.Title = title
-	task.Description = description
-	task.UpdatedAt = time.Now()
+	return task, nil
+}
+
+func (s *TaskService) SetTaskProject(ctx context.Context, id, project string) (*domain.Task, error) {
+	task, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task.SetProject(s.clock, project)
 
 	if err := s.repo.Update(ctx, task); err != nil {
 		return nil, err
@@ -126,13 +382,20 @@ func (s *TaskService) UpdateTask(ctx context.Context, id, title, description str
 	return task, nil
 }
 
-func (s *TaskService) MarkTaskComplete(ctx context.Context, id string) (*domain.Task, error) {
+// SetTaskEstimate sets the planned effort of a task; nil removes it.
+func (s *TaskService) SetTaskEstimate(ctx context.Context, id string, estimate *domain.Estimate) (*domain.Task, error) {
+	if estimate != nil {
+		if err := estimate.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	task.MarkComplete()
+	task.SetEstimate(s.clock, estimate)
 
 	if err := s.repo.Update(ctx, task); err != nil {
 		return nil, err
@@ -141,13 +404,17 @@ func (s *TaskService) MarkTaskComplete(ctx context.Context, id string) (*domain.
 	return task, nil
 }
 
-func (s *TaskService) MarkTaskActive(ctx context.Context, id string) (*domain.Task, error) {
+func (s *TaskService) SetTaskRank(ctx context.Context, id, rank string) (*domain.Task, error) {
+	if !domain.ValidRank(rank) {
+		return nil, fmt.Errorf("invalid rank %q", rank)
+	}
+
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	task.MarkActive()
+	task.SetRank(s.clock, rank)
 
 	if err := s.repo.Update(ctx, task); err != nil {
 		return nil, err
@@ -156,13 +423,41 @@ func (s *TaskService) MarkTaskActive(ctx context.Context, id string) (*domain.Ta
 	return task, nil
 }
 
-func (s *TaskService) SetTaskPriority(ctx context.Context, id string, priority domain.Priority) (*domain.Task, error) {
+// RebalanceRanks gives every task a short, evenly spaced rank in the
+// current manual order, placing unranked tasks at the end. Tasks with a new
+// rank count as updated, so the ranks reach other devices through sync. It
+// returns how many tasks got a new rank.
+func (s *TaskService) RebalanceRanks(ctx context.Context) (int, error) {
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return domain.RankLess(tasks[i], tasks[j])
+	})
+
+	changed := 0
+	for i, rank := range domain.SpreadRanks(len(tasks)) {
+		if tasks[i].Rank == rank {
+			continue
+		}
+		tasks[i].SetRank(s.clock, rank)
+		if err := s.repo.Update(ctx, tasks[i]); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+func (s *TaskService) AddTaskTags(ctx context.Context, id string, tags []string) (*domain.Task, error) {
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	task.SetPriority(priority)
+	task.AddTags(s.clock, tags...)
 
 	if err := s.repo.Update(ctx, task); err != nil {
 		return nil, err
@@ -171,13 +466,49 @@ func (s *TaskService) SetTaskPriority(ctx context.Context, id string, priority d
 	return task, nil
 }
 
-func (s *TaskService) SetTaskDueDate(ctx context.Context, id string, dueDate *time.Time) (*domain.Task, error) {
+// openBlockers returns the tasks task depends on that are still open.
+// Blockers that no longer exist do not count.
+func (s *TaskService) openBlockers(ctx context.Context, task *domain.Task) ([]*domain.Task, error) {
+	blockers := make([]*domain.Task, 0)
+	for _, id := range task.BlockedBy {
+		blocker, err := s.repo.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !blocker.Status.IsClosed() {
+			blockers = append(blockers, blocker)
+		}
+	}
+	return blockers, nil
+}
+
+// AddDependency makes a task wait for blockerID, unless that would make it
+// wait for itself.
+func (s *TaskService) AddDependency(ctx context.Context, id, blockerID string) (*domain.Task, error) {
 	task, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := s.repo.GetByID(ctx, blockerID); err != nil {
+		return nil, err
+	}
+	if task.DependsOn(blockerID) {
+		return task, nil
+	}
 
-	task.SetDueDate(dueDate)
+	tasks, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.NewDependencies(tasks).CheckDependency(id, blockerID); err != nil {
+		return nil, err
+	}
+
+	task.BlockedBy = append(task.BlockedBy, blockerID)
+	task.UpdatedAt = s.clock.Now()
 
 	if err := s.repo.Update(ctx, task); err != nil {
 		return nil, err
@@ -186,6 +517,72 @@ func (s *TaskService) SetTaskDueDate(ctx context.Context, id string, dueDate *ti
 	return task, nil
 }
 
+func (s *TaskService) RemoveDependency(ctx context.Context, id, blockerID string) (*domain.Task, error) {
+	task, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !task.DependsOn(blockerID) {
+		return task, nil
+	}
+
+	task.BlockedBy = removeID(task.BlockedBy, blockerID)
+	task.UpdatedAt = s.clock.Now()
+
+	if err := s.repo.Update(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func removeID(ids []string, id string) []string {
+	kept := make([]string, 0, len(ids))
+	for _, other := range ids {
+		if other != id {
+			kept = append(kept, other)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// DeleteTask deletes a task, stopping its timer, and drops it from the
+// tasks that depend on it. Its time entries stay in the time log.
+//
+// The time log is a store of its own, so the timer is stopped only once the
+// deletion is stored: a failed delete leaves the timer running. If stopping
+// it fails the task is gone all the same, and the error says so.
 func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
-}
\ No newline at end of file
+	err := s.WithTx(ctx, func(ctx context.Context, tx *TaskService) error {
+		if err := tx.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		tasks, err := tx.repo.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if !task.DependsOn(id) {
+				continue
+			}
+			task.BlockedBy = removeID(task.BlockedBy, id)
+			task.UpdatedAt = tx.clock.Now()
+			if err := tx.repo.Update(ctx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.stopTaskTimer(ctx, id); err != nil {
+		return fmt.Errorf("task deleted, but its timer could not be stopped: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+var errCommitFailed = errors.New("commit failed")
+
+// failingRepository fails to commit transactions once failCommit is set,
+// after their writes have been made.
+type failingRepository struct {
+	repository.TaskRepository
+	failCommit *bool
+}
+
+func (r failingRepository) WithTx(ctx context.Context, fn func(ctx context.Context, tx repository.TaskRepository) error) error {
+	return r.TaskRepository.WithTx(ctx, func(ctx context.Context, tx repository.TaskRepository) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		if *r.failCommit {
+			return errCommitFailed
+		}
+		return nil
+	})
+}
+
+func TestDeleteTaskStopsTimer(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(repository.NewMemoryTaskRepository())
+	task, err := s.CreateTask(ctx, "Write report", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartTimer(ctx, task.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	running, err := s.RunningTimer(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running != nil {
+		t.Fatalf("timer of the deleted task still running: %+v", running)
+	}
+	entries, err := s.GetTimeEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d time entries, want the stopped one kept", len(entries))
+	}
+}
+
+func TestFailedDeleteTaskLeavesTimerRunning(t *testing.T) {
+	ctx := context.Background()
+	failCommit := false
+	s := NewTaskService(failingRepository{TaskRepository: repository.NewMemoryTaskRepository(), failCommit: &failCommit})
+	task, err := s.CreateTask(ctx, "Write report", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartTimer(ctx, task.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	failCommit = true
+	if err := s.DeleteTask(ctx, task.ID); !errors.Is(err, errCommitFailed) {
+		t.Fatalf("DeleteTask returned %v, want %v", err, errCommitFailed)
+	}
+	failCommit = false
+
+	if _, err := s.GetTaskByID(ctx, task.ID); err != nil {
+		t.Fatalf("task gone after a failed delete: %v", err)
+	}
+	running, err := s.RunningTimer(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running == nil || running.TaskID != task.ID {
+		t.Fatalf("timer after a failed delete = %+v, want the task's timer running", running)
+	}
+}
+
+func TestTransitionTaskFollowsProjectWorkflow(t *testing.T) {
+	ctx := context.Background()
+	s := NewTaskService(repository.NewMemoryTaskRepository(), WithWorkflows(domain.Workflows{
+		"acme": {domain.TodoTask: {domain.InReviewTask}, domain.InReviewTask: {domain.DoneTask}},
+	}))
+	task, err := s.CreateTask(ctx, "Write report", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetTaskProject(ctx, task.ID, "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.TransitionTask(ctx, task.ID, domain.InProgressTask); !errors.Is(err, domain.ErrTransitionNotAllowed) {
+		t.Fatalf("TransitionTask to in_progress = %v, want ErrTransitionNotAllowed", err)
+	}
+	if _, err := s.ForceTransitionTask(ctx, task.ID, domain.DoneTask); !errors.Is(err, domain.ErrTransitionNotAllowed) {
+		t.Fatalf("ForceTransitionTask to done = %v, want the workflow still enforced", err)
+	}
+	if _, err := s.TransitionTask(ctx, task.ID, domain.InReviewTask); err != nil {
+		t.Fatal(err)
+	}
+	done, err := s.TransitionTask(ctx, task.ID, domain.DoneTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != domain.DoneTask || done.CompletedAt == nil {
+		t.Errorf("task is %s with CompletedAt %v, want it done", done.Status, done.CompletedAt)
+	}
+
+	if _, err := s.TransitionTask(ctx, task.ID, "waiting"); err == nil {
+		t.Error("TransitionTask accepted an unknown status")
+	}
+}
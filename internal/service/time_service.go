@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+)
+
+// StartTimer starts timing work on a task. A timer running for another task
+// is stopped first, so only one ever runs; one already running for this
+// task is returned as is.
+func (s *TaskService) StartTimer(ctx context.Context, taskID, note string) (*domain.TimeEntry, error) {
+	if _, err := s.GetTaskByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	var started *domain.TimeEntry
+	err := s.timeLog.WithTx(ctx, func(ctx context.Context, tx repository.TimeEntryRepository) error {
+		now := s.clock.Now()
+		running, err := runningEntry(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if running != nil && running.TaskID == taskID {
+			started = running
+			return nil
+		}
+		if running != nil {
+			if err := stopEntry(ctx, tx, running, now); err != nil {
+				return err
+			}
+		}
+
+		started = domain.NewTimeEntry(taskID, now, nil, note)
+		return tx.Create(ctx, started)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return started, nil
+}
+
+// StopTimer stops the running timer and returns its entry.
+func (s *TaskService) StopTimer(ctx context.Context) (*domain.TimeEntry, error) {
+	var stopped *domain.TimeEntry
+	err := s.timeLog.WithTx(ctx, func(ctx context.Context, tx repository.TimeEntryRepository) error {
+		running, err := runningEntry(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if running == nil {
+			return domain.ErrNoTimer
+		}
+
+		stopped = running
+		return stopEntry(ctx, tx, running, s.clock.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stopped, nil
+}
+
+// RunningTimer returns the entry of the running timer, or nil if there is
+// none.
+func (s *TaskService) RunningTimer(ctx context.Context) (*domain.TimeEntry, error) {
+	return runningEntry(ctx, s.timeLog)
+}
+
+func runningEntry(ctx context.Context, timeLog repository.TimeEntryRepository) (*domain.TimeEntry, error) {
+	entries, err := timeLog.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsRunning() {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func stopEntry(ctx context.Context, tx repository.TimeEntryRepository, entry *domain.TimeEntry, now time.Time) error {
+	end := now
+	if end.Before(entry.Start) {
+		end = entry.Start
+	}
+	entry.End = &end
+	return tx.Update(ctx, entry)
+}
+
+// AddTimeEntry logs time spent on a task after the fact.
+func (s *TaskService) AddTimeEntry(ctx context.Context, taskID string, start, end time.Time, note string) (*domain.TimeEntry, error) {
+	if _, err := s.GetTaskByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	return s.addTimeEntry(ctx, domain.NewTimeEntry(taskID, start, &end, note))
+}
+
+// RecordPomodoro logs a completed pomodoro as time spent on the task,
+// ending when the pomodoro did and leaving out its pauses.
+func (s *TaskService) RecordPomodoro(ctx context.Context, taskID string, pomodoro domain.FocusPhase) (*domain.TimeEntry, error) {
+	if !pomodoro.IsPomodoro() {
+		return nil, errors.New("only a completed work phase is a pomodoro")
+	}
+	if _, err := s.GetTaskByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	end := pomodoro.End
+	entry := domain.NewTimeEntry(taskID, end.Add(-pomodoro.Worked), &end, "")
+	entry.Focus = true
+	return s.addTimeEntry(ctx, entry)
+}
+
+func (s *TaskService) addTimeEntry(ctx context.Context, entry *domain.TimeEntry) (*domain.TimeEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.timeLog.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// UpdateTimeEntry corrects an entry. Only the running timer may be left
+// without an end.
+func (s *TaskService) UpdateTimeEntry(ctx context.Context, id string, start time.Time, end *time.Time, note string) (*domain.TimeEntry, error) {
+	var entry *domain.TimeEntry
+	err := s.timeLog.WithTx(ctx, func(ctx context.Context, tx repository.TimeEntryRepository) error {
+		var err error
+		entry, err = tx.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if end == nil && !entry.IsRunning() {
+			return errors.New("only the running timer can be left without an end")
+		}
+
+		entry.Start = start
+		entry.End = end
+		entry.Note = note
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+		return tx.Update(ctx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *TaskService) DeleteTimeEntry(ctx context.Context, id string) error {
+	return s.timeLog.Delete(ctx, id)
+}
+
+// stopTaskTimer stops the running timer if it is the task's.
+func (s *TaskService) stopTaskTimer(ctx context.Context, taskID string) error {
+	return s.timeLog.WithTx(ctx, func(ctx context.Context, tx repository.TimeEntryRepository) error {
+		running, err := runningEntry(ctx, tx)
+		if err != nil || running == nil || running.TaskID != taskID {
+			return err
+		}
+		return stopEntry(ctx, tx, running, s.clock.Now())
+	})
+}
+
+// GetTimeEntries returns the time log oldest first.
+func (s *TaskService) GetTimeEntries(ctx context.Context) ([]*domain.TimeEntry, error) {
+	return s.timeLog.GetAll(ctx)
+}
@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/service"
+)
+
+// Board columns are derived from one task field.
+const (
+	BoardByStatus   = "status"
+	BoardByPriority = "priority"
+	BoardByProject  = "project"
+)
+
+// BoardOptions says which field the columns come from and how many cards
+// each column should hold at most.
+type BoardOptions struct {
+	Columns   string         `json:"columns"`
+	WIPLimits map[string]int `json:"wip_limits,omitempty"`
+}
+
+// Column holds the cards with one value of the board's field, in board
+// order. OverLimit is set when it holds more cards than its WIP limit.
+type Column struct {
+	Name      string         `json:"name"`
+	Tasks     []*domain.Task `json:"tasks"`
+	WIPLimit  int            `json:"wip_limit,omitempty"`
+	OverLimit bool           `json:"over_limit,omitempty"`
+}
+
+type Board struct {
+	Columns []Column `json:"columns"`
+}
+
+type MoveCardResult struct {
+	Task *domain.Task `json:"task"`
+	// Warning is set when the move took a column over its WIP limit.
+	Warning string `json:"warning,omitempty"`
+}
+
+// GetBoard groups the tasks matching filter into columns. Status boards show
+// every state of the filtered project's workflow, priority boards every
+// priority, and project boards the projects that have tasks.
+func (uc *TaskUseCase) GetBoard(ctx context.Context, filter TaskFilter, opts BoardOptions) (*Board, error) {
+	names, err := uc.boardColumns(opts.Columns, filter.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := uc.filterTasks(ctx, uc.taskService, filter)
+	if err != nil {
+		return nil, err
+	}
+	sortCards(tasks)
+
+	cards := make(map[string][]*domain.Task)
+	for _, task := range tasks {
+		name := columnOf(task, opts.Columns)
+		if _, known := cards[name]; !known && !contains(names, name) {
+			names = append(names, name)
+		}
+		cards[name] = append(cards[name], task)
+	}
+	if opts.Columns == BoardByProject {
+		sort.Strings(names)
+	}
+
+	board := &Board{Columns: make([]Column, 0, len(names))}
+	for _, name := range names {
+		column := Column{Name: name, Tasks: cards[name], WIPLimit: opts.WIPLimits[name]}
+		if column.Tasks == nil {
+			column.Tasks = []*domain.Task{}
+		}
+		column.OverLimit = column.WIPLimit > 0 && len(column.Tasks) > column.WIPLimit
+		board.Columns = append(board.Columns, column)
+	}
+
+	return board, nil
+}
+
+func (uc *TaskUseCase) boardColumns(field, project string) ([]string, error) {
+	var names []string
+	switch field {
+	case BoardByStatus:
+		for _, status := range uc.taskService.Workflow(project).States() {
+			names = append(names, string(status))
+		}
+	case BoardByPriority:
+		names = []string{string(domain.HighPriority), string(domain.MediumPriority), string(domain.LowPriority)}
+	case BoardByProject:
+	default:
+		return nil, fmt.Errorf("unknown board columns %q", field)
+	}
+	return names, nil
+}
+
+// MoveCard moves a task into column at position (0 is the top) in one
+// transaction: it changes the task's field to the column's value, which for
+// status boards has to be allowed by the workflow, and ranks it between the
+// cards it was dropped between. Going over the column's WIP limit is
+// allowed but reported.
+func (uc *TaskUseCase) MoveCard(ctx context.Context, id, column string, position int, opts BoardOptions) (*MoveCardResult, error) {
+	if _, err := uc.boardColumns(opts.Columns, ""); err != nil {
+		return nil, err
+	}
+
+	result := &MoveCardResult{}
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		task, err := tx.GetTaskByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if columnOf(task, opts.Columns) != column {
+			switch opts.Columns {
+			case BoardByStatus:
+				_, err = tx.TransitionTask(ctx, id, domain.TaskStatus(column))
+			case BoardByPriority:
+				if !domain.Priority(column).IsValid() {
+					return fmt.Errorf("invalid priority %q", column)
+				}
+				_, err = tx.SetTaskPriority(ctx, id, domain.Priority(column))
+			case BoardByProject:
+				_, err = tx.SetTaskProject(ctx, id, column)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		all, err := tx.GetAllTasks(ctx)
+		if err != nil {
+			return err
+		}
+		sortCards(all)
+
+		cards := make([]*domain.Task, 0)
+		for _, card := range all {
+			if card.ID != id && columnOf(card, opts.Columns) == column {
+				cards = append(cards, card)
+			}
+		}
+		if position < 0 || position > len(cards) {
+			position = len(cards)
+		}
+		var beforeID, afterID string
+		if position > 0 {
+			beforeID = cards[position-1].ID
+		}
+		if position < len(cards) {
+			afterID = cards[position].ID
+		}
+
+		result.Task, err = reorder(ctx, tx, id, beforeID, afterID)
+		if err != nil {
+			return err
+		}
+
+		if limit := opts.WIPLimits[column]; limit > 0 && len(cards)+1 > limit {
+			result.Warning = fmt.Sprintf("%s has %d cards, over its WIP limit of %d", column, len(cards)+1, limit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func columnOf(task *domain.Task, field string) string {
+	switch field {
+	case BoardByPriority:
+		return string(task.Priority)
+	case BoardByProject:
+		return task.Project
+	}
+	return string(task.Status)
+}
+
+func sortCards(tasks []*domain.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return domain.RankLess(tasks[i], tasks[j])
+	})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
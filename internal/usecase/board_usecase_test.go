@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"todo-list/internal/domain"
+)
+
+func createCards(t *testing.T, uc *TaskUseCase, titles ...string) []string {
+	t.Helper()
+	ids := make([]string, len(titles))
+	for i, title := range titles {
+		task, err := uc.CreateTask(context.Background(), CreateTaskRequest{Title: title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+// columnTitles returns the titles in column of a fresh board.
+func columnTitles(t *testing.T, uc *TaskUseCase, opts BoardOptions, column string) string {
+	t.Helper()
+	board, err := uc.GetBoard(context.Background(), TaskFilter{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range board.Columns {
+		if c.Name == column {
+			titles := make([]string, len(c.Tasks))
+			for i, task := range c.Tasks {
+				titles[i] = task.Title
+			}
+			return strings.Join(titles, ",")
+		}
+	}
+	t.Fatalf("board has no column %q", column)
+	return ""
+}
+
+func TestMoveCardChangesStatus(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	ids := createCards(t, uc, "A", "B", "C")
+	opts := BoardOptions{Columns: BoardByStatus}
+
+	result, err := uc.MoveCard(ctx, ids[2], string(domain.InProgressTask), 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Task.Status != domain.InProgressTask || result.Task.StartedAt == nil {
+		t.Errorf("moved card is %s with StartedAt %v, want it started", result.Task.Status, result.Task.StartedAt)
+	}
+	if result.Warning != "" {
+		t.Errorf("Warning = %q without a WIP limit", result.Warning)
+	}
+
+	if _, err := uc.MoveCard(ctx, ids[0], string(domain.InProgressTask), 0, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := columnTitles(t, uc, opts, string(domain.InProgressTask)); got != "A,C" {
+		t.Errorf("in_progress = %s, want A,C", got)
+	}
+	if got := columnTitles(t, uc, opts, string(domain.TodoTask)); got != "B" {
+		t.Errorf("todo = %s, want B", got)
+	}
+
+	// Reordering within a column leaves the status alone.
+	result, err = uc.MoveCard(ctx, ids[0], string(domain.InProgressTask), 5, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := columnTitles(t, uc, opts, string(domain.InProgressTask)); got != "C,A" {
+		t.Errorf("in_progress = %s after moving A past the end, want C,A", got)
+	}
+	if result.Task.Status != domain.InProgressTask {
+		t.Errorf("reordered card is %s, want it still in_progress", result.Task.Status)
+	}
+}
+
+func TestMoveCardFollowsWorkflow(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	ids := createCards(t, uc, "A", "B")
+	opts := BoardOptions{Columns: BoardByStatus}
+	before := columnTitles(t, uc, opts, string(domain.TodoTask))
+
+	_, err := uc.MoveCard(ctx, ids[1], string(domain.InReviewTask), 0, opts)
+	if !errors.Is(err, domain.ErrTransitionNotAllowed) {
+		t.Fatalf("MoveCard to in_review = %v, want ErrTransitionNotAllowed", err)
+	}
+	task, err := uc.taskService.GetTaskByID(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != domain.TodoTask {
+		t.Errorf("refused card is %s, want it left in todo", task.Status)
+	}
+	if got := columnTitles(t, uc, opts, string(domain.TodoTask)); got != before {
+		t.Errorf("todo = %s after a refused move, want %s", got, before)
+	}
+}
+
+func TestMoveCardWarnsOverWIPLimit(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	ids := createCards(t, uc, "A", "B", "C")
+	opts := BoardOptions{Columns: BoardByStatus, WIPLimits: map[string]int{string(domain.InProgressTask): 2}}
+
+	for _, id := range ids[:2] {
+		result, err := uc.MoveCard(ctx, id, string(domain.InProgressTask), -1, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Warning != "" {
+			t.Errorf("Warning = %q at or under the limit", result.Warning)
+		}
+	}
+
+	result, err := uc.MoveCard(ctx, ids[2], string(domain.InProgressTask), -1, opts)
+	if err != nil {
+		t.Fatalf("MoveCard over the WIP limit = %v, want it allowed", err)
+	}
+	if want := "in_progress has 3 cards, over its WIP limit of 2"; result.Warning != want {
+		t.Errorf("Warning = %q, want %q", result.Warning, want)
+	}
+	if got := columnTitles(t, uc, opts, string(domain.InProgressTask)); got != "A,B,C" {
+		t.Errorf("in_progress = %s, want A,B,C", got)
+	}
+
+	board, err := uc.GetBoard(ctx, TaskFilter{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range board.Columns {
+		if want := column.Name == string(domain.InProgressTask); column.OverLimit != want {
+			t.Errorf("column %s OverLimit = %v, want %v", column.Name, column.OverLimit, want)
+		}
+	}
+}
+
+func TestMoveCardChangesPriorityAndProject(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	ids := createCards(t, uc, "A")
+
+	result, err := uc.MoveCard(ctx, ids[0], string(domain.HighPriority), 0, BoardOptions{Columns: BoardByPriority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Task.Priority != domain.HighPriority {
+		t.Errorf("Priority = %s, want high", result.Task.Priority)
+	}
+	if _, err := uc.MoveCard(ctx, ids[0], "urgent", 0, BoardOptions{Columns: BoardByPriority}); err == nil {
+		t.Error("MoveCard accepted an unknown priority")
+	}
+
+	result, err = uc.MoveCard(ctx, ids[0], "acme", 0, BoardOptions{Columns: BoardByProject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Task.Project != "acme" {
+		t.Errorf("Project = %q, want acme", result.Task.Project)
+	}
+
+	if _, err := uc.MoveCard(ctx, ids[0], "x", 0, BoardOptions{Columns: "colour"}); err == nil {
+		t.Error("MoveCard accepted unknown board columns")
+	}
+}
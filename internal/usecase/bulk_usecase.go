@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/service"
+)
+
+// BulkSelector picks the tasks a bulk operation applies to: either explicit
+// IDs or every task matching Filter.
+type BulkSelector struct {
+	IDs    []string    `json:"ids,omitempty"`
+	Filter *TaskFilter `json:"filter,omitempty"`
+}
+
+type BulkItemResult struct {
+	ID    string       `json:"id"`
+	Task  *domain.Task `json:"task,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+type BulkResult struct {
+	Items     []BulkItemResult `json:"items"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+func (uc *TaskUseCase) BulkComplete(ctx context.Context, selector BulkSelector) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.TransitionTask(ctx, id, domain.DoneTask)
+	})
+}
+
+func (uc *TaskUseCase) BulkDelete(ctx context.Context, selector BulkSelector) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return nil, tx.DeleteTask(ctx, id)
+	})
+}
+
+func (uc *TaskUseCase) BulkSetPriority(ctx context.Context, selector BulkSelector, priority string) (*BulkResult, error) {
+	if !domain.Priority(priority).IsValid() {
+		return nil, fmt.Errorf("invalid priority %q", priority)
+	}
+
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.SetTaskPriority(ctx, id, domain.Priority(priority))
+	})
+}
+
+func (uc *TaskUseCase) BulkSetDueDate(ctx context.Context, selector BulkSelector, dueDate *time.Time, allDay bool) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.SetTaskDueDate(ctx, id, dueDate, allDay)
+	})
+}
+
+func (uc *TaskUseCase) BulkSetStartDate(ctx context.Context, selector BulkSelector, startDate *time.Time) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.SetTaskStartDate(ctx, id, startDate)
+	})
+}
+
+func (uc *TaskUseCase) BulkMoveToProject(ctx context.Context, selector BulkSelector, project string) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.SetTaskProject(ctx, id, project)
+	})
+}
+
+func (uc *TaskUseCase) BulkAddTags(ctx context.Context, selector BulkSelector, tags []string) (*BulkResult, error) {
+	return uc.bulk(ctx, selector, func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error) {
+		return tx.AddTaskTags(ctx, id, tags)
+	})
+}
+
+// bulk applies op to every selected task inside one transaction. A failing
+// item is reported in its result and does not abort the others.
+func (uc *TaskUseCase) bulk(ctx context.Context, selector BulkSelector, op func(ctx context.Context, tx *service.TaskService, id string) (*domain.Task, error)) (*BulkResult, error) {
+	result := &BulkResult{Items: make([]BulkItemResult, 0)}
+
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		ids, err := uc.selectIDs(ctx, tx, selector)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			item := BulkItemResult{ID: id}
+
+			task, err := op(ctx, tx, id)
+			if err != nil {
+				item.Error = err.Error()
+				result.Failed++
+			} else {
+				item.Task = task
+				result.Succeeded++
+			}
+
+			result.Items = append(result.Items, item)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (uc *TaskUseCase) selectIDs(ctx context.Context, tx *service.TaskService, selector BulkSelector) ([]string, error) {
+	if len(selector.IDs) > 0 {
+		seen := make(map[string]bool, len(selector.IDs))
+		ids := make([]string, 0, len(selector.IDs))
+		for _, id := range selector.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return inDependencyOrder(ctx, tx, ids)
+	}
+
+	if selector.Filter == nil {
+		return nil, errors.New("bulk operation needs task ids or a filter")
+	}
+
+	tasks, err := uc.filterTasks(ctx, tx, *selector.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return inDependencyOrder(ctx, tx, ids)
+}
+
+// inDependencyOrder puts tasks after the tasks they wait for, so a task can
+// be completed together with its blockers. Unknown IDs go last to fail on
+// their own.
+func inDependencyOrder(ctx context.Context, tx *service.TaskService, ids []string) ([]string, error) {
+	all, err := tx.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*domain.Task, len(all))
+	for _, task := range all {
+		byID[task.ID] = task
+	}
+
+	selected := make([]*domain.Task, 0, len(ids))
+	var unknown []string
+	for _, id := range ids {
+		if task, ok := byID[id]; ok {
+			selected = append(selected, task)
+		} else {
+			unknown = append(unknown, id)
+		}
+	}
+
+	ordered := make([]string, 0, len(ids))
+	for _, task := range domain.NewDependencies(all).Order(selected) {
+		ordered = append(ordered, task.ID)
+	}
+	return append(ordered, unknown...), nil
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+
+	"todo-list/internal/domain"
+)
+
+// TaskDependencies shows where a task stands among the tasks it waits for
+// and the tasks waiting for it. Blocked is set while it is in the blocked
+// state or waits for an open task.
+type TaskDependencies struct {
+	BlockedBy []*domain.Task `json:"blocked_by"`
+	Blocks    []*domain.Task `json:"blocks"`
+	Blocked   bool           `json:"blocked"`
+}
+
+// DependencyPlan lists open tasks in an order they can be worked through,
+// each after the tasks it waits for, and the longest chain of tasks waiting
+// for each other among them.
+type DependencyPlan struct {
+	Order        []*domain.Task `json:"order"`
+	CriticalPath []*domain.Task `json:"critical_path"`
+}
+
+func (uc *TaskUseCase) AddDependency(ctx context.Context, id, blockerID string) (*domain.Task, error) {
+	return uc.taskService.AddDependency(ctx, id, blockerID)
+}
+
+func (uc *TaskUseCase) RemoveDependency(ctx context.Context, id, blockerID string) (*domain.Task, error) {
+	return uc.taskService.RemoveDependency(ctx, id, blockerID)
+}
+
+func (uc *TaskUseCase) GetDependencies(ctx context.Context, id string) (*TaskDependencies, error) {
+	task, err := uc.taskService.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := uc.taskService.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := domain.NewDependencies(tasks)
+	result := &TaskDependencies{
+		BlockedBy: deps.Blockers(task),
+		Blocks:    deps.Dependents(id),
+		Blocked:   deps.IsBlocked(task),
+	}
+	if result.Blocks == nil {
+		result.Blocks = []*domain.Task{}
+	}
+
+	return result, nil
+}
+
+// GetDependencyPlan plans the open tasks matching filter. Where dependencies
+// leave a choice, tasks keep the order of sort.
+func (uc *TaskUseCase) GetDependencyPlan(ctx context.Context, filter TaskFilter, sort TaskSort) (*DependencyPlan, error) {
+	spec, err := sort.Spec()
+	if err != nil {
+		return nil, err
+	}
+	all, err := uc.taskService.GetAllTasksSorted(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := applyFilter(ctx, uc.taskService, all, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	open := make([]*domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !task.Status.IsClosed() {
+			open = append(open, task)
+		}
+	}
+
+	deps := domain.NewDependencies(all)
+	plan := &DependencyPlan{
+		Order:        deps.Order(open),
+		CriticalPath: deps.CriticalPath(open, func(*domain.Task) int { return 1 }),
+	}
+	if plan.CriticalPath == nil {
+		plan.CriticalPath = []*domain.Task{}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/service"
+)
+
+// Effort is a sum of estimates. Durations and story points cannot be added
+// together, so they are summed apart.
+type Effort struct {
+	Minutes int     `json:"minutes"`
+	Points  float64 `json:"points"`
+}
+
+func (e *Effort) add(estimate *domain.Estimate) {
+	if estimate != nil {
+		e.Minutes += estimate.Minutes
+		e.Points += estimate.Points
+	}
+}
+
+// EffortSummary compares planned and actual effort of a set of tasks.
+// Remaining is estimated for open tasks and Completed for done ones;
+// cancelled tasks are left out of the estimates. OverEstimate lists the
+// tasks with more time tracked than estimated.
+type EffortSummary struct {
+	Tasks          int            `json:"tasks"`
+	Unestimated    int            `json:"unestimated"`
+	Estimated      Effort         `json:"estimated"`
+	Remaining      Effort         `json:"remaining"`
+	Completed      Effort         `json:"completed"`
+	TrackedSeconds int64          `json:"tracked_seconds"`
+	OverEstimate   []*domain.Task `json:"over_estimate"`
+}
+
+// SetTaskEstimate sets the estimate of a task from text such as "1h30m" or
+// "3pt"; an empty estimate removes it.
+func (uc *TaskUseCase) SetTaskEstimate(ctx context.Context, id, estimate string) (*domain.Task, error) {
+	parsed, err := domain.ParseEstimate(estimate)
+	if err != nil {
+		return nil, err
+	}
+	return uc.taskService.SetTaskEstimate(ctx, id, parsed)
+}
+
+// GetEffortSummary sums the estimates and tracked time of the tasks
+// matching filter, such as those due this week.
+func (uc *TaskUseCase) GetEffortSummary(ctx context.Context, filter TaskFilter) (*EffortSummary, error) {
+	tasks, err := uc.filterTasks(ctx, uc.taskService, filter)
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := trackedTime(ctx, uc.taskService)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &EffortSummary{Tasks: len(tasks), OverEstimate: []*domain.Task{}}
+	var trackedTotal time.Duration
+	for _, task := range tasks {
+		trackedTotal += tracked[task.ID]
+		if task.IsOverEstimate(tracked[task.ID]) {
+			summary.OverEstimate = append(summary.OverEstimate, task)
+		}
+
+		if task.Status == domain.CancelledTask {
+			continue
+		}
+		if task.Estimate == nil {
+			summary.Unestimated++
+			continue
+		}
+		summary.Estimated.add(task.Estimate)
+		if task.Status == domain.DoneTask {
+			summary.Completed.add(task.Estimate)
+		} else {
+			summary.Remaining.add(task.Estimate)
+		}
+	}
+	summary.TrackedSeconds = int64(trackedTotal.Round(time.Second) / time.Second)
+
+	return summary, nil
+}
+
+// trackedTime returns the time logged on each task, counting the running
+// timer up to now.
+func trackedTime(ctx context.Context, taskService *service.TaskService) (map[string]time.Duration, error) {
+	entries, err := taskService.GetTimeEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := taskService.Clock().Now()
+	tracked := make(map[string]time.Duration)
+	for _, entry := range entries {
+		tracked[entry.TaskID] += entry.Duration(now)
+	}
+	return tracked, nil
+}
+
+// estimateFilter returns whether a task passes the estimate filter of a
+// TaskFilter, or nil when it lets every task through. A comparison only
+// matches tasks estimated in the same unit.
+func estimateFilter(ctx context.Context, taskService *service.TaskService, value string) (func(*domain.Task) bool, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "", "all":
+		return nil, nil
+	case "none":
+		return func(task *domain.Task) bool { return task.Estimate == nil }, nil
+	case "estimated":
+		return func(task *domain.Task) bool { return task.Estimate != nil }, nil
+	case "over":
+		tracked, err := trackedTime(ctx, taskService)
+		if err != nil {
+			return nil, err
+		}
+		return func(task *domain.Task) bool { return task.IsOverEstimate(tracked[task.ID]) }, nil
+	}
+
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+		rest, ok := strings.CutPrefix(value, op)
+		if !ok {
+			continue
+		}
+		limit, err := domain.ParseEstimate(rest)
+		if err != nil {
+			return nil, err
+		}
+		if limit == nil {
+			break
+		}
+
+		return func(task *domain.Task) bool {
+			if task.Estimate == nil || task.Estimate.IsPoints() != limit.IsPoints() {
+				return false
+			}
+			n := task.Estimate.Compare(*limit)
+			switch op {
+			case "<=":
+				return n <= 0
+			case ">=":
+				return n >= 0
+			case "<":
+				return n < 0
+			case ">":
+				return n > 0
+			}
+			return n == 0
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown estimate filter %q", value)
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+)
+
+// newEffortUseCase returns tasks estimated in both units, one of them over
+// its estimate and one with its timer still running.
+func newEffortUseCase(t *testing.T) *TaskUseCase {
+	t.Helper()
+	ctx := context.Background()
+	clock := &steppedClock{now: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)}
+	uc := NewTaskUseCase(service.NewTaskService(repository.NewMemoryTaskRepository(), service.WithClock(clock)))
+
+	ids := make(map[string]string)
+	for _, req := range []CreateTaskRequest{
+		{Title: "Plan", Estimate: "30m"},
+		{Title: "Build", Estimate: "2h"},
+		{Title: "Shipped", Estimate: "1h"},
+		{Title: "Dropped", Estimate: "1h"},
+		{Title: "Size", Estimate: "3pt"},
+		{Title: "Polish", Estimate: "5pt"},
+		{Title: "Loose"},
+	} {
+		task, err := uc.CreateTask(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[task.Title] = task.ID
+	}
+
+	if _, err := uc.TransitionTask(ctx, ids["Shipped"], string(domain.DoneTask), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.TransitionTask(ctx, ids["Dropped"], string(domain.CancelledTask), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uc.StartTimer(ctx, ids["Plan"]); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(45 * time.Minute)
+	if _, err := uc.StopTimer(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.StartTimer(ctx, ids["Build"]); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(time.Hour)
+	return uc
+}
+
+func TestEstimateFilter(t *testing.T) {
+	uc := newEffortUseCase(t)
+
+	tests := []struct {
+		estimate string
+		want     string
+	}{
+		{"", "Build,Dropped,Loose,Plan,Polish,Shipped,Size"},
+		{"none", "Loose"},
+		{"estimated", "Build,Dropped,Plan,Polish,Shipped,Size"},
+		{"over", "Plan"},
+		{"<=2h", "Build,Dropped,Plan,Shipped"},
+		{">=1h", "Build,Dropped,Shipped"},
+		{"<1h", "Plan"},
+		{">2h", ""},
+		{"=60m", "Dropped,Shipped"},
+		{" >= 3pt ", "Polish,Size"},
+		{"<=4pt", "Size"},
+		{"=3pt", "Size"},
+		// Comparisons never match tasks estimated in the other unit.
+		{"<=100pt", "Polish,Size"},
+		{">1m", "Build,Dropped,Plan,Shipped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.estimate, func(t *testing.T) {
+			tasks, err := uc.GetFilteredAndSortedTasks(context.Background(), TaskFilter{Estimate: tt.estimate}, TaskSort{Field: "title"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := make([]string, len(tasks))
+			for i, task := range tasks {
+				titles[i] = task.Title
+			}
+			if got := strings.Join(titles, ","); got != tt.want {
+				t.Errorf("tasks = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateFilterErrors(t *testing.T) {
+	uc := newTestUseCase()
+	for _, estimate := range []string{"~2h", "<=", "<=soon", "2h"} {
+		if _, err := uc.GetFilteredAndSortedTasks(context.Background(), TaskFilter{Estimate: estimate}, TaskSort{}); err == nil {
+			t.Errorf("estimate filter %q was accepted", estimate)
+		}
+	}
+}
+
+func TestGetEffortSummary(t *testing.T) {
+	uc := newEffortUseCase(t)
+
+	summary, err := uc.GetEffortSummary(context.Background(), TaskFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Tasks != 7 || summary.Unestimated != 1 {
+		t.Errorf("Tasks %d Unestimated %d, want 7 and 1", summary.Tasks, summary.Unestimated)
+	}
+	// Dropped is cancelled, so its hour counts nowhere.
+	if want := (Effort{Minutes: 210, Points: 8}); summary.Estimated != want {
+		t.Errorf("Estimated = %+v, want %+v", summary.Estimated, want)
+	}
+	if want := (Effort{Minutes: 150, Points: 8}); summary.Remaining != want {
+		t.Errorf("Remaining = %+v, want %+v", summary.Remaining, want)
+	}
+	if want := (Effort{Minutes: 60}); summary.Completed != want {
+		t.Errorf("Completed = %+v, want %+v", summary.Completed, want)
+	}
+	// 45 minutes on Plan and the running hour on Build.
+	if want := int64((105 * time.Minute) / time.Second); summary.TrackedSeconds != want {
+		t.Errorf("TrackedSeconds = %d, want %d", summary.TrackedSeconds, want)
+	}
+	if len(summary.OverEstimate) != 1 || summary.OverEstimate[0].Title != "Plan" {
+		t.Errorf("OverEstimate = %v, want only Plan", summary.OverEstimate)
+	}
+
+	summary, err = uc.GetEffortSummary(context.Background(), TaskFilter{Estimate: ">=3pt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Effort{Points: 8}); summary.Tasks != 2 || summary.Estimated != want || summary.TrackedSeconds != 0 {
+		t.Errorf("summary of point tasks = %+v, want 2 tasks of 8 points and nothing tracked", summary)
+	}
+}
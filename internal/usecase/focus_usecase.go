@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"todo-list/internal/domain"
+)
+
+var errNoFocusSession = errors.New("no focus session is running")
+
+// FocusTimer runs one Pomodoro focus session at a time and records the
+// pomodoros it completes against the session's task. Run drives it; the
+// listeners hear of every tick and every change of phase.
+type FocusTimer struct {
+	taskUseCase *TaskUseCase
+	durations   func() domain.FocusDurations
+
+	mutex   sync.Mutex
+	session *domain.FocusSession
+	onTick  func(domain.FocusState)
+	onPhase func(domain.FocusState, domain.FocusPhase)
+}
+
+// NewFocusTimer takes the phase lengths from durations whenever a session
+// starts.
+func NewFocusTimer(taskUseCase *TaskUseCase, durations func() domain.FocusDurations) *FocusTimer {
+	return &FocusTimer{taskUseCase: taskUseCase, durations: durations}
+}
+
+func (f *FocusTimer) OnTick(fn func(domain.FocusState)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onTick = fn
+}
+
+// OnPhase is called with the new state and the phase that ended, whether
+// its time was up or it was skipped.
+func (f *FocusTimer) OnPhase(fn func(domain.FocusState, domain.FocusPhase)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onPhase = fn
+}
+
+// Run checks the session every interval until ctx is done.
+func (f *FocusTimer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.tick(ctx)
+		}
+	}
+}
+
+func (f *FocusTimer) tick(ctx context.Context) {
+	f.mutex.Lock()
+	if f.session == nil {
+		f.mutex.Unlock()
+		return
+	}
+	now := f.now()
+	taskID := f.session.TaskID
+	ended := f.session.Advance(now)
+	state := f.session.State(now)
+	onTick, onPhase := f.onTick, f.onPhase
+	f.mutex.Unlock()
+
+	if ended != nil {
+		f.phaseEnded(ctx, taskID, state, *ended, onPhase)
+	}
+	if onTick != nil {
+		onTick(state)
+	}
+}
+
+// phaseEnded records a completed pomodoro and tells the listener.
+func (f *FocusTimer) phaseEnded(ctx context.Context, taskID string, state domain.FocusState, ended domain.FocusPhase, onPhase func(domain.FocusState, domain.FocusPhase)) {
+	if ended.IsPomodoro() {
+		if _, err := f.taskUseCase.taskService.RecordPomodoro(ctx, taskID, ended); err != nil {
+			println("Failed to record pomodoro:", err.Error())
+		}
+	}
+	if onPhase != nil {
+		onPhase(state, ended)
+	}
+}
+
+// Start begins a session on a task with a work phase, ending any session
+// already running. The time tracking timer is stopped so the same time is
+// not logged twice.
+func (f *FocusTimer) Start(ctx context.Context, taskID string) (domain.FocusState, error) {
+	if _, err := f.taskUseCase.GetTask(ctx, taskID); err != nil {
+		return domain.FocusState{}, err
+	}
+	if _, err := f.taskUseCase.StopTimer(ctx); err != nil && !errors.Is(err, domain.ErrNoTimer) {
+		return domain.FocusState{}, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	now := f.now()
+	f.session = domain.NewFocusSession(taskID, f.durations(), now)
+	return f.session.State(now), nil
+}
+
+func (f *FocusTimer) Pause() (domain.FocusState, error) {
+	return f.update(func(session *domain.FocusSession, now time.Time) error {
+		return session.Pause(now)
+	})
+}
+
+func (f *FocusTimer) Resume() (domain.FocusState, error) {
+	return f.update(func(session *domain.FocusSession, now time.Time) error {
+		return session.Resume(now)
+	})
+}
+
+func (f *FocusTimer) update(change func(session *domain.FocusSession, now time.Time) error) (domain.FocusState, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.session == nil {
+		return domain.FocusState{}, errNoFocusSession
+	}
+	now := f.now()
+	if err := change(f.session, now); err != nil {
+		return domain.FocusState{}, err
+	}
+	return f.session.State(now), nil
+}
+
+// Skip ends the current phase early; a skipped work phase is not recorded.
+func (f *FocusTimer) Skip(ctx context.Context) (domain.FocusState, error) {
+	f.mutex.Lock()
+	if f.session == nil {
+		f.mutex.Unlock()
+		return domain.FocusState{}, errNoFocusSession
+	}
+	now := f.now()
+	taskID := f.session.TaskID
+	ended := f.session.Skip(now)
+	state := f.session.State(now)
+	onPhase := f.onPhase
+	f.mutex.Unlock()
+
+	f.phaseEnded(ctx, taskID, state, *ended, onPhase)
+	return state, nil
+}
+
+// Stop ends the session. The pomodoro it was in, if any, is not recorded.
+func (f *FocusTimer) Stop() domain.FocusState {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.session = nil
+	return domain.FocusState{}
+}
+
+func (f *FocusTimer) State() domain.FocusState {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.session.State(f.now())
+}
+
+func (f *FocusTimer) now() time.Time {
+	return f.taskUseCase.taskService.Clock().Now()
+}
+
+// FocusDay is the focus time of one day, as 2006-01-02. A pomodoro counts
+// on the day it ended.
+type FocusDay struct {
+	Date      string `json:"date"`
+	Pomodoros int    `json:"pomodoros"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// GetFocusStats returns the days with pomodoros matching filter, oldest
+// first.
+func (uc *TaskUseCase) GetFocusStats(ctx context.Context, filter TimeFilter) ([]FocusDay, error) {
+	entries, log, period, err := uc.selectTimeEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	focus := make([]*domain.TimeEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Focus {
+			focus = append(focus, entry)
+		}
+	}
+	segments, err := log.Segments(focus, domain.TimeByDay, period)
+	if err != nil {
+		return nil, err
+	}
+
+	pomodoros := make(map[string]int)
+	for _, segment := range segments {
+		if segment.Entry.End != nil && segment.End.Equal(*segment.Entry.End) {
+			pomodoros[segment.Group]++
+		}
+	}
+
+	days := make([]FocusDay, 0)
+	for _, total := range log.Totals(segments, domain.TimeByDay) {
+		days = append(days, FocusDay{Date: total.Group, Pomodoros: pomodoros[total.Group], Seconds: total.Seconds})
+	}
+	return days, nil
+}
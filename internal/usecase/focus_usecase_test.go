@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+)
+
+// steppedClock is a clock tests move forward by hand.
+type steppedClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *steppedClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *steppedClock) WeekStart() time.Weekday { return time.Monday }
+
+func (c *steppedClock) advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var focusDurations = domain.FocusDurations{Work: 25 * time.Minute, ShortBreak: 5 * time.Minute, LongBreak: 15 * time.Minute, Cycles: 4}
+
+func newTestFocusTimer(t *testing.T) (*FocusTimer, *TaskUseCase, *steppedClock, *domain.Task) {
+	t.Helper()
+	clock := &steppedClock{now: time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)}
+	uc := NewTaskUseCase(service.NewTaskService(repository.NewMemoryTaskRepository(), service.WithClock(clock)))
+	task, err := uc.CreateTask(context.Background(), CreateTaskRequest{Title: "Write report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timer := NewFocusTimer(uc, func() domain.FocusDurations { return focusDurations })
+	return timer, uc, clock, task
+}
+
+func pomodoros(t *testing.T, uc *TaskUseCase) []*domain.TimeEntry {
+	t.Helper()
+	entries, err := uc.GetTimeEntries(context.Background(), TimeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var focus []*domain.TimeEntry
+	for _, entry := range entries {
+		if entry.Focus {
+			focus = append(focus, entry)
+		}
+	}
+	return focus
+}
+
+func TestFocusTimerRecordsPomodoros(t *testing.T) {
+	ctx := context.Background()
+	timer, uc, clock, task := newTestFocusTimer(t)
+
+	var phases []domain.FocusPhase
+	timer.OnPhase(func(state domain.FocusState, ended domain.FocusPhase) {
+		phases = append(phases, ended)
+	})
+
+	// A running time tracking timer is stopped so the time is not logged
+	// twice.
+	if _, err := uc.StartTimer(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	state, err := timer.Start(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Active || state.Phase != domain.FocusWork || state.Remaining != 25*60 {
+		t.Fatalf("started %+v, want 25m of work", state)
+	}
+	if running, err := uc.taskService.RunningTimer(ctx); err != nil || running != nil {
+		t.Fatalf("time tracking timer %+v (%v), want it stopped", running, err)
+	}
+
+	clock.advance(24 * time.Minute)
+	timer.tick(ctx)
+	if len(phases) != 0 {
+		t.Fatalf("phases %+v ended early", phases)
+	}
+
+	clock.advance(time.Minute)
+	timer.tick(ctx)
+	if len(phases) != 1 || !phases[0].IsPomodoro() {
+		t.Fatalf("phases %+v, want one pomodoro", phases)
+	}
+	if state := timer.State(); state.Phase != domain.FocusShortBreak || state.Completed != 1 {
+		t.Errorf("state %+v, want a short break after one pomodoro", state)
+	}
+
+	recorded := pomodoros(t, uc)
+	if len(recorded) != 1 || recorded[0].TaskID != task.ID || recorded[0].Duration(clock.Now()) != 25*time.Minute {
+		t.Fatalf("recorded %+v, want one 25m pomodoro of %s", recorded, task.ID)
+	}
+
+	// The break is not recorded.
+	clock.advance(5 * time.Minute)
+	timer.tick(ctx)
+	if len(phases) != 2 || len(pomodoros(t, uc)) != 1 {
+		t.Errorf("phases %+v with %d pomodoros, want the break ended and still one pomodoro", phases, len(pomodoros(t, uc)))
+	}
+}
+
+func TestFocusTimerSkipPauseResume(t *testing.T) {
+	ctx := context.Background()
+	timer, uc, clock, task := newTestFocusTimer(t)
+
+	if _, err := timer.Pause(); !errors.Is(err, errNoFocusSession) {
+		t.Errorf("Pause without a session = %v, want %v", err, errNoFocusSession)
+	}
+	if _, err := timer.Skip(ctx); !errors.Is(err, errNoFocusSession) {
+		t.Errorf("Skip without a session = %v, want %v", err, errNoFocusSession)
+	}
+
+	if _, err := timer.Start(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.advance(10 * time.Minute)
+	state, err := timer.Pause()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Paused || state.Remaining != 15*60 {
+		t.Errorf("paused %+v, want 15m left", state)
+	}
+	if _, err := timer.Pause(); !errors.Is(err, domain.ErrFocusPaused) {
+		t.Errorf("second Pause = %v, want %v", err, domain.ErrFocusPaused)
+	}
+
+	// Paused time does not count.
+	clock.advance(time.Hour)
+	timer.tick(ctx)
+	if state := timer.State(); state.Phase != domain.FocusWork || state.Remaining != 15*60 {
+		t.Errorf("after an hour paused %+v, want work with 15m left", state)
+	}
+
+	state, err = timer.Resume()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Paused {
+		t.Errorf("resumed %+v, want it running", state)
+	}
+	if _, err := timer.Resume(); !errors.Is(err, domain.ErrFocusNotPaused) {
+		t.Errorf("second Resume = %v, want %v", err, domain.ErrFocusNotPaused)
+	}
+
+	clock.advance(5 * time.Minute)
+	state, err = timer.Skip(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Phase != domain.FocusShortBreak || state.Completed != 0 {
+		t.Errorf("after skipping work %+v, want a short break and no pomodoro", state)
+	}
+	if recorded := pomodoros(t, uc); len(recorded) != 0 {
+		t.Errorf("a skipped work phase was recorded: %+v", recorded)
+	}
+
+	if state := timer.Stop(); state.Active {
+		t.Errorf("stopped %+v, want no session", state)
+	}
+	if state := timer.State(); state.Active {
+		t.Errorf("state after Stop %+v, want no session", state)
+	}
+}
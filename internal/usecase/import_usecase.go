@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/importer"
+	"todo-list/internal/interchange"
+	"todo-list/internal/service"
+)
+
+const (
+	ImportRowImported    = "imported"
+	ImportRowWouldImport = "would_import"
+	ImportRowDuplicate   = "duplicate"
+	ImportRowInvalid     = "invalid"
+)
+
+// ImportRowReport describes what happened to one record. Task is the task as
+// it is (or, in a dry run, would be) stored, so a dry run doubles as preview.
+type ImportRowReport struct {
+	Line     int          `json:"line"`
+	Title    string       `json:"title"`
+	Result   string       `json:"result"`
+	TaskID   string       `json:"task_id,omitempty"`
+	Errors   []string     `json:"errors,omitempty"`
+	Warnings []string     `json:"warnings,omitempty"`
+	Task     *domain.Task `json:"task,omitempty"`
+}
+
+type ImportReport struct {
+	Source     string            `json:"source,omitempty"`
+	Format     string            `json:"format,omitempty"`
+	DryRun     bool              `json:"dry_run"`
+	Rows       []ImportRowReport `json:"rows"`
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+}
+
+func (uc *TaskUseCase) ExportTasksCSV(ctx context.Context, w io.Writer, filter TaskFilter, sort TaskSort, opts interchange.CSVOptions) error {
+	tasks, err := uc.GetFilteredAndSortedTasks(ctx, filter, sort)
+	if err != nil {
+		return err
+	}
+
+	return interchange.WriteCSV(w, tasks, opts)
+}
+
+func (uc *TaskUseCase) ExportTasksMarkdown(ctx context.Context, w io.Writer, filter TaskFilter, sort TaskSort, opts interchange.MarkdownOptions) error {
+	tasks, err := uc.GetFilteredAndSortedTasks(ctx, filter, sort)
+	if err != nil {
+		return err
+	}
+
+	return interchange.WriteMarkdown(w, tasks, opts)
+}
+
+func (uc *TaskUseCase) ExportTasksICal(ctx context.Context, w io.Writer, filter TaskFilter) error {
+	tasks, err := uc.GetFilteredAndSortedTasks(ctx, filter, TaskSort{Field: "due_date", Order: "asc"})
+	if err != nil {
+		return err
+	}
+
+	return interchange.WriteICalendar(w, tasks, "Tasks")
+}
+
+func (uc *TaskUseCase) ImportTasksICal(ctx context.Context, r io.Reader, dryRun bool) (*ImportReport, error) {
+	records, err := interchange.ReadICalendar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.importRecords(ctx, records, dryRun, duplicateKey)
+}
+
+func (uc *TaskUseCase) ImportTasksCSV(ctx context.Context, r io.Reader, opts interchange.CSVImportOptions, dryRun bool) (*ImportReport, error) {
+	records, err := interchange.ReadCSV(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.importRecords(ctx, records, dryRun, duplicateKey)
+}
+
+// ImportTasks decodes data with the importer registered as format, or the
+// detected one when format is empty, and imports it like any other file.
+func (uc *TaskUseCase) ImportTasks(ctx context.Context, data []byte, filename, format string, dryRun bool) (*ImportReport, error) {
+	var source importer.Importer
+	var err error
+	if format == "" {
+		source, err = importer.Detect(filename, data)
+	} else {
+		source, err = importer.Lookup(format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := source.Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source.Description(), err)
+	}
+
+	key := duplicateKey
+	if keeper, ok := source.(importer.IDKeeper); ok && keeper.KeepsIDs() {
+		key = nil
+	}
+	report, err := uc.importRecords(ctx, records, dryRun, key)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Source = filename
+	report.Format = source.Name()
+	return report, nil
+}
+
+// duplicateKey identifies a task by title and due date, which is how users
+// recognise the same task across spreadsheets.
+func duplicateKey(task *domain.Task) string {
+	key := strings.ToLower(strings.TrimSpace(task.Title))
+	if task.DueDate != nil {
+		key += "|" + task.DueDate.UTC().Format("2006-01-02T15:04")
+	}
+	return key
+}
+
+// ExportTasksJSON writes every task as a versioned JSON backup.
+func (uc *TaskUseCase) ExportTasksJSON(ctx context.Context, w io.Writer) error {
+	tasks, err := uc.taskService.GetAllTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	return interchange.WriteJSON(w, tasks)
+}
+
+// ImportTasksJSON restores a JSON backup, possibly made with another
+// storage backend. Tasks keep their IDs and timestamps and only a task with
+// the same ID counts as a duplicate, so restoring twice is harmless.
+func (uc *TaskUseCase) ImportTasksJSON(ctx context.Context, r io.Reader, dryRun bool) (*ImportReport, error) {
+	records, err := interchange.ReadJSON(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := uc.importRecords(ctx, records, dryRun, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Format = "json"
+	return report, nil
+}
+
+// importRecords creates every valid, non-duplicate record in a single
+// transaction. In dry-run mode nothing is written but the report is the
+// same. Besides IDs, tasks with the same key count as duplicates; a nil key
+// matches by ID only. Records may refer to each other as parent and subtask
+// or as tasks waiting for each other; references to a record that turns
+// out to be a duplicate are pointed at the existing task, references to
+// records that are not imported are dropped, and so are dependencies that
+// would close a cycle.
+func (uc *TaskUseCase) importRecords(ctx context.Context, records []interchange.Record, dryRun bool, key func(*domain.Task) string) (*ImportReport, error) {
+	if key == nil {
+		key = func(task *domain.Task) string { return "" }
+	}
+
+	report := &ImportReport{DryRun: dryRun, Rows: make([]ImportRowReport, 0, len(records))}
+
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		existing, err := tx.GetAllTasks(ctx)
+		if err != nil {
+			return err
+		}
+
+		// seen maps task IDs and duplicate keys to the ID of the task they
+		// belong to.
+		seen := make(map[string]string, 2*len(existing))
+		for _, task := range existing {
+			seen[task.ID] = task.ID
+			seen[key(task)] = task.ID
+		}
+		delete(seen, "")
+
+		// First pass: classify every record and learn which ID each
+		// imported ID ends up as.
+		resolved := make(map[string]string, len(records))
+		for _, record := range records {
+			row := ImportRowReport{Line: record.Line, Errors: record.Errors, Warnings: record.Warnings}
+			if record.Task != nil {
+				row.Title = record.Task.Title
+			}
+
+			switch {
+			case !record.Valid():
+				row.Result = ImportRowInvalid
+			case seen[record.Task.ID] != "":
+				row.Result = ImportRowDuplicate
+				row.TaskID = seen[record.Task.ID]
+			case seen[key(record.Task)] != "":
+				row.Result = ImportRowDuplicate
+				row.TaskID = seen[key(record.Task)]
+			default:
+				row.Result = ImportRowWouldImport
+				row.TaskID = record.Task.ID
+				seen[record.Task.ID] = record.Task.ID
+				if k := key(record.Task); k != "" {
+					seen[k] = record.Task.ID
+				}
+			}
+			if record.Task != nil && row.Result != ImportRowInvalid {
+				resolved[record.Task.ID] = row.TaskID
+			}
+
+			report.Rows = append(report.Rows, row)
+		}
+
+		// Second pass: fix parent and dependency references and write.
+		// graph holds the tasks stored so far, to check new dependencies
+		// against.
+		graph := existing
+		for i, record := range records {
+			row := &report.Rows[i]
+			if row.Result != ImportRowWouldImport {
+				continue
+			}
+
+			task := record.Task
+			if task.ParentID != "" {
+				parentID := resolved[task.ParentID]
+				if parentID == "" {
+					parentID = seen[task.ParentID]
+				}
+				if parentID == "" || parentID == task.ID {
+					row.Warnings = append(row.Warnings, "parent task is not imported, added as a top-level task")
+				}
+				if parentID == task.ID {
+					parentID = ""
+				}
+				task.ParentID = parentID
+			}
+			if len(task.BlockedBy) > 0 {
+				candidate := task.Clone()
+				candidate.BlockedBy = nil
+				deps := domain.NewDependencies(append(graph[:len(graph):len(graph)], candidate))
+
+				blockers := make([]string, 0, len(task.BlockedBy))
+				for _, id := range task.BlockedBy {
+					blockerID := resolved[id]
+					if blockerID == "" {
+						blockerID = seen[id]
+					}
+					if blockerID == "" || blockerID == task.ID {
+						row.Warnings = append(row.Warnings, "a task it depends on is not imported, dependency dropped")
+						continue
+					}
+					if err := deps.CheckDependency(task.ID, blockerID); err != nil {
+						row.Warnings = append(row.Warnings, "dependency dropped: "+err.Error())
+						continue
+					}
+					blockers = append(blockers, blockerID)
+				}
+				task.BlockedBy = blockers
+				if len(blockers) == 0 {
+					task.BlockedBy = nil
+				}
+			}
+			row.Task = task
+
+			if dryRun {
+				graph = append(graph, task)
+				continue
+			}
+			if err := tx.ImportTask(ctx, task); err != nil {
+				row.Result = ImportRowInvalid
+				row.TaskID = ""
+				row.Errors = append(row.Errors, err.Error())
+				continue
+			}
+			graph = append(graph, task)
+			row.Result = ImportRowImported
+		}
+
+		for _, row := range report.Rows {
+			switch row.Result {
+			case ImportRowImported, ImportRowWouldImport:
+				report.Imported++
+			case ImportRowDuplicate:
+				report.Duplicates++
+			case ImportRowInvalid:
+				report.Invalid++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
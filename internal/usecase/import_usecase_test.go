@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+)
+
+func newTestUseCase() *TaskUseCase {
+	return NewTaskUseCase(service.NewTaskService(repository.NewMemoryTaskRepository()))
+}
+
+func TestImportTasksKeepsSameTitlesFromOtherApps(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	data := []byte(`[
+{"uuid":"a1","description":"Review","status":"pending","entry":"20240101T090000Z","project":"A"},
+{"uuid":"b2","description":"Review","status":"pending","entry":"20240101T090000Z","project":"B"}
+]`)
+
+	report, err := uc.ImportTasks(ctx, data, "export.json", "taskwarrior", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 2 || report.Duplicates != 0 {
+		t.Fatalf("imported %d, duplicates %d; want 2 and 0", report.Imported, report.Duplicates)
+	}
+
+	report, err = uc.ImportTasks(ctx, data, "export.json", "taskwarrior", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 0 || report.Duplicates != 2 {
+		t.Fatalf("second import: imported %d, duplicates %d; want 0 and 2", report.Imported, report.Duplicates)
+	}
+}
+
+func TestImportTasksMatchesTitlesWithoutIDs(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestUseCase()
+	data := []byte("- [ ] Review\n")
+
+	if _, err := uc.ImportTasks(ctx, data, "tasks.md", "markdown", false); err != nil {
+		t.Fatal(err)
+	}
+	report, err := uc.ImportTasks(ctx, data, "tasks.md", "markdown", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 0 || report.Duplicates != 1 {
+		t.Fatalf("imported %d, duplicates %d; want 0 and 1", report.Imported, report.Duplicates)
+	}
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/service"
+)
+
+// rankRebalanceLength is how long ranks may grow from repeated reordering
+// in one spot before all ranks are spread out again.
+const rankRebalanceLength = 12
+
+// ReorderTask moves a task between beforeID and afterID in the manual order
+// by changing only its own rank. An empty beforeID moves it to the top and
+// an empty afterID to the bottom.
+func (uc *TaskUseCase) ReorderTask(ctx context.Context, id, beforeID, afterID string) (*domain.Task, error) {
+	var task *domain.Task
+
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		var err error
+		task, err = reorder(ctx, tx, id, beforeID, afterID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// RebalanceRanks spreads out the ranks once reordering has made some of
+// them long or tasks without a rank exist. It returns how many tasks got
+// a new rank.
+func (uc *TaskUseCase) RebalanceRanks(ctx context.Context) (int, error) {
+	changed := 0
+
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		tasks, err := tx.GetAllTasks(ctx)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if task.Rank == "" || len(task.Rank) > rankRebalanceLength {
+				changed, err = tx.RebalanceRanks(ctx)
+				return err
+			}
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+func reorder(ctx context.Context, tx *service.TaskService, id, beforeID, afterID string) (*domain.Task, error) {
+	if id == beforeID || id == afterID {
+		return nil, errors.New("task cannot be placed next to itself")
+	}
+	if _, err := tx.GetTaskByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rank, err := rankBetween(ctx, tx, beforeID, afterID)
+	if err != nil {
+		// The neighbours have no rank yet or were reordered themselves;
+		// spreading all ranks settles both.
+		if _, err := tx.RebalanceRanks(ctx); err != nil {
+			return nil, err
+		}
+		if rank, err = rankBetween(ctx, tx, beforeID, afterID); err != nil {
+			return nil, fmt.Errorf("cannot place task between %s and %s: %w", beforeID, afterID, err)
+		}
+	}
+
+	task, err := tx.SetTaskRank(ctx, id, rank)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rank) > rankRebalanceLength {
+		if _, err := tx.RebalanceRanks(ctx); err != nil {
+			return nil, err
+		}
+		return tx.GetTaskByID(ctx, id)
+	}
+
+	return task, nil
+}
+
+func rankBetween(ctx context.Context, tx *service.TaskService, beforeID, afterID string) (string, error) {
+	var before, after string
+	if beforeID != "" {
+		task, err := tx.GetTaskByID(ctx, beforeID)
+		if err != nil {
+			return "", err
+		}
+		if task.Rank == "" {
+			return "", fmt.Errorf("task %s has no rank", beforeID)
+		}
+		before = task.Rank
+	}
+	if afterID != "" {
+		task, err := tx.GetTaskByID(ctx, afterID)
+		if err != nil {
+			return "", err
+		}
+		if task.Rank == "" {
+			return "", fmt.Errorf("task %s has no rank", afterID)
+		}
+		after = task.Rank
+	}
+
+	return domain.RankBetween(before, after)
+}
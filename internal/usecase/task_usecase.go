@@ -2,13 +2,19 @@ package usecase
 
 import (
 	"context"
-	"sort"
+	"errors"
+	"strings"
 	"time"
 
 	"todo-list/internal/domain"
+	"todo-list/internal/repository"
 	"todo-list/internal/service"
 )
 
+// ErrTaskModified is returned by UpsertTask when the caller's precondition
+// does not hold for the stored task.
+var ErrTaskModified = errors.New("task was modified")
+
 type TaskUseCase struct {
 	taskService *service.TaskService
 }
@@ -24,100 +30,208 @@ type CreateTaskRequest struct {
 	Description string     `json:"description"`
 	Priority    string     `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueAllDay   bool       `json:"due_all_day,omitempty"`
+	StartDate   *time.Time `json:"start_date,omitempty"`
+	Estimate    string     `json:"estimate,omitempty"`
 }
 
 type TaskFilter struct {
 	Status   string `json:"status"`
 	Priority string `json:"priority"`
 	DateType string `json:"date"`
+	Project  string `json:"project,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	// Estimate is none, estimated, over (more time tracked than estimated)
+	// or a comparison such as "<=2h" or ">3pt".
+	Estimate string `json:"estimate,omitempty"`
 }
 
+// TaskSort orders tasks by Keys. Without keys, Field is either one field
+// sorted in Order or a whole spec such as "priority desc,due_date,title".
 type TaskSort struct {
-	Field string `json:"field"`
-	Order string `json:"order"`
+	Field string          `json:"field"`
+	Order string          `json:"order"`
+	Keys  domain.SortSpec `json:"keys,omitempty"`
 }
 
-func (uc *TaskUseCase) CreateTask(ctx context.Context, req CreateTaskRequest) (*domain.Task, error) {
-	task, err := uc.taskService.CreateTask(ctx, req.Title, req.Description)
-	if err != nil {
-		return nil, err
+// Spec returns the keys s sorts by. Tasks are sorted by creation when no
+// field is given, and the manual order ignores Order so it always shows the
+// way the user arranged it.
+func (s TaskSort) Spec() (domain.SortSpec, error) {
+	if len(s.Keys) > 0 {
+		return s.Keys, s.Keys.Validate()
+	}
+
+	field := strings.TrimSpace(s.Field)
+	switch {
+	case field == "":
+		field = string(domain.SortCreated)
+	case strings.ContainsAny(field, ", "):
+		return domain.ParseSortSpec(field)
 	}
 
-	if req.Priority != "" {
-		priority := domain.Priority(req.Priority)
-		if priority == domain.LowPriority || priority == domain.MediumPriority || priority == domain.HighPriority {
-			task, err = uc.taskService.SetTaskPriority(ctx, task.ID, priority)
+	if domain.SortField(field) == domain.SortManual {
+		return domain.SortSpec{{Field: domain.SortManual}, {Field: domain.SortCreated}}, nil
+	}
+	spec := domain.SortSpec{{Field: domain.SortField(field), Desc: s.Order == "desc"}}
+	return spec, spec.Validate()
+}
+
+func (uc *TaskUseCase) CreateTask(ctx context.Context, req CreateTaskRequest) (*domain.Task, error) {
+	var task *domain.Task
+
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		var err error
+		task, err = tx.CreateTask(ctx, req.Title, req.Description)
+		if err != nil {
+			return err
+		}
+
+		if req.Priority != "" {
+			priority := domain.Priority(req.Priority)
+			if priority == domain.LowPriority || priority == domain.MediumPriority || priority == domain.HighPriority {
+				task, err = tx.SetTaskPriority(ctx, task.ID, priority)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if req.DueDate != nil {
+			task, err = tx.SetTaskDueDate(ctx, task.ID, req.DueDate, req.DueAllDay)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		}
-	}
 
-	if req.DueDate != nil {
-		task, err = uc.taskService.SetTaskDueDate(ctx, task.ID, req.DueDate)
-		if err != nil {
-			return nil, err
+		if req.StartDate != nil {
+			task, err = tx.SetTaskStartDate(ctx, task.ID, req.StartDate)
+			if err != nil {
+				return err
+			}
+		}
+
+		if req.Estimate != "" {
+			estimate, err := domain.ParseEstimate(req.Estimate)
+			if err != nil {
+				return err
+			}
+			task, err = tx.SetTaskEstimate(ctx, task.ID, estimate)
+			if err != nil {
+				return err
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return task, nil
 }
 
 func (uc *TaskUseCase) GetFilteredAndSortedTasks(ctx context.Context, filter TaskFilter, sort TaskSort) ([]*domain.Task, error) {
-	var tasks []*domain.Task
-	var err error
-
-	switch filter.Status {
-	case "active":
-		tasks, err = uc.taskService.GetTasksByStatus(ctx, domain.ActiveTask)
-	case "completed":
-		tasks, err = uc.taskService.GetTasksByStatus(ctx, domain.CompletedTask)
-	default:
-		tasks, err = uc.taskService.GetAllTasks(ctx)
+	spec, err := sort.Spec()
+	if err != nil {
+		return nil, err
 	}
 
+	tasks, err := uc.taskService.GetAllTasksSorted(ctx, spec)
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter by priority
-	if filter.Priority != "all" && filter.Priority != "" {
-		priorityFiltered := make([]*domain.Task, 0)
-		priority := domain.Priority(filter.Priority)
-		for _, task := range tasks {
-			if task.Priority == priority {
-				priorityFiltered = append(priorityFiltered, task)
-			}
-		}
-		tasks = priorityFiltered
+	return applyFilter(ctx, uc.taskService, tasks, filter)
+}
+
+// filterTasks takes the service explicitly so it can also run inside a
+// transaction.
+func (uc *TaskUseCase) filterTasks(ctx context.Context, taskService *service.TaskService, filter TaskFilter) ([]*domain.Task, error) {
+	tasks, err := taskService.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Filter by date
-	switch filter.DateType {
-	case "today":
-		todayTasks, err := uc.taskService.GetTodayTasks(ctx)
-		if err != nil {
+	return applyFilter(ctx, taskService, tasks, filter)
+}
+
+// applyFilter keeps the tasks matching filter in the order they are given.
+func applyFilter(ctx context.Context, taskService *service.TaskService, tasks []*domain.Task, filter TaskFilter) ([]*domain.Task, error) {
+	clock := taskService.Clock()
+	estimated, err := estimateFilter(ctx, taskService, filter.Estimate)
+	if err != nil {
+		return nil, err
+	}
+	dated := filter.DateType != "" && filter.DateType != "all"
+	if dated {
+		if _, _, err := domain.DatePresetRange(filter.DateType, clock); err != nil {
 			return nil, err
 		}
-		tasks = uc.intersectTasks(tasks, todayTasks)
-	case "week":
-		weekTasks, err := uc.taskService.GetWeekTasks(ctx)
-		if err != nil {
-			return nil, err
+	}
+	priority := domain.Priority(filter.Priority)
+	anyPriority := filter.Priority == "all" || filter.Priority == ""
+	var deps *domain.Dependencies
+	if filter.Status == "ready" || filter.Status == string(domain.BlockedTask) {
+		deps = domain.NewDependencies(tasks)
+	}
+
+	filtered := make([]*domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		// Besides the workflow states, active, deferred and completed group
+		// them: open tasks that can be worked on now, open tasks waiting for
+		// their start date, and done or cancelled tasks. Ready tasks are
+		// active ones not blocked, while blocked also covers tasks waiting
+		// for open tasks they depend on.
+		switch filter.Status {
+		case "ready":
+			if !deps.IsReady(task, clock) {
+				continue
+			}
+		case string(domain.BlockedTask):
+			if task.Status.IsClosed() || !deps.IsBlocked(task) {
+				continue
+			}
+		case "active":
+			if task.Status.IsClosed() || task.IsDeferred(clock) {
+				continue
+			}
+		case "deferred":
+			if !task.IsDeferred(clock) {
+				continue
+			}
+		case "completed":
+			if !task.Status.IsClosed() {
+				continue
+			}
+		default:
+			if domain.TaskStatus(filter.Status).IsValid() && task.Status != domain.TaskStatus(filter.Status) {
+				continue
+			}
 		}
-		tasks = uc.intersectTasks(tasks, weekTasks)
-	case "overdue":
-		overdueTasks, err := uc.taskService.GetOverdueTasks(ctx)
-		if err != nil {
-			return nil, err
+
+		if !anyPriority && task.Priority != priority {
+			continue
+		}
+		if filter.Project != "" && task.Project != filter.Project {
+			continue
+		}
+		if filter.Tag != "" && !task.HasTag(filter.Tag) {
+			continue
+		}
+		if dated {
+			if ok, _ := task.MatchesDatePreset(filter.DateType, clock); !ok {
+				continue
+			}
+		}
+		if estimated != nil && !estimated(task) {
+			continue
 		}
-		tasks = uc.intersectTasks(tasks, overdueTasks)
-	}
 
-	// Apply sorting
-	uc.sortTasks(tasks, sort)
+		filtered = append(filtered, task)
+	}
 
-	return tasks, nil
+	return filtered, nil
 }
 
 func (uc *TaskUseCase) GetTask(ctx context.Context, id string) (*domain.Task, error) {
@@ -128,86 +242,92 @@ func (uc *TaskUseCase) UpdateTask(ctx context.Context, id, title, description st
 	return uc.taskService.UpdateTask(ctx, id, title, description)
 }
 
-func (uc *TaskUseCase) ToggleTaskStatus(ctx context.Context, id string) (*domain.Task, error) {
-	task, err := uc.taskService.GetTaskByID(ctx, id)
-	if err != nil {
-		return nil, err
+// TransitionTask moves a task to status. With override set, the task can be
+// started or done although tasks it depends on are open.
+func (uc *TaskUseCase) TransitionTask(ctx context.Context, id, status string, override bool) (*domain.Task, error) {
+	if override {
+		return uc.taskService.ForceTransitionTask(ctx, id, domain.TaskStatus(status))
 	}
+	return uc.taskService.TransitionTask(ctx, id, domain.TaskStatus(status))
+}
 
-	if task.Status == domain.ActiveTask {
-		return uc.taskService.MarkTaskComplete(ctx, id)
-	} else {
-		return uc.taskService.MarkTaskActive(ctx, id)
-	}
+// Workflow returns the workflow tasks in project follow.
+func (uc *TaskUseCase) Workflow(project string) domain.Workflow {
+	return uc.taskService.Workflow(project)
 }
 
 func (uc *TaskUseCase) SetTaskPriority(ctx context.Context, id, priority string) (*domain.Task, error) {
 	return uc.taskService.SetTaskPriority(ctx, id, domain.Priority(priority))
 }
 
-func (uc *TaskUseCase) SetTaskDueDate(ctx context.Context, id string, dueDate *time.Time) (*domain.Task, error) {
-	return uc.taskService.SetTaskDueDate(ctx, id, dueDate)
+func (uc *TaskUseCase) SetTaskDueDate(ctx context.Context, id string, dueDate *time.Time, allDay bool) (*domain.Task, error) {
+	return uc.taskService.SetTaskDueDate(ctx, id, dueDate, allDay)
+}
+
+func (uc *TaskUseCase) SetTaskStartDate(ctx context.Context, id string, startDate *time.Time) (*domain.Task, error) {
+	return uc.taskService.SetTaskStartDate(ctx, id, startDate)
 }
 
 func (uc *TaskUseCase) DeleteTask(ctx context.Context, id string) error {
 	return uc.taskService.DeleteTask(ctx, id)
 }
 
-func (uc *TaskUseCase) intersectTasks(tasks1, tasks2 []*domain.Task) []*domain.Task {
-	taskMap := make(map[string]*domain.Task)
-	for _, task := range tasks1 {
-		taskMap[task.ID] = task
-	}
+// UpsertTask creates the task with the given ID or overwrites the stored
+// one. precondition, when set, sees the stored task (nil if there is none)
+// inside the transaction and can veto the write; build makes the task to
+// write from it. The task keeps its ID and creation time, and is returned as
+// read back from the repository.
+func (uc *TaskUseCase) UpsertTask(ctx context.Context, id string, precondition func(current *domain.Task) bool, build func(current *domain.Task) *domain.Task) (*domain.Task, bool, error) {
+	var stored *domain.Task
+	created := false
 
-	result := make([]*domain.Task, 0)
-	for _, task := range tasks2 {
-		if _, exists := taskMap[task.ID]; exists {
-			result = append(result, task)
+	err := uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		current, err := tx.GetTaskByID(ctx, id)
+		if err != nil && !errors.Is(err, repository.ErrTaskNotFound) {
+			return err
+		}
+		if err != nil {
+			current = nil
 		}
-	}
-
-	return result
-}
 
-func (uc *TaskUseCase) sortTasks(tasks []*domain.Task, sortBy TaskSort) {
-	switch sortBy.Field {
-	case "priority":
-		sort.Slice(tasks, func(i, j int) bool {
-			priorityOrder := map[domain.Priority]int{
-				domain.HighPriority:   3,
-				domain.MediumPriority: 2,
-				domain.LowPriority:    1,
-			}
+		if precondition != nil && !precondition(current) {
+			return ErrTaskModified
+		}
 
-			if sortBy.Order == "desc" {
-				return priorityOrder[tasks[i].Priority] > priorityOrder[tasks[j].Priority]
-			}
-			return priorityOrder[tasks[i].Priority] < priorityOrder[tasks[j].Priority]
-		})
-	case "due_date":
-		sort.Slice(tasks, func(i, j int) bool {
-			// Tasks without due dates go to the end
-			if tasks[i].DueDate == nil && tasks[j].DueDate == nil {
-				return false
-			}
-			if tasks[i].DueDate == nil {
-				return false
-			}
-			if tasks[j].DueDate == nil {
-				return true
-			}
+		task := build(current)
+		task.ID = id
+		task.UpdatedAt = tx.Clock().Now()
+		if current == nil {
+			created = true
+			err = tx.ImportTask(ctx, task)
+		} else {
+			task.CreatedAt = current.CreatedAt
+			err = tx.ReplaceTask(ctx, task)
+		}
+		if err != nil {
+			return err
+		}
 
-			if sortBy.Order == "desc" {
-				return tasks[i].DueDate.After(*tasks[j].DueDate)
-			}
-			return tasks[i].DueDate.Before(*tasks[j].DueDate)
-		})
-	default: // "created"
-		sort.Slice(tasks, func(i, j int) bool {
-			if sortBy.Order == "desc" {
-				return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
-			}
-			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
-		})
+		stored, err = tx.GetTaskByID(ctx, task.ID)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
 	}
+
+	return stored, created, nil
+}
+
+// DeleteTaskIf deletes the task only when precondition holds for it.
+func (uc *TaskUseCase) DeleteTaskIf(ctx context.Context, id string, precondition func(current *domain.Task) bool) error {
+	return uc.taskService.WithTx(ctx, func(ctx context.Context, tx *service.TaskService) error {
+		current, err := tx.GetTaskByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !precondition(current) {
+			return ErrTaskModified
+		}
+		return tx.DeleteTask(ctx, id)
+	})
 }
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"todo-list/internal/domain"
+	"todo-list/internal/interchange"
+)
+
+// TimeFilter picks time log entries. Period is a date preset such as today,
+// this_week or this_month; From and To, when set, replace its ends. Entries
+// reaching over the ends only count the time between them.
+type TimeFilter struct {
+	TaskID  string     `json:"task_id,omitempty"`
+	Project string     `json:"project,omitempty"`
+	Period  string     `json:"period,omitempty"`
+	From    *time.Time `json:"from,omitempty"`
+	To      *time.Time `json:"to,omitempty"`
+}
+
+// TimeReport totals the time spent per group, in the order of the groups'
+// labels.
+type TimeReport struct {
+	GroupBy string             `json:"group_by"`
+	Totals  []domain.TimeTotal `json:"totals"`
+	Seconds int64              `json:"seconds"`
+}
+
+func (uc *TaskUseCase) StartTimer(ctx context.Context, taskID string) (*domain.TimeEntry, error) {
+	return uc.taskService.StartTimer(ctx, taskID, "")
+}
+
+func (uc *TaskUseCase) StopTimer(ctx context.Context) (*domain.TimeEntry, error) {
+	return uc.taskService.StopTimer(ctx)
+}
+
+func (uc *TaskUseCase) GetRunningTimer(ctx context.Context) (*domain.TimeEntry, error) {
+	return uc.taskService.RunningTimer(ctx)
+}
+
+func (uc *TaskUseCase) AddTimeEntry(ctx context.Context, taskID string, start, end time.Time, note string) (*domain.TimeEntry, error) {
+	return uc.taskService.AddTimeEntry(ctx, taskID, start, end, note)
+}
+
+func (uc *TaskUseCase) UpdateTimeEntry(ctx context.Context, id string, start time.Time, end *time.Time, note string) (*domain.TimeEntry, error) {
+	return uc.taskService.UpdateTimeEntry(ctx, id, start, end, note)
+}
+
+func (uc *TaskUseCase) DeleteTimeEntry(ctx context.Context, id string) error {
+	return uc.taskService.DeleteTimeEntry(ctx, id)
+}
+
+// GetTimeEntries returns the entries matching filter, newest first.
+func (uc *TaskUseCase) GetTimeEntries(ctx context.Context, filter TimeFilter) ([]*domain.TimeEntry, error) {
+	entries, _, period, err := uc.selectTimeEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.taskService.Clock().Now()
+	result := make([]*domain.TimeEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		start, end := entries[i].Span(now)
+		if (period.From.IsZero() || end.After(period.From)) && (period.To.IsZero() || start.Before(period.To)) {
+			result = append(result, entries[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (uc *TaskUseCase) GetTimeReport(ctx context.Context, filter TimeFilter, groupBy string) (*TimeReport, error) {
+	entries, log, period, err := uc.selectTimeEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := log.Segments(entries, groupBy, period)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TimeReport{GroupBy: groupBy, Totals: log.Totals(segments, groupBy)}
+	for _, total := range report.Totals {
+		report.Seconds += total.Seconds
+	}
+
+	return report, nil
+}
+
+func (uc *TaskUseCase) ExportTimeLogCSV(ctx context.Context, w io.Writer, filter TimeFilter, opts interchange.TimeLogOptions) error {
+	entries, log, period, err := uc.selectTimeEntries(ctx, filter)
+	if err != nil {
+		return err
+	}
+	segments, err := log.Segments(entries, opts.GroupBy, period)
+	if err != nil {
+		return err
+	}
+
+	return interchange.WriteTimeLogCSV(w, log, segments, opts)
+}
+
+// selectTimeEntries returns the entries of the task or project filter asks
+// for, oldest first, with the period they are cut to.
+func (uc *TaskUseCase) selectTimeEntries(ctx context.Context, filter TimeFilter) ([]*domain.TimeEntry, *domain.TimeLog, domain.DateRange, error) {
+	var period domain.DateRange
+	if filter.Period != "" {
+		dateRange, isRange, err := domain.DatePresetRange(filter.Period, uc.taskService.Clock())
+		if err != nil {
+			return nil, nil, period, err
+		}
+		if !isRange {
+			return nil, nil, period, fmt.Errorf("%q is not a period", filter.Period)
+		}
+		period = dateRange
+	}
+	if filter.From != nil {
+		period.From = *filter.From
+	}
+	if filter.To != nil {
+		period.To = *filter.To
+	}
+
+	tasks, err := uc.taskService.GetAllTasks(ctx)
+	if err != nil {
+		return nil, nil, period, err
+	}
+	all, err := uc.taskService.GetTimeEntries(ctx)
+	if err != nil {
+		return nil, nil, period, err
+	}
+
+	log := domain.NewTimeLog(tasks, uc.taskService.Clock())
+	entries := make([]*domain.TimeEntry, 0, len(all))
+	for _, entry := range all {
+		if filter.TaskID != "" && entry.TaskID != filter.TaskID {
+			continue
+		}
+		if filter.Project != "" {
+			if task := log.Task(entry); task == nil || task.Project != filter.Project {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, log, period, nil
+}
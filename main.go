@@ -2,6 +2,8 @@ package main
 
 import (
 	"embed"
+	"flag"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,12 +14,26 @@ import (
 var assets embed.FS
 
 func main() {
-	app := NewApp()
+	settings, args, err := loadSettings(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		println("Failed to load settings:", err.Error())
+		os.Exit(2)
+	}
+
+	if handled, code := runCLI(settings.Get(), args); handled {
+		os.Exit(code)
+	}
+
+	app := NewApp(settings)
+	window := settings.Get().Window
 
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "todo-list",
-		Width:  1024,
-		Height: 768,
+		Width:  window.Width,
+		Height: window.Height,
 		AssetServer: &assetserver.Options{
 			Assets: assets,
 		},
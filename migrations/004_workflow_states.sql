@@ -0,0 +1,8 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+UPDATE tasks SET status = 'todo' WHERE status = 'active';
+UPDATE tasks SET status = 'done', completed_at = COALESCE(completed_at, updated_at) WHERE status = 'completed';
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+    CHECK (status IN ('todo', 'in_progress', 'blocked', 'in_review', 'done', 'cancelled'));
@@ -0,0 +1,6 @@
+-- Ranks order tasks by plain byte comparison, hence the C collation. They
+-- replace the integer board positions of earlier builds.
+ALTER TABLE tasks DROP COLUMN IF EXISTS position;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_rank ON tasks(rank);
@@ -0,0 +1,10 @@
+-- A task waits for each blocker_id until that task is done. Blockers have
+-- no foreign key so a task can arrive before the tasks it waits for.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id VARCHAR(255) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocker_id VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (task_id, blocker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocker_id ON task_dependencies(blocker_id);
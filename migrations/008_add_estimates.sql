@@ -0,0 +1,4 @@
+-- An estimate is either a duration in minutes or story points; tasks
+-- without one have both NULL.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_points DOUBLE PRECISION;
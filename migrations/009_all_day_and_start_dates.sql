@@ -0,0 +1,4 @@
+-- All-day due dates are kept as midnight UTC of their day; start dates have
+-- no time of day at all.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_all_day BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS start_date DATE;
@@ -0,0 +1,8 @@
+// Package migrations holds the PostgreSQL schema as numbered SQL files,
+// applied in name order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
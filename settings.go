@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-list/internal/config"
+	"todo-list/internal/domain"
+
+	"golang.org/x/text/language"
+)
+
+// loadSettings opens the settings file and applies the environment and the
+// global flags, which may precede a command. It returns the remaining
+// arguments.
+func loadSettings(args []string) (*config.Store, []string, error) {
+	overrides := config.EnvOverrides()
+
+	flags := flag.NewFlagSet("todo-list", flag.ContinueOnError)
+	flags.Usage = func() { printUsage(os.Stderr) }
+	path := flags.String("config", os.Getenv("TODOLIST_CONFIG"), "")
+	flags.Func("data-dir", "", func(value string) error {
+		overrides["storage.data_dir"] = value
+		return nil
+	})
+	flags.Func("backend", "", func(value string) error {
+		overrides["storage.backend"] = value
+		return nil
+	})
+	flags.Func("set", "", func(value string) error {
+		key, setting, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", value)
+		}
+		overrides[strings.TrimSpace(key)] = strings.TrimSpace(setting)
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *path == "" {
+		*path = config.DefaultPath()
+	}
+
+	settings, err := config.Open(*path, overrides)
+	if err != nil {
+		return nil, nil, err
+	}
+	return settings, flags.Args(), nil
+}
+
+// settingsClock follows the time zone and week start in the settings, so
+// changing them applies without a restart.
+type settingsClock struct {
+	settings *config.Store
+
+	mutex    sync.Mutex
+	zone     string
+	location *time.Location
+}
+
+func newSettingsClock(settings *config.Store) *settingsClock {
+	return &settingsClock{settings: settings}
+}
+
+func (c *settingsClock) Now() time.Time {
+	cfg := c.settings.Get()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.location == nil || c.zone != cfg.Calendar.TimeZone {
+		c.zone, c.location = cfg.Calendar.TimeZone, cfg.Location()
+	}
+	return time.Now().In(c.location)
+}
+
+func (c *settingsClock) WeekStart() time.Weekday {
+	return c.settings.Get().WeekStart()
+}
+
+// sortLanguage follows the language titles are sorted for in the settings.
+func sortLanguage(settings *config.Store) func() language.Tag {
+	return func() language.Tag {
+		return settings.Get().SortLanguage()
+	}
+}
+
+// focusDurations follows the focus session lengths in the settings; changes
+// apply from the next session. The store only holds validated settings, but
+// should invalid ones slip through the defaults are used rather than zero
+// lengths.
+func focusDurations(settings *config.Store) func() domain.FocusDurations {
+	return func() domain.FocusDurations {
+		durations, err := settings.Get().FocusDurations()
+		if err != nil {
+			println("Invalid focus settings, using the defaults:", err.Error())
+			durations, _ = config.Default().FocusDurations()
+		}
+		return durations
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+
+	"todo-list/internal/config"
+	"todo-list/internal/domain"
+	"todo-list/internal/repository"
+	"todo-list/internal/service"
+	"todo-list/internal/usecase"
+
+	"golang.org/x/text/language"
+)
+
+// storage is the repository stack shared by the desktop app and the CLI.
+type storage struct {
+	dataDir   string
+	taskRepo  repository.TaskRepository
+	syncRepo  *repository.HybridTaskRepository
+	workflows domain.Workflows
+	timeLog   repository.TimeEntryRepository
+}
+
+func openStorage(cfg config.Config) *storage {
+	st := &storage{
+		dataDir: cfg.Storage.DataDir,
+	}
+
+	switch cfg.Storage.Backend {
+	case config.BackendPostgres:
+		hybridRepo, err := newHybridRepository(filepath.Join(st.dataDir, "cache"), cfg.Storage.DSN)
+		if err == nil {
+			st.taskRepo = hybridRepo
+			st.syncRepo = hybridRepo
+		} else {
+			println("Failed to set up PostgreSQL cache:", err.Error())
+		}
+
+	case config.BackendTodoTxt:
+		todoTxtRepo, err := repository.NewTodoTxtTaskRepository(cfg.TodoTxtPath())
+		if err == nil {
+			st.taskRepo = todoTxtRepo
+		} else {
+			println("Failed to open todo.txt:", err.Error())
+		}
+
+	case config.BackendMarkdown:
+		markdownRepo, err := repository.NewMarkdownTaskRepository(cfg.MarkdownPath())
+		if err == nil {
+			st.taskRepo = markdownRepo
+		} else {
+			println("Failed to open Markdown tasks:", err.Error())
+		}
+	}
+
+	workflows, err := config.LoadWorkflows(cfg.WorkflowsPath())
+	if err == nil {
+		st.workflows = workflows
+	} else {
+		println("Failed to load workflows, using the default:", err.Error())
+	}
+
+	timeLog, err := repository.NewFileTimeEntryRepository(cfg.TimeLogPath())
+	if err == nil {
+		st.timeLog = timeLog
+	} else {
+		println("Failed to open the time log, keeping it in memory:", err.Error())
+		st.timeLog = repository.NewMemoryTimeEntryRepository()
+	}
+
+	if st.taskRepo == nil {
+		fileRepo, err := repository.NewFileTaskRepository(st.dataDir)
+		if err != nil {
+			st.taskRepo = repository.NewMemoryTaskRepository()
+		} else {
+			st.taskRepo = fileRepo
+		}
+	}
+
+	return st
+}
+
+func (st *storage) taskUseCase(clock domain.Clock, language func() language.Tag) *usecase.TaskUseCase {
+	taskService := service.NewTaskService(st.taskRepo,
+		service.WithClock(clock),
+		service.WithWorkflows(st.workflows),
+		service.WithLanguage(language),
+		service.WithTimeLog(st.timeLog))
+	return usecase.NewTaskUseCase(taskService)
+}
+
+// newHybridRepository keeps a local cache in cacheDir that is synced with
+// PostgreSQL whenever the database is reachable, so the app keeps working
+// offline.
+func newHybridRepository(cacheDir, pgConnStr string) (*repository.HybridTaskRepository, error) {
+	cacheRepo, err := repository.NewFileTaskRepository(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.NewHybridTaskRepository(cacheRepo, cacheDir, func() (repository.TaskRepository, error) {
+		pgRepo, err := repository.NewPostgresTaskRepository(pgConnStr)
+		if err != nil {
+			println("Failed to connect to PostgreSQL:", err.Error())
+			return nil, err
+		}
+		return pgRepo, nil
+	})
+}